@@ -1,20 +1,43 @@
 package config
 
 import (
+	"fmt"
 	"online-library/internal/logger"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	DBHost     string `mapstructure:"DB_HOST"`
-	DBPort     string `mapstructure:"DB_PORT"`
-	DBUser     string `mapstructure:"DB_USER"`
-	DBPassword string `mapstructure:"DB_PASSWORD"`
-	DBName     string `mapstructure:"DB_NAME"`
-	APIFullURL string `mapstructure:"EXTERNAL_API_FULL_URL"`
-	ServerPort string `mapstructure:"SERVER_PORT"`
-	Method     string `mapstructure:"EXTERNAL_API_METHOD"`
+	DBHost     string        `mapstructure:"DB_HOST"`
+	DBPort     string        `mapstructure:"DB_PORT"`
+	DBUser     string        `mapstructure:"DB_USER"`
+	DBPassword string        `mapstructure:"DB_PASSWORD"`
+	DBName     string        `mapstructure:"DB_NAME"`
+	APIFullURL string        `mapstructure:"EXTERNAL_API_FULL_URL"`
+	ServerPort string        `mapstructure:"SERVER_PORT"`
+	AdminAddr  string        `mapstructure:"ADMIN_ADDR"`
+	Method     string        `mapstructure:"EXTERNAL_API_METHOD"`
+	APITimeout time.Duration `mapstructure:"EXTERNAL_API_TIMEOUT"`
+
+	APIMaxRetries       int           `mapstructure:"EXTERNAL_API_MAX_RETRIES"`
+	APIBreakerThreshold int           `mapstructure:"EXTERNAL_API_BREAKER_THRESHOLD"`
+	APIBreakerTimeout   time.Duration `mapstructure:"EXTERNAL_API_BREAKER_TIMEOUT"`
+
+	APIProviders        string        `mapstructure:"EXTERNAL_API_PROVIDERS"`
+	APICatalogFile      string        `mapstructure:"EXTERNAL_API_CATALOG_FILE"`
+	APICache            string        `mapstructure:"EXTERNAL_API_CACHE"`
+	APICacheSize        int           `mapstructure:"EXTERNAL_API_CACHE_SIZE"`
+	APICacheTTL         time.Duration `mapstructure:"EXTERNAL_API_CACHE_TTL"`
+	APICacheNegativeTTL time.Duration `mapstructure:"EXTERNAL_API_CACHE_NEGATIVE_TTL"`
+
+	EnrichmentWorkers      int           `mapstructure:"ENRICHMENT_WORKERS"`
+	EnrichmentPollInterval time.Duration `mapstructure:"ENRICHMENT_POLL_INTERVAL"`
+	EnrichmentMaxAttempts  int           `mapstructure:"ENRICHMENT_MAX_ATTEMPTS"`
+	EnrichmentRetryBackoff time.Duration `mapstructure:"ENRICHMENT_RETRY_BACKOFF"`
+
+	TrashRetention     time.Duration `mapstructure:"TRASH_RETENTION"`
+	TrashPurgeInterval time.Duration `mapstructure:"TRASH_PURGE_INTERVAL"`
 }
 
 func LoadConfig() (*Config, error) {
@@ -24,6 +47,24 @@ func LoadConfig() (*Config, error) {
 
 	viper.AutomaticEnv()
 
+	// Значения по умолчанию для необязательных параметров
+	viper.SetDefault("EXTERNAL_API_TIMEOUT", "10s")
+	viper.SetDefault("EXTERNAL_API_MAX_RETRIES", 2)
+	viper.SetDefault("EXTERNAL_API_BREAKER_THRESHOLD", 5)
+	viper.SetDefault("EXTERNAL_API_BREAKER_TIMEOUT", "30s")
+	viper.SetDefault("EXTERNAL_API_PROVIDERS", "http")
+	viper.SetDefault("EXTERNAL_API_CACHE", "memory")
+	viper.SetDefault("EXTERNAL_API_CACHE_SIZE", 1000)
+	viper.SetDefault("EXTERNAL_API_CACHE_TTL", "24h")
+	viper.SetDefault("EXTERNAL_API_CACHE_NEGATIVE_TTL", "1h")
+	viper.SetDefault("ENRICHMENT_WORKERS", 4)
+	viper.SetDefault("ENRICHMENT_POLL_INTERVAL", "2s")
+	viper.SetDefault("ENRICHMENT_MAX_ATTEMPTS", 5)
+	viper.SetDefault("ENRICHMENT_RETRY_BACKOFF", "30s")
+	viper.SetDefault("TRASH_RETENTION", "720h")
+	viper.SetDefault("TRASH_PURGE_INTERVAL", "1h")
+	viper.SetDefault("ADMIN_ADDR", "localhost:8081")
+
 	if err := viper.ReadInConfig(); err != nil {
 		logger.Log.Fatalf("error reading config file: %v", err)
 	}
@@ -36,6 +77,20 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	if err := config.validate(); err != nil {
+		logger.Log.Errorf("Invalid configuration: %v", err)
+		return nil, err
+	}
+
 	logger.Log.Info("Configuration loaded successfully")
 	return &config, nil
 }
+
+// validate проверяет параметры, ошибка в которых приведет к потере данных
+func (c *Config) validate() error {
+	// При нулевом сроке хранения первая же очистка удалила бы всю корзину
+	if c.TrashRetention <= 0 {
+		return fmt.Errorf("TRASH_RETENTION must be positive, got %v", c.TrashRetention)
+	}
+	return nil
+}
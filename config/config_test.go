@@ -0,0 +1,23 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateTrashRetention(t *testing.T) {
+	tests := []struct {
+		retention time.Duration
+		wantErr   bool
+	}{
+		{720 * time.Hour, false},
+		{0, true},
+		{-time.Hour, true},
+	}
+	for _, tt := range tests {
+		cfg := Config{TrashRetention: tt.retention}
+		if err := cfg.validate(); (err != nil) != tt.wantErr {
+			t.Errorf("validate() with retention %v: error = %v, wantErr %v", tt.retention, err, tt.wantErr)
+		}
+	}
+}
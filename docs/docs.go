@@ -15,9 +15,9 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/songs": {
+        "/artists": {
             "get": {
-                "description": "Получение списка песен с возможностью фильтрации по группе и названию.",
+                "description": "Получение списка артистов с возможностью фильтрации по имени.",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,22 +25,15 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "songs"
+                    "artists"
                 ],
-                "summary": "Get Songs",
+                "summary": "Get Artists",
                 "parameters": [
                     {
                         "type": "string",
                         "example": "\"Queen\"",
-                        "description": "Название группы",
-                        "name": "group",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "example": "\"Bohemian Rhapsody\"",
-                        "description": "Название песни",
-                        "name": "title",
+                        "description": "Часть имени артиста, без учета регистра; % и _ ищутся как обычные символы",
+                        "name": "name",
                         "in": "query"
                     },
                     {
@@ -51,6 +44,7 @@ const docTemplate = `{
                         "in": "query"
                     },
                     {
+                        "maximum": 100,
                         "type": "integer",
                         "default": 10,
                         "description": "Количество элементов на странице",
@@ -60,36 +54,24 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Список песен",
+                        "description": "Список артистов",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Song"
-                            }
-                        }
-                    },
-                    "400": {
-                        "description": "Ошибочные параметры запроса",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                                "$ref": "#/definitions/models.Artist"
                             }
                         }
                     },
                     "500": {
                         "description": "Ошибка сервера",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Добавление новой песни в базу данных. Данные о песне подтягиваются из внешнего API.",
+                "description": "Добавление нового артиста.",
                 "consumes": [
                     "application/json"
                 ],
@@ -97,23 +79,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "songs"
+                    "artists"
                 ],
-                "summary": "Add Song",
+                "summary": "Add Artist",
                 "parameters": [
                     {
-                        "description": "Данные песни",
-                        "name": "song",
+                        "description": "Данные артиста",
+                        "name": "artist",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Song"
+                            "$ref": "#/definitions/models.Artist"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "ID добавленной песни",
+                        "description": "ID добавленного артиста",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -124,27 +106,91 @@ const docTemplate = `{
                     "400": {
                         "description": "Ошибочный запрос",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Артист с таким именем уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     },
                     "500": {
                         "description": "Ошибка сервера",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     }
                 }
             }
         },
-        "/songs/{id}": {
+        "/artists/{id}": {
+            "get": {
+                "description": "Получение артиста по ID: биография и постраничная дискография.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "artists"
+                ],
+                "summary": "Get Artist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID артиста",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы дискографии",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество песен на странице",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Артист и его песни",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ResponseArtist"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибочные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Артист не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
             "put": {
-                "description": "Обновление данных песни в базе по её ID.",
+                "description": "Обновление имени и биографии артиста по его ID. Переименование меняет версии песен артиста и записывается в историю каждой песни.",
                 "consumes": [
                     "application/json"
                 ],
@@ -152,66 +198,69 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "songs"
+                    "artists"
                 ],
-                "summary": "Update Song",
+                "summary": "Update Artist",
                 "parameters": [
                     {
                         "type": "integer",
                         "example": 1,
-                        "description": "ID песни",
+                        "description": "ID артиста",
                         "name": "id",
-                        "in": "query",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Обновленные данные песни",
-                        "name": "song",
+                        "description": "Обновленные данные артиста",
+                        "name": "artist",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Song"
+                            "$ref": "#/definitions/models.Artist"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Автор изменения для истории песен, указывается клиентом и не проверяется",
+                        "name": "X-Author",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Песня успешно обновлена",
+                        "description": "Артист успешно обновлен",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handlers.StatusResponse"
                         }
                     },
                     "400": {
                         "description": "Ошибочный запрос",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     },
                     "404": {
-                        "description": "Песня не найдена",
+                        "description": "Артист не найден",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Артист с таким именем уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     },
                     "500": {
                         "description": "Ошибка сервера",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     }
                 }
             },
             "delete": {
-                "description": "Удаление песни из базы данных по её ID.",
+                "description": "Удаление артиста по ID. Песни артиста удаляются каскадно, поэтому для артиста с песнями, в том числе с песнями в корзине, требуется confirm=true.",
                 "consumes": [
                     "application/json"
                 ],
@@ -219,59 +268,68 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "songs"
+                    "artists"
                 ],
-                "summary": "Delete Song",
+                "summary": "Delete Artist",
                 "parameters": [
                     {
                         "type": "integer",
                         "example": 1,
-                        "description": "ID песни",
+                        "description": "ID артиста",
                         "name": "id",
-                        "in": "query",
+                        "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Подтверждение каскадного удаления песен",
+                        "name": "confirm",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Автор изменения для истории песен, указывается клиентом и не проверяется",
+                        "name": "X-Author",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Песня успешно удалена",
+                        "description": "Артист успешно удален",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handlers.StatusResponse"
                         }
                     },
                     "400": {
                         "description": "Ошибочный запрос",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     },
                     "404": {
-                        "description": "Песня не найдена",
+                        "description": "Артист не найден",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Требуется подтверждение удаления песен",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     },
                     "500": {
                         "description": "Ошибка сервера",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     }
                 }
             }
         },
-        "/songs/{id}/lyrics": {
+        "/songs": {
             "get": {
-                "description": "Получение текста песни по ID с возможностью разбивки на страницы.",
+                "description": "Получение списка песен с фильтрацией и сортировкой. Все фильтры необязательны. Поддерживается пагинация номером страницы и курсором (keyset): курсор не дает дублей и пропусков при вставке новых песен.",
                 "consumes": [
                     "application/json"
                 ],
@@ -281,15 +339,66 @@ const docTemplate = `{
                 "tags": [
                     "songs"
                 ],
-                "summary": "Get Song Lyrics",
+                "summary": "Get Songs",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "example": 1,
-                        "description": "ID песни",
-                        "name": "id",
-                        "in": "query",
-                        "required": true
+                        "type": "string",
+                        "example": "\"Queen\"",
+                        "description": "Название группы",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"Bohemian Rhapsody\"",
+                        "description": "Название песни",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "substring",
+                            "exact"
+                        ],
+                        "type": "string",
+                        "default": "substring",
+                        "description": "Сравнение group и title: подстрока или точное совпадение",
+                        "name": "match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"Rock\"",
+                        "description": "Жанр",
+                        "name": "genre",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"1970-01-01\"",
+                        "description": "Дата выпуска не раньше (YYYY-MM-DD или DD.MM.YYYY)",
+                        "name": "released_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"1999-12-31\"",
+                        "description": "Дата выпуска не позже (YYYY-MM-DD или DD.MM.YYYY)",
+                        "name": "released_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только песни с текстом (true) или без текста (false)",
+                        "name": "has_lyrics",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"-release_date,song\"",
+                        "description": "Сортировка: поля через запятую, минус - по убыванию. Поля: id, song, group, genre, release_date",
+                        "name": "sort",
+                        "in": "query"
                     },
                     {
                         "type": "integer",
@@ -299,60 +408,2066 @@ const docTemplate = `{
                         "in": "query"
                     },
                     {
+                        "maximum": 100,
                         "type": "integer",
-                        "default": 5,
-                        "description": "Количество куплетов на странице",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
                         "name": "limit",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор из next_cursor предыдущего ответа; заменяет page",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Текст песни с пагинацией",
+                        "description": "Страница списка песен",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ResponseLyrics"
+                            "$ref": "#/definitions/handlers.SongListResponse"
                         }
                     },
                     "400": {
                         "description": "Ошибочные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Добавление новой песни в базу данных. Артист находится по имени группы или создается, данные о песне подтягиваются из внешнего API.\nС async=true песня сохраняется сразу со статусом enrichment_status=pending, а данные загружаются в фоне; статус можно узнать через GET /songs/{id}.\nС source=manual сохраняются данные клиента (release_date, lyrics, link) без обращения к внешнему API; с fill_missing=true внешний API дополняет только незаполненные поля.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Add Song",
+                "parameters": [
+                    {
+                        "description": "Данные песни",
+                        "name": "song",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Song"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Сохранить песню сразу и загрузить данные из внешнего API в фоне",
+                        "name": "async",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "external",
+                            "manual"
+                        ],
+                        "type": "string",
+                        "default": "external",
+                        "description": "Откуда брать данные песни: из внешнего API или из запроса",
+                        "name": "source",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Для source=manual: дополнить незаполненные поля из внешнего API",
+                        "name": "fill_missing",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "ID добавленной песни (для source=manual - и enrichment_status)",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Песня не найдена",
+                    "202": {
+                        "description": "Песня сохранена, данные загружаются в фоне",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "additionalProperties": true
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "Адрес песни для проверки статуса"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Ошибочный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена во внешнем API",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
                     "500": {
                         "description": "Ошибка сервера",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/handlers.Problem"
                         }
-                    }
-                }
-            }
-        }
+                    },
+                    "502": {
+                        "description": "Внешний API ответил ошибкой",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Запросы к внешнему API временно приостановлены",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/batch": {
+            "post": {
+                "description": "Удаление (action=delete) или частичное обновление (action=patch, поле patch в формате JSON Merge Patch) песен из списка ids или под фильтром filter (ключи - query-параметры GET /songs, нужен хотя бы один фильтр).\nВсе изменения выполняются одной транзакцией, в ответе - итог по каждой песне. С atomic=true ошибка по любой песне отменяет всю операцию. За раз можно изменить не больше 1000 песен.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Batch Songs",
+                "parameters": [
+                    {
+                        "description": "Пакетная операция",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Итог по каждой песне",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибочный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/export": {
+            "get": {
+                "description": "Потоковая выгрузка всех песен под фильтром в JSON (массив), CSV или JSON Lines. Фильтры и сортировка - как в GET /songs, пагинации нет. CSV-выгрузку можно загрузить обратно через POST /songs/import.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Export Songs",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "Формат выгрузки",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"Queen\"",
+                        "description": "Название группы",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"Bohemian Rhapsody\"",
+                        "description": "Название песни",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "substring",
+                            "exact"
+                        ],
+                        "type": "string",
+                        "default": "substring",
+                        "description": "Сравнение group и title: подстрока или точное совпадение",
+                        "name": "match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"Rock\"",
+                        "description": "Жанр",
+                        "name": "genre",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"1970-01-01\"",
+                        "description": "Дата выпуска не раньше (YYYY-MM-DD или DD.MM.YYYY)",
+                        "name": "released_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"1999-12-31\"",
+                        "description": "Дата выпуска не позже (YYYY-MM-DD или DD.MM.YYYY)",
+                        "name": "released_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только песни с текстом (true) или без текста (false)",
+                        "name": "has_lyrics",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"-release_date,song\"",
+                        "description": "Сортировка: поля через запятую, минус - по убыванию. Поля: id, song, group, genre, release_date",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песни под фильтром",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Song"
+                            }
+                        },
+                        "headers": {
+                            "Content-Disposition": {
+                                "type": "string",
+                                "description": "Имя файла выгрузки"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибочные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/import": {
+            "post": {
+                "description": "Потоковый импорт песен. Формат определяется по Content-Type (text/csv или application/x-ndjson) либо параметру format.\nCSV должен начинаться со строки заголовка с колонками group, song, genre, release_date, lyrics, link (обязательны group и song, колонка id из выгрузки игнорируется); в JSON Lines каждая строка - объект песни.\nДанные из внешнего API не запрашиваются; песня, которая уже есть у артиста или повторяется в файле, пропускается. Ответ содержит итог по каждой строке.\nСтроки сохраняются порциями по 500. Тело ограничено 32 МБ и 50000 строками. Если импорт прервался на середине, ответ об ошибке содержит отчет report: уже сохраненные строки в нем отмечены created, строки прерванной порции - failed.",
+                "consumes": [
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Import Songs",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "description": "Формат данных, если Content-Type его не определяет",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только проверить данные, ничего не сохраняя",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Отчет об импорте",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибочные данные или параметры",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ImportAbortedProblem"
+                        }
+                    },
+                    "413": {
+                        "description": "Превышен размер или число строк",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ImportAbortedProblem"
+                        }
+                    },
+                    "415": {
+                        "description": "Неподдерживаемый формат",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ImportAbortedProblem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/search": {
+            "get": {
+                "description": "Полнотекстовый поиск по тексту, названию песни и группе. Результаты упорядочены по релевантности, в snippet совпадения выделены тегами \u003cb\u003e\u003c/b\u003e. Поддерживается синтаксис web-поиска: \"точная фраза\", OR, -исключение.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Search Songs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"love\"",
+                        "description": "Поисковый запрос",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница найденных песен",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SongSearchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибочные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/trash": {
+            "get": {
+                "description": "Список удаленных песен, последние удаленные идут первыми. Песни хранятся в корзине ограниченное время, после чего удаляются окончательно.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Get Trash",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница корзины",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SongListResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}": {
+            "get": {
+                "description": "Получение метаданных песни по ID. Поле enrichment_status показывает, загружены ли данные из внешнего API: pending, processing, done, failed (будет повтор) или dead (попытки исчерпаны).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Get Song",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Данные песни",
+                        "schema": {
+                            "$ref": "#/definitions/models.Song"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия песни"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибочные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Полная замена данных песни по её ID: непереданные поля очищаются. Для частичного обновления используйте PATCH.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Update Song",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни, полученный при чтении",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Обновленные данные песни",
+                        "name": "song",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Song"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песня успешно обновлена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибочный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Песня изменена другим клиентом",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаление песни по её ID. Песня перемещается в корзину (GET /songs/trash), откуда ее можно восстановить через POST /songs/{id}/restore, пока не истек срок хранения.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Delete Song",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни, полученный при чтении",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песня успешно удалена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибочный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Песня изменена другим клиентом",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Частичное обновление песни телом JSON Merge Patch (RFC 7386): переданные поля заменяются, null очищает поле, остальные поля не меняются. Поля group и song нельзя очистить.",
+                "consumes": [
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Patch Song",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни, полученный при чтении",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Изменяемые поля песни",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Song"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песня после изменения",
+                        "schema": {
+                            "$ref": "#/definitions/models.Song"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибочный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Песня изменена другим клиентом",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Неподдерживаемый тип содержимого",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/enrichment": {
+            "post": {
+                "description": "Сбрасывает счетчик попыток и возвращает песню в очередь фоновой загрузки данных, например после перехода в состояние dead.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Requeue Song Enrichment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Песня поставлена в очередь",
+                        "schema": {
+                            "$ref": "#/definitions/models.Song"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибочный ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Данные песни уже загружаются",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/lyrics": {
+            "get": {
+                "description": "Получение текста песни по ID с возможностью разбивки на страницы. Текст разбирается на куплеты (verse, chorus, bridge и т. д.) по пустым строкам и заголовкам вида [Chorus]; строки пронумерованы по всей песне.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/plain",
+                    "text/markdown",
+                    "text/html"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Get Song Lyrics",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "application/json",
+                        "description": "Представление текста: application/json, text/plain, text/markdown или text/html; страницы куплетов одинаковы для всех",
+                        "name": "Accept",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "example": "en",
+                        "description": "Языки перевода через запятую в порядке предпочтения; важнее Accept-Language",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Предпочитаемые языки текста; без перевода отдается оригинал",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "json-timed",
+                            "lrc"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "json - куплеты; json-timed - строки с временными метками; lrc - файл LRC целиком",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "type": "integer",
+                        "default": 5,
+                        "description": "Количество куплетов на странице (для json-timed - строк, по умолчанию 50)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Строки с временными метками (format=json-timed)",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TimedLyricsResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия песни, перевода и формата текста"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибочные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "Ни одно представление не подходит под Accept",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/lyrics/lines": {
+            "get": {
+                "description": "Строки текста песни с номерами от from до to включительно. Строки нумеруются с 1 по всей песне без заголовков и пустых строк; to за концом текста обрезается.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Get Lyrics Lines",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Языки перевода через запятую; важнее Accept-Language",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер первой строки",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер последней строки; по умолчанию до конца текста",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Строки текста",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LinesResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия песни и перевода"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибочный диапазон строк",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня или текст не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/lyrics/stanzas/{index}": {
+            "get": {
+                "description": "Куплет (или припев, бридж) песни по номеру с видом части и номерами строк.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Get Lyrics Stanza",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Языки перевода через запятую; важнее Accept-Language",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 2,
+                        "description": "Номер куплета, с 1",
+                        "name": "index",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Куплет песни",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StanzaResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия песни и перевода"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибочные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня, текст или куплет не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/lyrics/synced": {
+            "put": {
+                "description": "Загрузка текста песни в формате LRC ([mm:ss.xx]строка). Файл проверяется: ошибки возвращаются с номерами строк. С dry_run=true файл только проверяется. Если у песни нет обычного текста, он заполняется строками из LRC.",
+                "consumes": [
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Put Synced Lyrics",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Текст в формате LRC",
+                        "name": "lrc",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только проверить файл, не сохраняя",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни, полученный ранее",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Разобранный текст с временными метками",
+                        "schema": {
+                            "$ref": "#/definitions/lyrics.Synced"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия песни"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибки в файле LRC",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия песни не совпадает с If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Файл слишком большой",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаление текста с временными метками. Обычный текст песни не меняется.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Delete Synced Lyrics",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни, полученный ранее",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Метки удалены",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StatusResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия песни"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибочный ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена или у нее нет текста с метками",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия песни не совпадает с If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/restore": {
+            "post": {
+                "description": "Восстановление удаленной песни из корзины.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Restore Song",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Восстановленная песня",
+                        "schema": {
+                            "$ref": "#/definitions/models.Song"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия песни"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибочный ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песни нет в корзине",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Песня не удалена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/revisions": {
+            "get": {
+                "description": "История изменений песни: кто, когда и какие поля поменял, последние изменения идут первыми. Автор берется из заголовка X-Author запроса, изменившего песню, и не проверяется: это имя, которое назвал клиент, поэтому история не годится как аудит. Фоновые изменения записываются от имени system. Переименование артиста записывается в историю каждой его песни с действием artist_rename.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Get Song Revisions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница истории",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RevisionListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибочный ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/revisions/diff": {
+            "get": {
+                "description": "Различия текста песни между ревизиями from и to в формате unified diff. Без to текст сравнивается с текущим. Одинаковые тексты дают пустой ответ.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Diff Song Lyrics",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID ревизии, с которой сравнивать",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID ревизии, с которой сравнивать; по умолчанию текущий текст",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 3,
+                        "description": "Сколько неизмененных строк показывать вокруг изменений",
+                        "name": "context",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Unified diff текста",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибочные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня или ревизия не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/revisions/{revision}/revert": {
+            "post": {
+                "description": "Возвращает полям песни значения, которые были у них сразу после указанной ревизии. Откат записывается в историю как новая ревизия. Переименования артиста (artist_rename) откат не отменяет: песня остается у того же артиста. Заголовок If-Match включает проверку версии.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Revert Song",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID ревизии",
+                        "name": "revision",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни, полученный ранее",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Автор изменения для истории, указывается клиентом и не проверяется",
+                        "name": "X-Author",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песня после отката",
+                        "schema": {
+                            "$ref": "#/definitions/models.Song"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия песни"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибочные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня или ревизия не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия песни не совпадает с If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/translations": {
+            "get": {
+                "description": "Все переводы текста песни. Оригинальный текст в список не входит.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Get Lyrics Translations",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Переводы текста",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.LyricsTranslation"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибочный ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/translations/{lang}": {
+            "put": {
+                "description": "Добавление перевода текста песни на язык lang или замена существующего. Язык - тег BCP 47 (en, pt-BR), хранится в нижнем регистре.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Put Lyrics Translation",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "en",
+                        "description": "Язык перевода",
+                        "name": "lang",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Текст перевода",
+                        "name": "translation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TranslationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Перевод изменен",
+                        "schema": {
+                            "$ref": "#/definitions/models.LyricsTranslation"
+                        }
+                    },
+                    "201": {
+                        "description": "Перевод добавлен",
+                        "schema": {
+                            "$ref": "#/definitions/models.LyricsTranslation"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибочные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаление перевода текста песни на язык lang.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Delete Lyrics Translation",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "en",
+                        "description": "Язык перевода",
+                        "name": "lang",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Перевод удален",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибочные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Перевод не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        }
     },
     "definitions": {
+        "handlers.BatchRequest": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "операция",
+                    "type": "string",
+                    "enum": [
+                        "delete",
+                        "patch"
+                    ]
+                },
+                "atomic": {
+                    "description": "отменить все изменения, если хотя бы одна песня не изменилась",
+                    "type": "boolean"
+                },
+                "filter": {
+                    "description": "фильтр в формате query-параметров GET /songs",
+                    "type": "object"
+                },
+                "ids": {
+                    "description": "ID песен; взаимоисключается с filter",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "patch": {
+                    "description": "JSON Merge Patch для action=patch",
+                    "type": "object"
+                }
+            }
+        },
+        "handlers.BatchResponse": {
+            "type": "object",
+            "properties": {
+                "committed": {
+                    "description": "изменения сохранены",
+                    "type": "boolean"
+                },
+                "results": {
+                    "description": "итог по каждой песне",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repository.BatchResult"
+                    }
+                }
+            }
+        },
+        "handlers.ImportAbortedProblem": {
+            "description": "Ошибка application/problem+json с отчетом о строках, обработанных до нее: порции, вошедшие в отчет со статусом created, уже сохранены.",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "машиночитаемый код ошибки",
+                    "type": "string",
+                    "example": "not_found"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "song with ID 1 not found"
+                },
+                "instance": {
+                    "description": "путь запроса",
+                    "type": "string",
+                    "example": "/songs/1"
+                },
+                "report": {
+                    "$ref": "#/definitions/handlers.ImportReport"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "3f2b8c0e9a1d4e7f"
+                },
+                "status": {
+                    "description": "HTTP-статус",
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "description": "описание HTTP-статуса",
+                    "type": "string",
+                    "example": "Not Found"
+                },
+                "type": {
+                    "description": "тип ошибки; about:blank - смысл ошибки передает статус",
+                    "type": "string",
+                    "example": "about:blank"
+                }
+            }
+        },
+        "handlers.ImportReport": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "description": "изменения не сохранены",
+                    "type": "boolean"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "rows": {
+                    "description": "итог по каждой строке, по возрастанию номера строки",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repository.ImportResult"
+                    }
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.LinesResponse": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "description": "номер первой строки",
+                    "type": "integer"
+                },
+                "lang": {
+                    "description": "язык перевода, нет у оригинального текста",
+                    "type": "string"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lyrics.Line"
+                    }
+                },
+                "song": {
+                    "description": "название песни",
+                    "type": "string"
+                },
+                "song_id": {
+                    "description": "id песни",
+                    "type": "integer"
+                },
+                "to": {
+                    "description": "номер последней строки",
+                    "type": "integer"
+                },
+                "total_lines": {
+                    "description": "всего строк текста",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.PageLinks": {
+            "type": "object",
+            "properties": {
+                "next": {
+                    "type": "string"
+                },
+                "prev": {
+                    "type": "string"
+                },
+                "self": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.Problem": {
+            "description": "Ошибка в формате application/problem+json с машиночитаемым кодом и ID запроса.",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "машиночитаемый код ошибки",
+                    "type": "string",
+                    "example": "not_found"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "song with ID 1 not found"
+                },
+                "instance": {
+                    "description": "путь запроса",
+                    "type": "string",
+                    "example": "/songs/1"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "3f2b8c0e9a1d4e7f"
+                },
+                "status": {
+                    "description": "HTTP-статус",
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "description": "описание HTTP-статуса",
+                    "type": "string",
+                    "example": "Not Found"
+                },
+                "type": {
+                    "description": "тип ошибки; about:blank - смысл ошибки передает статус",
+                    "type": "string",
+                    "example": "about:blank"
+                }
+            }
+        },
+        "handlers.ResponseArtist": {
+            "type": "object",
+            "properties": {
+                "artist_id": {
+                    "type": "integer"
+                },
+                "bio": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "page": {
+                    "description": "страница",
+                    "type": "integer"
+                },
+                "page_size": {
+                    "description": "размер страницы",
+                    "type": "integer"
+                },
+                "songs": {
+                    "description": "дискография",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Song"
+                    }
+                },
+                "songs_count": {
+                    "description": "всего песен у артиста",
+                    "type": "integer"
+                }
+            }
+        },
         "handlers.ResponseLyrics": {
             "type": "object",
             "properties": {
-                "lyrics": {
-                    "description": "текст песни",
+                "lang": {
+                    "description": "язык перевода, нет у оригинального текста",
+                    "type": "string"
+                },
+                "lyrics": {
+                    "description": "текст куплетов страницы без заголовков",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "page": {
+                    "description": "страница",
+                    "type": "integer"
+                },
+                "page_size": {
+                    "description": "размер страницы",
+                    "type": "integer"
+                },
+                "sections": {
+                    "description": "куплеты страницы с видом и номерами строк",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lyrics.Section"
+                    }
+                },
+                "song": {
+                    "description": "название песни",
+                    "type": "string"
+                },
+                "song_id": {
+                    "description": "id песни",
+                    "type": "string"
+                },
+                "total_lines": {
+                    "description": "всего строк текста",
+                    "type": "integer"
+                },
+                "total_stanzas": {
+                    "description": "всего куплетов",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.RevisionListResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "description": "ревизии, последние идут первыми",
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/models.SongRevision"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "links": {
+                    "$ref": "#/definitions/handlers.PageLinks"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "всего ревизий у песни",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.SongListResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "description": "песни текущей страницы",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Song"
+                    }
+                },
+                "limit": {
+                    "description": "размер страницы",
+                    "type": "integer"
+                },
+                "links": {
+                    "$ref": "#/definitions/handlers.PageLinks"
+                },
+                "next_cursor": {
+                    "description": "курсор следующей страницы",
+                    "type": "string"
+                },
+                "page": {
+                    "description": "номер страницы, не указывается при пагинации курсором",
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "всего песен под фильтром",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.SongSearchResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "description": "найденные песни текущей страницы",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SongSearchResult"
+                    }
+                },
+                "limit": {
+                    "description": "размер страницы",
+                    "type": "integer"
+                },
+                "links": {
+                    "$ref": "#/definitions/handlers.PageLinks"
+                },
+                "page": {
+                    "description": "номер страницы",
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "всего найдено песен",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.StanzaResponse": {
+            "type": "object",
+            "properties": {
+                "lang": {
+                    "description": "язык перевода, нет у оригинального текста",
+                    "type": "string"
+                },
+                "song": {
+                    "description": "название песни",
+                    "type": "string"
+                },
+                "song_id": {
+                    "description": "id песни",
+                    "type": "integer"
+                },
+                "stanza": {
+                    "$ref": "#/definitions/lyrics.Section"
+                },
+                "total_stanzas": {
+                    "description": "всего куплетов",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.StatusResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Song updated successfully"
+                },
+                "status": {
+                    "description": "updated, deleted",
+                    "type": "string",
+                    "example": "updated"
+                }
+            }
+        },
+        "handlers.TimedLyricsResponse": {
+            "type": "object",
+            "properties": {
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lyrics.TimedLine"
                     }
                 },
                 "page": {
@@ -369,7 +2484,141 @@ const docTemplate = `{
                 },
                 "song_id": {
                     "description": "id песни",
+                    "type": "integer"
+                },
+                "tags": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "total_lines": {
+                    "description": "всего строк с метками",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.TranslationRequest": {
+            "type": "object",
+            "properties": {
+                "lyrics": {
+                    "type": "string"
+                }
+            }
+        },
+        "lyrics.Line": {
+            "type": "object",
+            "properties": {
+                "number": {
+                    "description": "номер строки в песне, с 1",
+                    "type": "integer"
+                },
+                "section": {
+                    "description": "номер части, в которую входит строка",
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "lyrics.Section": {
+            "type": "object",
+            "properties": {
+                "index": {
+                    "description": "номер части в песне, с 1",
+                    "type": "integer"
+                },
+                "kind": {
+                    "description": "verse, chorus, pre-chorus, bridge, intro, outro, other",
+                    "type": "string"
+                },
+                "label": {
+                    "description": "заголовок части как в тексте, например [Chorus]",
+                    "type": "string"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lyrics.Line"
+                    }
+                },
+                "number": {
+                    "description": "номер куплета, у остальных частей - если указан в заголовке",
+                    "type": "integer"
+                }
+            }
+        },
+        "lyrics.Synced": {
+            "description": "Строки текста со временем начала для караоке.",
+            "type": "object",
+            "properties": {
+                "lines": {
+                    "description": "строки по возрастанию времени",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lyrics.TimedLine"
+                    }
+                },
+                "tags": {
+                    "description": "теги LRC: ar - исполнитель, ti - название, al - альбом и т. д.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "lyrics.TimedLine": {
+            "type": "object",
+            "properties": {
+                "text": {
+                    "description": "пустая строка означает паузу или конец куплета",
+                    "type": "string"
+                },
+                "time": {
+                    "description": "то же время в виде mm:ss.xx",
+                    "type": "string"
+                },
+                "time_ms": {
+                    "description": "время от начала песни в миллисекундах",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Artist": {
+            "description": "Модель исполнителя, к которому привязаны песни.",
+            "type": "object",
+            "properties": {
+                "artist_id": {
+                    "type": "integer"
+                },
+                "bio": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LyricsTranslation": {
+            "description": "Текст песни на одном из языков. Язык - тег BCP 47 в нижнем регистре.",
+            "type": "object",
+            "properties": {
+                "lang": {
+                    "type": "string",
+                    "example": "en"
+                },
+                "lyrics": {
                     "type": "string"
+                },
+                "updated_at": {
+                    "description": "RFC 3339",
+                    "type": "string"
+                },
+                "version": {
+                    "description": "меняется при каждом изменении перевода",
+                    "type": "integer"
                 }
             }
         },
@@ -377,23 +2626,176 @@ const docTemplate = `{
             "description": "Модель песни с основными атрибутами.",
             "type": "object",
             "properties": {
+                "artist_id": {
+                    "type": "integer"
+                },
+                "deleted_at": {
+                    "description": "когда песня перемещена в корзину, RFC 3339",
+                    "type": "string"
+                },
+                "enrichment_error": {
+                    "description": "последняя ошибка загрузки",
+                    "type": "string"
+                },
+                "enrichment_status": {
+                    "description": "состояние загрузки данных из внешнего API",
+                    "type": "string"
+                },
+                "genre": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "link": {
+                    "description": "ссылка на видео",
+                    "type": "string"
+                },
+                "lyrics": {
+                    "type": "string"
+                },
+                "release_date": {
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "version": {
+                    "description": "версия записи, отдается клиенту как ETag",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SongRevision": {
+            "description": "Изменение песни: кто, когда и какие поля поменял. В old и new попадают только измененные поля.",
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "author": {
+                    "description": "из X-Author, указывается клиентом и не проверяется",
+                    "type": "string"
+                },
+                "changed_at": {
+                    "description": "RFC 3339",
+                    "type": "string"
+                },
+                "new": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "old": {
+                    "description": "прежние значения, нет у create",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "revision_id": {
+                    "type": "integer"
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "version": {
+                    "description": "версия песни после изменения",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SongSearchResult": {
+            "description": "Песня с релевантностью и фрагментом текста, в котором подсвечены совпадения.",
+            "type": "object",
+            "properties": {
+                "artist_id": {
+                    "type": "integer"
+                },
+                "deleted_at": {
+                    "description": "когда песня перемещена в корзину, RFC 3339",
+                    "type": "string"
+                },
+                "enrichment_error": {
+                    "description": "последняя ошибка загрузки",
+                    "type": "string"
+                },
+                "enrichment_status": {
+                    "description": "состояние загрузки данных из внешнего API",
+                    "type": "string"
+                },
+                "genre": {
+                    "type": "string"
+                },
                 "group": {
                     "type": "string"
                 },
                 "link": {
+                    "description": "ссылка на видео",
                     "type": "string"
                 },
                 "lyrics": {
                     "type": "string"
                 },
+                "rank": {
+                    "description": "релевантность",
+                    "type": "number"
+                },
                 "release_date": {
                     "type": "string"
                 },
+                "snippet": {
+                    "description": "экранированный для HTML фрагмент с совпадениями, выделенными \u003cb\u003e\u003c/b\u003e",
+                    "type": "string"
+                },
                 "song": {
                     "type": "string"
                 },
                 "song_id": {
                     "type": "integer"
+                },
+                "version": {
+                    "description": "версия записи, отдается клиенту как ETag",
+                    "type": "integer"
+                }
+            }
+        },
+        "repository.BatchResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "версия песни после изменения",
+                    "type": "integer"
+                }
+            }
+        },
+        "repository.ImportResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
                 }
             }
         }
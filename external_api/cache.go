@@ -0,0 +1,180 @@
+package externalapi
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"net/http"
+	"online-library/internal/logger"
+	"online-library/internal/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheEntry сохраненный ответ внешнего API.
+// Detail = nil означает, что внешний API ответил 404.
+type CacheEntry struct {
+	Detail    *models.SongDetail
+	ExpiresAt time.Time
+}
+
+// CacheStore хранилище ответов внешнего API
+type CacheStore interface {
+	// Get возвращает nil без ошибки, если записи нет
+	Get(ctx context.Context, key string) (*CacheEntry, error)
+	Set(ctx context.Context, key string, entry CacheEntry) error
+}
+
+// CacheConfig параметры кэша
+type CacheConfig struct {
+	TTL         time.Duration // сколько хранится найденная песня
+	NegativeTTL time.Duration // сколько хранится ответ 404; 0 отключает кэширование 404
+}
+
+// CachedClient оборачивает ExternalAPI кэшем ответов
+type CachedClient struct {
+	next  ExternalAPI
+	store CacheStore
+	cfg   CacheConfig
+	now   func() time.Time
+}
+
+func NewCachedClient(next ExternalAPI, store CacheStore, cfg CacheConfig) *CachedClient {
+	return &CachedClient{next: next, store: store, cfg: cfg, now: time.Now}
+}
+
+// cacheKey приводит group и song к виду, в котором одна и та же песня, набранная по-разному, дает один ключ:
+// регистр не учитывается, пробелы по краям отбрасываются, повторяющиеся пробелы схлопываются
+func cacheKey(group string, song string) string {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.Join(strings.Fields(s), " "))
+	}
+	// После normalize в строках не остается табуляций, поэтому ключ однозначен
+	return normalize(group) + "\t" + normalize(song)
+}
+
+func (c *CachedClient) GetSongDetails(ctx context.Context, group string, song string) (*models.SongDetail, error) {
+	key := cacheKey(group, song)
+
+	// Ошибка хранилища не должна мешать запросу к внешнему API
+	entry, err := c.store.Get(ctx, key)
+	if err != nil {
+		logger.Log.Errorf("Failed to read external API cache: %v", err)
+	}
+	if entry != nil && c.now().Before(entry.ExpiresAt) {
+		metrics.Add("cache_hits", 1)
+		if entry.Detail == nil {
+			logger.Log.Debugf("External API cache hit (not found) for group=%q song=%q", group, song)
+			return nil, &APIError{StatusCode: http.StatusNotFound, Status: "404 Not Found (cached)"}
+		}
+		logger.Log.Debugf("External API cache hit for group=%q song=%q", group, song)
+		detail := *entry.Detail
+		return &detail, nil
+	}
+	metrics.Add("cache_misses", 1)
+
+	detail, err := c.next.GetSongDetails(ctx, group, song)
+	switch {
+	case err == nil:
+		c.set(ctx, key, CacheEntry{Detail: detail, ExpiresAt: c.now().Add(c.cfg.TTL)})
+	case errors.Is(err, ErrNotFound) && c.cfg.NegativeTTL > 0:
+		c.set(ctx, key, CacheEntry{ExpiresAt: c.now().Add(c.cfg.NegativeTTL)})
+	}
+	return detail, err
+}
+
+func (c *CachedClient) set(ctx context.Context, key string, entry CacheEntry) {
+	if entry.Detail != nil {
+		detail := *entry.Detail
+		entry.Detail = &detail
+	}
+	if err := c.store.Set(ctx, key, entry); err != nil {
+		logger.Log.Errorf("Failed to write external API cache: %v", err)
+	}
+}
+
+// MemoryCache хранит не больше capacity записей в памяти, вытесняя самые давно использованные
+type MemoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List // в начале - последние использованные
+}
+
+// memoryItem элемент списка order
+type memoryItem struct {
+	key   string
+	entry CacheEntry
+}
+
+func NewMemoryCache(capacity int) *MemoryCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &MemoryCache{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (m *MemoryCache) Get(ctx context.Context, key string) (*CacheEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elem, ok := m.items[key]
+	if !ok {
+		return nil, nil
+	}
+	m.order.MoveToFront(elem)
+	entry := elem.Value.(*memoryItem).entry
+	return &entry, nil
+}
+
+func (m *MemoryCache) Set(ctx context.Context, key string, entry CacheEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if elem, ok := m.items[key]; ok {
+		elem.Value.(*memoryItem).entry = entry
+		m.order.MoveToFront(elem)
+		return nil
+	}
+
+	m.items[key] = m.order.PushFront(&memoryItem{key: key, entry: entry})
+	if m.order.Len() > m.capacity {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.items, oldest.Value.(*memoryItem).key)
+	}
+	return nil
+}
+
+// TieredCache читает сначала из быстрого хранилища, затем из медленного, и пишет в оба
+type TieredCache struct {
+	front CacheStore
+	back  CacheStore
+}
+
+func NewTieredCache(front CacheStore, back CacheStore) *TieredCache {
+	return &TieredCache{front: front, back: back}
+}
+
+func (t *TieredCache) Get(ctx context.Context, key string) (*CacheEntry, error) {
+	if entry, err := t.front.Get(ctx, key); entry != nil || err != nil {
+		return entry, err
+	}
+	entry, err := t.back.Get(ctx, key)
+	if entry != nil {
+		t.front.Set(ctx, key, *entry)
+	}
+	return entry, err
+}
+
+func (t *TieredCache) Set(ctx context.Context, key string, entry CacheEntry) error {
+	if err := t.front.Set(ctx, key, entry); err != nil {
+		return err
+	}
+	return t.back.Set(ctx, key, entry)
+}
@@ -0,0 +1,72 @@
+package externalapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"online-library/internal/models"
+)
+
+// PostgresCache хранит ответы внешнего API в таблице external_api_cache, чтобы они переживали перезапуск
+type PostgresCache struct {
+	db *sql.DB
+}
+
+func NewPostgresCache(db *sql.DB) *PostgresCache {
+	return &PostgresCache{db: db}
+}
+
+func (p *PostgresCache) Get(ctx context.Context, key string) (*CacheEntry, error) {
+	var (
+		entry  CacheEntry
+		detail []byte
+	)
+	query := `SELECT detail, expires_at FROM external_api_cache WHERE key = $1`
+	err := p.db.QueryRowContext(ctx, query, key).Scan(&detail, &entry.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+
+	if detail != nil {
+		entry.Detail = &models.SongDetail{}
+		if err := json.Unmarshal(detail, entry.Detail); err != nil {
+			return nil, fmt.Errorf("failed to decode cache entry: %w", err)
+		}
+	}
+	return &entry, nil
+}
+
+func (p *PostgresCache) Set(ctx context.Context, key string, entry CacheEntry) error {
+	// JSONB передается строкой; nil записывается как NULL
+	var detail interface{}
+	if entry.Detail != nil {
+		encoded, err := json.Marshal(entry.Detail)
+		if err != nil {
+			return fmt.Errorf("failed to encode cache entry: %w", err)
+		}
+		detail = string(encoded)
+	}
+
+	query := `
+		INSERT INTO external_api_cache (key, detail, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE SET detail = EXCLUDED.detail, expires_at = EXCLUDED.expires_at
+	`
+	if _, err := p.db.ExecContext(ctx, query, key, detail, entry.ExpiresAt); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// Purge удаляет просроченные записи
+func (p *PostgresCache) Purge(ctx context.Context) (int64, error) {
+	result, err := p.db.ExecContext(ctx, `DELETE FROM external_api_cache WHERE expires_at <= now()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge cache: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,116 @@
+package externalapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func newTestCachedClient(next ExternalAPI, store CacheStore) (*CachedClient, *time.Time) {
+	now := time.Now()
+	client := NewCachedClient(next, store, CacheConfig{TTL: time.Hour, NegativeTTL: time.Minute})
+	client.now = func() time.Time { return now }
+	return client, &now
+}
+
+func TestCacheKeyNormalization(t *testing.T) {
+	want := cacheKey("Muse", "Uprising")
+	for _, tt := range [][2]string{
+		{"muse", "uprising"},
+		{"  MUSE ", "Uprising\t"},
+		{"Muse", " uprising  "},
+	} {
+		if got := cacheKey(tt[0], tt[1]); got != want {
+			t.Errorf("cacheKey(%q, %q) = %q, want %q", tt[0], tt[1], got, want)
+		}
+	}
+
+	// Граница между group и song не должна смещаться
+	if cacheKey("a b", "c") == cacheKey("a", "b c") {
+		t.Error("cacheKey is ambiguous")
+	}
+}
+
+func TestCachedClientHit(t *testing.T) {
+	stub := &stubAPI{}
+	client, now := newTestCachedClient(stub, NewMemoryCache(10))
+
+	for _, group := range []string{"Muse", "muse "} {
+		detail, err := client.GetSongDetails(context.Background(), group, "Uprising")
+		if err != nil {
+			t.Fatalf("GetSongDetails() error = %v", err)
+		}
+		if detail.Text != "lyrics" {
+			t.Errorf("Text = %q, want lyrics", detail.Text)
+		}
+	}
+	if stub.calls != 1 {
+		t.Errorf("calls = %d, want 1", stub.calls)
+	}
+
+	// По истечении TTL запрос снова уходит во внешний API
+	*now = now.Add(time.Hour)
+	if _, err := client.GetSongDetails(context.Background(), "Muse", "Uprising"); err != nil {
+		t.Fatalf("GetSongDetails() error = %v", err)
+	}
+	if stub.calls != 2 {
+		t.Errorf("calls after TTL = %d, want 2", stub.calls)
+	}
+}
+
+func TestCachedClientNegativeCaching(t *testing.T) {
+	notFound := &APIError{StatusCode: http.StatusNotFound, Status: "404 Not Found"}
+	stub := &stubAPI{errs: []error{notFound, errUnavailable}}
+	client, now := newTestCachedClient(stub, NewMemoryCache(10))
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetSongDetails(context.Background(), "Muse", "Unknown"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("call %d: error = %v, want ErrNotFound", i, err)
+		}
+	}
+	if stub.calls != 1 {
+		t.Errorf("calls = %d, want 1", stub.calls)
+	}
+
+	// Прочие ошибки не кэшируются
+	*now = now.Add(time.Minute)
+	for i := 0; i < 2; i++ {
+		client.GetSongDetails(context.Background(), "Muse", "Unknown")
+	}
+	if stub.calls != 3 {
+		t.Errorf("calls = %d, want 3", stub.calls)
+	}
+}
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(2)
+
+	cache.Set(ctx, "a", CacheEntry{})
+	cache.Set(ctx, "b", CacheEntry{})
+	cache.Get(ctx, "a") // "b" становится самым давно использованным
+	cache.Set(ctx, "c", CacheEntry{})
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		entry, _ := cache.Get(ctx, key)
+		if got := entry != nil; got != want {
+			t.Errorf("Get(%q) present = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestTieredCacheFillsFront(t *testing.T) {
+	ctx := context.Background()
+	front, back := NewMemoryCache(10), NewMemoryCache(10)
+	back.Set(ctx, "a", CacheEntry{ExpiresAt: time.Now()})
+
+	cache := NewTieredCache(front, back)
+	if entry, err := cache.Get(ctx, "a"); entry == nil || err != nil {
+		t.Fatalf("Get() = %v, %v, want entry", entry, err)
+	}
+	if entry, _ := front.Get(ctx, "a"); entry == nil {
+		t.Error("front cache was not filled")
+	}
+}
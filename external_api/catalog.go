@@ -0,0 +1,106 @@
+package externalapi
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"online-library/internal/logger"
+	"online-library/internal/models"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// catalogSong запись файла каталога; поля песни совпадают с ответом music-info API
+type catalogSong struct {
+	Group string `json:"group"`
+	Song  string `json:"song"`
+	models.SongDetail
+}
+
+// catalogColumns - колонки CSV-каталога, первая строка файла должна их перечислять
+var catalogColumns = []string{"group", "song", "releaseDate", "text", "link"}
+
+// LoadCatalog читает локальный каталог песен из JSON-массива или CSV-файла (формат определяется по расширению).
+// Каталог работает как FixtureProvider.
+func LoadCatalog(path string) (*FixtureProvider, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open catalog: %w", err)
+	}
+	defer file.Close()
+
+	var songs []catalogSong
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		if err := json.NewDecoder(file).Decode(&songs); err != nil {
+			return nil, fmt.Errorf("failed to parse catalog %s: %w", path, err)
+		}
+	case ".csv":
+		if songs, err = readCatalogCSV(file); err != nil {
+			return nil, fmt.Errorf("failed to parse catalog %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported catalog format %q, expected .json or .csv", ext)
+	}
+
+	fixtures := make([]FixtureSong, 0, len(songs))
+	for i, s := range songs {
+		if s.Group == "" || s.Song == "" {
+			return nil, fmt.Errorf("catalog %s: entry %d has empty group or song", path, i+1)
+		}
+		fixtures = append(fixtures, FixtureSong{Group: s.Group, Song: s.Song, Detail: s.SongDetail})
+	}
+
+	logger.Log.Infof("Loaded %d songs from catalog %s", len(fixtures), path)
+	return NewFixtureProvider(fixtures...), nil
+}
+
+// readCatalogCSV читает CSV с заголовком; порядок колонок произвольный, отсутствующие колонки остаются пустыми
+func readCatalogCSV(r io.Reader) ([]catalogSong, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.TrimSpace(name)] = i
+	}
+	for _, required := range catalogColumns[:2] {
+		if _, ok := index[required]; !ok {
+			return nil, fmt.Errorf("missing column %q", required)
+		}
+	}
+
+	var songs []catalogSong
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return songs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		field := func(name string) string {
+			if i, ok := index[name]; ok && i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+		songs = append(songs, catalogSong{
+			Group: field("group"),
+			Song:  field("song"),
+			SongDetail: models.SongDetail{
+				ReleaseDate: field("releaseDate"),
+				Text:        field("text"),
+				Link:        field("link"),
+			},
+		})
+	}
+}
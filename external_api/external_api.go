@@ -1,53 +1,107 @@
 package externalapi
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"online-library/internal/logger"
 	"online-library/internal/models"
 	"strings"
+	"time"
 )
 
+// DefaultTimeout - время ожидания ответа внешнего API, если в конфигурации оно не задано
+const DefaultTimeout = 10 * time.Second
+
+// ErrNotFound - внешний API не знает песню с такими group и song
+var ErrNotFound = errors.New("song not found in external API")
+
 type ExternalAPI interface {
-	GetSongDetails(group string, song string) (*models.SongDetail, error)
+	GetSongDetails(ctx context.Context, group string, song string) (*models.SongDetail, error)
+}
+
+// APIError - внешний API ответил статусом, отличным от 200
+type APIError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *APIError) Error() string {
+	return "API error: " + e.Status
+}
+
+// Is позволяет проверять ответ 404 через errors.Is(err, ErrNotFound)
+func (e *APIError) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == http.StatusNotFound
 }
 
 type ExternalAPIClient struct {
-	fullURL string
+	infoURL string
 	Method  string
+	client  *http.Client
+	timeout time.Duration
 }
 
-func NewExternalAPIClient(URL, Method string) *ExternalAPIClient {
+// NewExternalAPIClient создает клиент music-info API.
+// URL - базовый адрес сервиса, запросы отправляются на {URL}/info.
+func NewExternalAPIClient(URL, Method string, timeout time.Duration) *ExternalAPIClient {
 	Method = strings.ToUpper(Method)
 	switch Method {
 	case http.MethodGet, http.MethodPatch, http.MethodPost:
 	default:
 		Method = http.MethodGet
 	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	infoURL := strings.TrimRight(URL, "/")
+	if !strings.HasSuffix(infoURL, "/info") {
+		infoURL += "/info"
+	}
+
 	return &ExternalAPIClient{
-		fullURL: URL,
+		infoURL: infoURL,
 		Method:  Method,
+		client:  &http.Client{Timeout: timeout},
+		timeout: timeout,
 	}
 }
 
-func (c *ExternalAPIClient) GetSongDetails(group string, song string) (*models.SongDetail, error) {
+// songQuery - параметры запроса к /info, для POST и PATCH передаются в теле
+type songQuery struct {
+	Group string `json:"group"`
+	Song  string `json:"song"`
+}
+
+func (c *ExternalAPIClient) GetSongDetails(ctx context.Context, group string, song string) (*models.SongDetail, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
 
-	req, err := http.NewRequest(c.Method, c.fullURL, nil)
+	req, err := c.newRequest(ctx, group, song)
 	if err != nil {
 		logger.Log.Errorf("Failed to create request: %v", err)
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	logger.Log.Debugf("Requesting song details: %s %s", req.Method, req.URL.Redacted())
+	resp, err := c.client.Do(req)
 	if err != nil {
-		logger.Log.Errorf("Failed tto send request: %v", err)
+		logger.Log.Errorf("Failed to send request: %v", err)
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error: %s", resp.Status)
+		// Тело дочитывается, чтобы соединение могло быть переиспользовано
+		io.Copy(io.Discard, resp.Body)
+		logger.Log.Warnf("External API responded %s for group=%q song=%q", resp.Status, group, song)
+		return nil, &APIError{StatusCode: resp.StatusCode, Status: resp.Status}
 	}
 
 	var songDetail models.SongDetail
@@ -58,3 +112,24 @@ func (c *ExternalAPIClient) GetSongDetails(group string, song string) (*models.S
 
 	return &songDetail, nil
 }
+
+// newRequest формирует запрос к /info: для GET параметры кодируются в query, для POST и PATCH - в JSON-тело
+func (c *ExternalAPIClient) newRequest(ctx context.Context, group string, song string) (*http.Request, error) {
+	if c.Method == http.MethodGet {
+		params := url.Values{}
+		params.Set("group", group)
+		params.Set("song", song)
+		return http.NewRequestWithContext(ctx, c.Method, c.infoURL+"?"+params.Encode(), nil)
+	}
+
+	body, err := json.Marshal(songQuery{Group: group, Song: song})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, c.Method, c.infoURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
@@ -0,0 +1,209 @@
+package externalapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const (
+	testGroup = "Muse & Friends"
+	testSong  = "Supermassive Black Hole?"
+)
+
+// infoResponse - ответ music-info API из контракта
+const infoResponse = `{"releaseDate":"16.07.2006","text":"Ooh baby, don't you know I suffer?","link":"https://www.youtube.com/watch?v=Xsp3_a-PMTw"}`
+
+func newInfoServer(t *testing.T, check func(t *testing.T, r *http.Request)) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/info" {
+			t.Errorf("path = %q, want /info", r.URL.Path)
+		}
+		check(t, r)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(infoResponse))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGetSongDetailsGETEncodesQuery(t *testing.T) {
+	server := newInfoServer(t, func(t *testing.T, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("method = %s, want GET", r.Method)
+		}
+		if got := r.URL.Query().Get("group"); got != testGroup {
+			t.Errorf("group = %q, want %q", got, testGroup)
+		}
+		if got := r.URL.Query().Get("song"); got != testSong {
+			t.Errorf("song = %q, want %q", got, testSong)
+		}
+	})
+
+	client := NewExternalAPIClient(server.URL, "get", time.Second)
+	detail, err := client.GetSongDetails(context.Background(), testGroup, testSong)
+	if err != nil {
+		t.Fatalf("GetSongDetails() error = %v", err)
+	}
+
+	if detail.ReleaseDate != "16.07.2006" {
+		t.Errorf("ReleaseDate = %q, want 16.07.2006", detail.ReleaseDate)
+	}
+	if detail.Text != "Ooh baby, don't you know I suffer?" {
+		t.Errorf("Text = %q", detail.Text)
+	}
+	if detail.Link != "https://www.youtube.com/watch?v=Xsp3_a-PMTw" {
+		t.Errorf("Link = %q", detail.Link)
+	}
+}
+
+func TestGetSongDetailsBodyMethods(t *testing.T) {
+	for _, method := range []string{http.MethodPost, http.MethodPatch} {
+		t.Run(method, func(t *testing.T) {
+			server := newInfoServer(t, func(t *testing.T, r *http.Request) {
+				if r.Method != method {
+					t.Errorf("method = %s, want %s", r.Method, method)
+				}
+				if r.URL.RawQuery != "" {
+					t.Errorf("query = %q, want empty", r.URL.RawQuery)
+				}
+				if got := r.Header.Get("Content-Type"); got != "application/json" {
+					t.Errorf("Content-Type = %q, want application/json", got)
+				}
+
+				var body songQuery
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Fatalf("decode body: %v", err)
+				}
+				if body.Group != testGroup || body.Song != testSong {
+					t.Errorf("body = %+v", body)
+				}
+			})
+
+			client := NewExternalAPIClient(server.URL, method, time.Second)
+			if _, err := client.GetSongDetails(context.Background(), testGroup, testSong); err != nil {
+				t.Fatalf("GetSongDetails() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestNewExternalAPIClient(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		method     string
+		wantURL    string
+		wantMethod string
+	}{
+		{"base url", "http://music-info", "GET", "http://music-info/info", http.MethodGet},
+		{"trailing slash", "http://music-info/", "post", "http://music-info/info", http.MethodPost},
+		{"full info url", "http://music-info/api/info", "PATCH", "http://music-info/api/info", http.MethodPatch},
+		{"unsupported method", "http://music-info", "DELETE", "http://music-info/info", http.MethodGet},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewExternalAPIClient(tt.url, tt.method, 0)
+			if client.infoURL != tt.wantURL {
+				t.Errorf("infoURL = %q, want %q", client.infoURL, tt.wantURL)
+			}
+			if client.Method != tt.wantMethod {
+				t.Errorf("Method = %q, want %q", client.Method, tt.wantMethod)
+			}
+			if client.timeout != DefaultTimeout {
+				t.Errorf("timeout = %v, want %v", client.timeout, DefaultTimeout)
+			}
+		})
+	}
+}
+
+func TestGetSongDetailsErrorStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		wantNotFound bool
+	}{
+		{"not found", http.StatusNotFound, true},
+		{"bad request", http.StatusBadRequest, false},
+		{"server error", http.StatusInternalServerError, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "upstream says no", tt.status)
+			}))
+			defer server.Close()
+
+			client := NewExternalAPIClient(server.URL, http.MethodGet, time.Second)
+			_, err := client.GetSongDetails(context.Background(), testGroup, testSong)
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error = %v, want *APIError", err)
+			}
+			if apiErr.StatusCode != tt.status {
+				t.Errorf("StatusCode = %d, want %d", apiErr.StatusCode, tt.status)
+			}
+			if got := errors.Is(err, ErrNotFound); got != tt.wantNotFound {
+				t.Errorf("errors.Is(err, ErrNotFound) = %v, want %v", got, tt.wantNotFound)
+			}
+		})
+	}
+}
+
+func TestGetSongDetailsInvalidJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("not json"))
+	}))
+	defer server.Close()
+
+	client := NewExternalAPIClient(server.URL, http.MethodGet, time.Second)
+	if _, err := client.GetSongDetails(context.Background(), testGroup, testSong); err == nil {
+		t.Fatal("GetSongDetails() error = nil, want parse error")
+	}
+}
+
+func TestGetSongDetailsTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewExternalAPIClient(server.URL, http.MethodGet, 50*time.Millisecond)
+
+	start := time.Now()
+	_, err := client.GetSongDetails(context.Background(), testGroup, testSong)
+	if err == nil {
+		t.Fatal("GetSongDetails() error = nil, want timeout")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("request took %v, timeout was not applied", elapsed)
+	}
+}
+
+func TestGetSongDetailsCanceledContext(t *testing.T) {
+	server := newInfoServer(t, func(t *testing.T, r *http.Request) {})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	client := NewExternalAPIClient(server.URL, http.MethodGet, time.Second)
+	if _, err := client.GetSongDetails(ctx, testGroup, testSong); !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want context.Canceled", err)
+	}
+}
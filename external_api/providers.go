@@ -0,0 +1,176 @@
+package externalapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"online-library/internal/logger"
+	"online-library/internal/models"
+	"sort"
+	"strings"
+)
+
+// ProviderFactory создает источник данных о песнях
+type ProviderFactory func() (ExternalAPI, error)
+
+// Registry хранит источники данных о песнях по именам, чтобы порядок опроса задавался конфигурацией
+type Registry struct {
+	factories map[string]ProviderFactory
+}
+
+func NewRegistry() *Registry {
+	return &Registry{factories: make(map[string]ProviderFactory)}
+}
+
+// Register добавляет источник; повторная регистрация имени заменяет прежний
+func (r *Registry) Register(name string, factory ProviderFactory) {
+	r.factories[strings.ToLower(name)] = factory
+}
+
+// Names возвращает имена зарегистрированных источников
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Build создает источники в указанном порядке и объединяет их в цепочку
+func (r *Registry) Build(names []string) (ExternalAPI, error) {
+	var chain ChainProvider
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		factory, ok := r.factories[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown song details provider %q, available: %s", name, strings.Join(r.Names(), ", "))
+		}
+		provider, err := factory()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create provider %q: %w", name, err)
+		}
+		chain.providers = append(chain.providers, namedProvider{name: name, api: provider})
+	}
+
+	switch len(chain.providers) {
+	case 0:
+		return nil, errors.New("no song details providers configured")
+	case 1:
+		return chain.providers[0].api, nil
+	}
+	return &chain, nil
+}
+
+type namedProvider struct {
+	name string
+	api  ExternalAPI
+}
+
+// ChainProvider опрашивает источники по порядку и собирает ответ по полям:
+// поле берется из первого источника, который его заполнил.
+// Следующие источники опрашиваются, только пока в ответе остаются пустые поля.
+type ChainProvider struct {
+	providers []namedProvider
+}
+
+// NewChainProvider объединяет источники в цепочку в указанном порядке
+func NewChainProvider(providers ...ExternalAPI) *ChainProvider {
+	chain := &ChainProvider{}
+	for i, api := range providers {
+		chain.providers = append(chain.providers, namedProvider{name: fmt.Sprintf("#%d", i+1), api: api})
+	}
+	return chain
+}
+
+func (c *ChainProvider) GetSongDetails(ctx context.Context, group string, song string) (*models.SongDetail, error) {
+	var (
+		merged   *models.SongDetail
+		notFound error
+		failure  error
+	)
+
+	for _, provider := range c.providers {
+		detail, err := provider.api.GetSongDetails(ctx, group, song)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			if errors.Is(err, ErrNotFound) {
+				logger.Log.Debugf("Provider %s does not know group=%q song=%q", provider.name, group, song)
+				if notFound == nil {
+					notFound = err
+				}
+			} else {
+				logger.Log.Warnf("Provider %s failed for group=%q song=%q: %v", provider.name, group, song, err)
+				if failure == nil {
+					failure = err
+				}
+			}
+			continue
+		}
+
+		if merged == nil {
+			merged = &models.SongDetail{}
+		}
+		mergeDetail(merged, detail)
+		if merged.ReleaseDate != "" && merged.Text != "" && merged.Link != "" {
+			break
+		}
+	}
+
+	switch {
+	case merged != nil:
+		return merged, nil
+	case failure != nil:
+		// Хотя бы один источник недоступен: отсутствие песни в остальных не окончательно
+		return nil, failure
+	case notFound != nil:
+		return nil, notFound
+	}
+	return nil, ErrNotFound
+}
+
+// mergeDetail заполняет пустые поля dst значениями из src
+func mergeDetail(dst *models.SongDetail, src *models.SongDetail) {
+	if dst.ReleaseDate == "" {
+		dst.ReleaseDate = src.ReleaseDate
+	}
+	if dst.Text == "" {
+		dst.Text = src.Text
+	}
+	if dst.Link == "" {
+		dst.Link = src.Link
+	}
+}
+
+// FixtureProvider отдает заранее заданные данные; используется в тестах и для локального запуска без внешнего API
+type FixtureProvider struct {
+	songs map[string]models.SongDetail
+}
+
+// FixtureSong запись FixtureProvider
+type FixtureSong struct {
+	Group  string
+	Song   string
+	Detail models.SongDetail
+}
+
+func NewFixtureProvider(songs ...FixtureSong) *FixtureProvider {
+	provider := &FixtureProvider{songs: make(map[string]models.SongDetail, len(songs))}
+	for _, s := range songs {
+		provider.songs[cacheKey(s.Group, s.Song)] = s.Detail
+	}
+	return provider
+}
+
+func (f *FixtureProvider) GetSongDetails(ctx context.Context, group string, song string) (*models.SongDetail, error) {
+	detail, ok := f.songs[cacheKey(group, song)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &detail, nil
+}
@@ -0,0 +1,134 @@
+package externalapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"online-library/internal/models"
+)
+
+func fixture(detail models.SongDetail) *FixtureProvider {
+	return NewFixtureProvider(FixtureSong{Group: "Muse", Song: "Uprising", Detail: detail})
+}
+
+func TestChainProviderMergesFields(t *testing.T) {
+	first := fixture(models.SongDetail{Text: "first text"})
+	second := fixture(models.SongDetail{Text: "second text", ReleaseDate: "07.09.2009"})
+	third := fixture(models.SongDetail{Link: "https://example.com/uprising"})
+
+	detail, err := NewChainProvider(first, second, third).GetSongDetails(context.Background(), "muse", "UPRISING")
+	if err != nil {
+		t.Fatalf("GetSongDetails() error = %v", err)
+	}
+	want := models.SongDetail{Text: "first text", ReleaseDate: "07.09.2009", Link: "https://example.com/uprising"}
+	if *detail != want {
+		t.Errorf("detail = %+v, want %+v", *detail, want)
+	}
+}
+
+func TestChainProviderStopsWhenComplete(t *testing.T) {
+	complete := fixture(models.SongDetail{Text: "text", ReleaseDate: "07.09.2009", Link: "link"})
+	stub := &stubAPI{}
+
+	if _, err := NewChainProvider(complete, stub).GetSongDetails(context.Background(), "Muse", "Uprising"); err != nil {
+		t.Fatalf("GetSongDetails() error = %v", err)
+	}
+	if stub.calls != 0 {
+		t.Errorf("calls = %d, want 0", stub.calls)
+	}
+}
+
+func TestChainProviderFallback(t *testing.T) {
+	down := &stubAPI{errs: []error{errUnavailable}}
+	detail, err := NewChainProvider(down, fixture(models.SongDetail{Text: "text"})).GetSongDetails(context.Background(), "Muse", "Uprising")
+	if err != nil {
+		t.Fatalf("GetSongDetails() error = %v", err)
+	}
+	if detail.Text != "text" {
+		t.Errorf("Text = %q, want text", detail.Text)
+	}
+}
+
+func TestChainProviderErrors(t *testing.T) {
+	empty := NewFixtureProvider()
+
+	_, err := NewChainProvider(empty, empty).GetSongDetails(context.Background(), "Muse", "Uprising")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("all not found: error = %v, want ErrNotFound", err)
+	}
+
+	// Недоступность источника важнее того, что остальные песню не знают
+	down := &stubAPI{errs: []error{errUnavailable}}
+	_, err = NewChainProvider(empty, down).GetSongDetails(context.Background(), "Muse", "Uprising")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("provider down: error = %v, want 503 APIError", err)
+	}
+}
+
+func TestRegistryBuild(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("fixture", func() (ExternalAPI, error) { return fixture(models.SongDetail{}), nil })
+	registry.Register("stub", func() (ExternalAPI, error) { return &stubAPI{}, nil })
+
+	single, err := registry.Build([]string{" Fixture "})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if _, ok := single.(*FixtureProvider); !ok {
+		t.Errorf("single provider = %T, want *FixtureProvider", single)
+	}
+
+	chain, err := registry.Build([]string{"fixture", "stub"})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if c, ok := chain.(*ChainProvider); !ok || len(c.providers) != 2 {
+		t.Errorf("chain = %#v, want ChainProvider with 2 providers", chain)
+	}
+
+	if _, err := registry.Build([]string{"fixture", "unknown"}); err == nil || !strings.Contains(err.Error(), "unknown") {
+		t.Errorf("unknown provider: error = %v", err)
+	}
+	if _, err := registry.Build([]string{""}); err == nil {
+		t.Error("empty list: error = nil, want error")
+	}
+}
+
+func TestLoadCatalog(t *testing.T) {
+	files := map[string]string{
+		"catalog.json": `[{"group":"Muse","song":"Uprising","releaseDate":"07.09.2009","text":"Paranoia is in bloom","link":"https://example.com"}]`,
+		"catalog.csv":  "song,group,releaseDate,text,link\nUprising,Muse,07.09.2009,\"Paranoia is in bloom\",https://example.com\n",
+	}
+	want := models.SongDetail{ReleaseDate: "07.09.2009", Text: "Paranoia is in bloom", Link: "https://example.com"}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			catalog, err := LoadCatalog(path)
+			if err != nil {
+				t.Fatalf("LoadCatalog() error = %v", err)
+			}
+			detail, err := catalog.GetSongDetails(context.Background(), "muse", "uprising")
+			if err != nil {
+				t.Fatalf("GetSongDetails() error = %v", err)
+			}
+			if *detail != want {
+				t.Errorf("detail = %+v, want %+v", *detail, want)
+			}
+		})
+	}
+
+	if _, err := LoadCatalog(filepath.Join(t.TempDir(), "catalog.xml")); err == nil {
+		t.Error("LoadCatalog(missing .xml) error = nil, want error")
+	}
+}
@@ -0,0 +1,292 @@
+package externalapi
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"online-library/internal/logger"
+	"online-library/internal/models"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrCircuitOpen - размыкатель открыт, запрос к внешнему API не отправлялся
+var ErrCircuitOpen = errors.New("external API circuit breaker is open")
+
+// CircuitOpenError сообщает, через сколько размыкатель пропустит пробный запрос
+type CircuitOpenError struct {
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return ErrCircuitOpen.Error()
+}
+
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// metrics - счетчики внешнего API, доступны на служебном сервере по /debug/vars в разделе external_api
+var metrics = expvar.NewMap("external_api")
+
+// clientMetrics - счетчики и состояние размыкателя каждого ResilientClient в разделе external_api.clients
+var (
+	clientMetrics   = newClientMetrics()
+	clientMetricsMu sync.Mutex
+)
+
+func newClientMetrics() *expvar.Map {
+	clients := new(expvar.Map)
+	metrics.Set("clients", clients)
+	return clients
+}
+
+// metricsFor возвращает счетчики клиента name; клиенты с одним именем делят счетчики
+func metricsFor(name string) *expvar.Map {
+	clientMetricsMu.Lock()
+	defer clientMetricsMu.Unlock()
+
+	if m, ok := clientMetrics.Get(name).(*expvar.Map); ok {
+		return m
+	}
+	m := new(expvar.Map)
+	clientMetrics.Set(name, m)
+	return m
+}
+
+// ResilienceConfig параметры повторов и размыкателя
+type ResilienceConfig struct {
+	MaxRetries       int           // сколько раз повторить запрос после первой неудачной попытки
+	BaseBackoff      time.Duration // базовая задержка перед повтором, удваивается с каждой попыткой
+	MaxBackoff       time.Duration // верхняя граница задержки
+	FailureThreshold int           // сколько неудачных вызовов подряд размыкают цепь
+	OpenTimeout      time.Duration // сколько цепь остается разомкнутой до пробного запроса
+}
+
+// DefaultResilienceConfig возвращает параметры, с которыми клиент работает, если конфигурация их не задает
+func DefaultResilienceConfig() ResilienceConfig {
+	return ResilienceConfig{
+		MaxRetries:       2,
+		BaseBackoff:      200 * time.Millisecond,
+		MaxBackoff:       2 * time.Second,
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+	}
+}
+
+// ResilientClient оборачивает ExternalAPI повторами с экспоненциальной задержкой и размыкателем цепи
+type ResilientClient struct {
+	next    ExternalAPI
+	cfg     ResilienceConfig
+	breaker *circuitBreaker
+	metrics *expvar.Map
+	sleep   func(ctx context.Context, d time.Duration) error
+}
+
+// NewResilientClient создает клиент; name - имя источника, под которым его метрики видны в external_api.clients
+func NewResilientClient(name string, next ExternalAPI, cfg ResilienceConfig) *ResilientClient {
+	counters := metricsFor(name)
+	return &ResilientClient{
+		next:    next,
+		cfg:     cfg,
+		breaker: newCircuitBreaker(name, cfg.FailureThreshold, cfg.OpenTimeout, counters),
+		metrics: counters,
+		sleep:   sleepContext,
+	}
+}
+
+func (c *ResilientClient) GetSongDetails(ctx context.Context, group string, song string) (*models.SongDetail, error) {
+	var err error
+	for attempt := 0; attempt <= c.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			c.metrics.Add("retries", 1)
+			delay := c.backoff(attempt)
+			logger.Log.Warnf("Retrying external API call (attempt %d of %d) in %v: %v", attempt+1, c.cfg.MaxRetries+1, delay, err)
+			if sleepErr := c.sleep(ctx, delay); sleepErr != nil {
+				return nil, sleepErr
+			}
+		}
+
+		if retryAfter, ok := c.breaker.allow(); !ok {
+			c.metrics.Add("rejected", 1)
+			return nil, &CircuitOpenError{RetryAfter: retryAfter}
+		}
+
+		var detail *models.SongDetail
+		detail, err = c.next.GetSongDetails(ctx, group, song)
+		switch {
+		case err == nil:
+			c.breaker.success()
+			return detail, nil
+		case ctx.Err() != nil:
+			// Вызывающий отменил запрос или исчерпал свое время: это не говорит о состоянии сервиса
+			c.breaker.release()
+			return nil, err
+		case isClientError(err):
+			// Ответы 4xx означают, что сервис работает, и не размыкают цепь
+			c.breaker.success()
+			return nil, err
+		}
+		// Любая другая ошибка - недоступность, обрыв соединения, неразборчивый ответ - считается отказом сервиса
+		c.breaker.failure()
+		if !isRetryable(err) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// backoff возвращает задержку перед попыткой attempt: случайное значение от 0 до BaseBackoff*2^(attempt-1)
+func (c *ResilientClient) backoff(attempt int) time.Duration {
+	limit := c.cfg.BaseBackoff << (attempt - 1)
+	if limit <= 0 || limit > c.cfg.MaxBackoff {
+		limit = c.cfg.MaxBackoff
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return rand.N(limit) + 1
+}
+
+// isClientError сообщает, что сервис ответил статусом 4xx
+func isClientError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) &&
+		apiErr.StatusCode >= http.StatusBadRequest && apiErr.StatusCode < http.StatusInternalServerError
+}
+
+// isRetryable сообщает, стоит ли повторять запрос: ответы 5xx, таймауты самой попытки и сброшенные соединения
+func isRetryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Состояния размыкателя цепи
+const (
+	stateClosed   = "closed"
+	stateOpen     = "open"
+	stateHalfOpen = "half_open"
+)
+
+// circuitBreaker размыкает цепь после threshold неудач подряд и через openTimeout пропускает один пробный запрос
+type circuitBreaker struct {
+	name        string
+	mu          sync.Mutex
+	state       string
+	failures    int
+	threshold   int
+	openTimeout time.Duration
+	openedAt    time.Time
+	probing     bool // пробный запрос в полуоткрытом состоянии уже отправлен
+	now         func() time.Time
+	metrics     *expvar.Map
+}
+
+func newCircuitBreaker(name string, threshold int, openTimeout time.Duration, metrics *expvar.Map) *circuitBreaker {
+	metrics.Set("circuit_state", stateVar(stateClosed))
+	return &circuitBreaker{
+		name:        name,
+		metrics:     metrics,
+		state:       stateClosed,
+		threshold:   threshold,
+		openTimeout: openTimeout,
+		now:         time.Now,
+	}
+}
+
+// allow решает, можно ли отправить запрос; если нельзя, возвращает время до пробного запроса
+func (b *circuitBreaker) allow() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		if wait := b.openTimeout - b.now().Sub(b.openedAt); wait > 0 {
+			return wait, false
+		}
+		b.transition(stateHalfOpen)
+		b.probing = true
+		return 0, true
+	case stateHalfOpen:
+		if b.probing {
+			return b.openTimeout, false
+		}
+		b.probing = true
+		return 0, true
+	default:
+		return 0, true
+	}
+}
+
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+	if b.state != stateClosed {
+		b.transition(stateClosed)
+	}
+}
+
+// release освобождает пробный запрос, результат которого ничего не говорит о сервисе
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+func (b *circuitBreaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == stateHalfOpen || (b.threshold > 0 && b.failures >= b.threshold) {
+		b.openedAt = b.now()
+		if b.state != stateOpen {
+			b.transition(stateOpen)
+		}
+	}
+}
+
+// transition меняет состояние и учитывает переход в метриках; вызывается под b.mu
+func (b *circuitBreaker) transition(state string) {
+	logger.Log.Warnf("External API circuit breaker %s: %s -> %s", b.name, b.state, state)
+	b.state = state
+	b.metrics.Add("circuit_to_"+state, 1)
+	b.metrics.Set("circuit_state", stateVar(state))
+}
+
+// stateVar - строковое значение для expvar
+type stateVar string
+
+func (s stateVar) String() string {
+	return `"` + string(s) + `"`
+}
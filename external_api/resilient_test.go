@@ -0,0 +1,217 @@
+package externalapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+	"time"
+
+	"online-library/internal/models"
+)
+
+// stubAPI отдает ошибки из errs по очереди, после них - успешный ответ
+type stubAPI struct {
+	errs  []error
+	calls int
+}
+
+func (s *stubAPI) GetSongDetails(ctx context.Context, group string, song string) (*models.SongDetail, error) {
+	s.calls++
+	if s.calls <= len(s.errs) && s.errs[s.calls-1] != nil {
+		return nil, s.errs[s.calls-1]
+	}
+	return &models.SongDetail{Text: "lyrics"}, nil
+}
+
+func newTestResilientClient(next ExternalAPI, cfg ResilienceConfig) *ResilientClient {
+	client := NewResilientClient("test", next, cfg)
+	client.sleep = func(ctx context.Context, d time.Duration) error { return ctx.Err() }
+	return client
+}
+
+var errUnavailable = &APIError{StatusCode: http.StatusServiceUnavailable, Status: "503 Service Unavailable"}
+
+func TestResilientClientRetriesServerErrors(t *testing.T) {
+	stub := &stubAPI{errs: []error{errUnavailable, context.DeadlineExceeded}}
+	client := newTestResilientClient(stub, ResilienceConfig{MaxRetries: 2, FailureThreshold: 10})
+
+	detail, err := client.GetSongDetails(context.Background(), "Muse", "Uprising")
+	if err != nil {
+		t.Fatalf("GetSongDetails() error = %v", err)
+	}
+	if detail.Text != "lyrics" {
+		t.Errorf("Text = %q, want lyrics", detail.Text)
+	}
+	if stub.calls != 3 {
+		t.Errorf("calls = %d, want 3", stub.calls)
+	}
+}
+
+func TestResilientClientDoesNotRetryClientErrors(t *testing.T) {
+	notFound := &APIError{StatusCode: http.StatusNotFound, Status: "404 Not Found"}
+	stub := &stubAPI{errs: []error{notFound}}
+	client := newTestResilientClient(stub, ResilienceConfig{MaxRetries: 3, FailureThreshold: 1})
+
+	_, err := client.GetSongDetails(context.Background(), "Muse", "Uprising")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("error = %v, want ErrNotFound", err)
+	}
+	if stub.calls != 1 {
+		t.Errorf("calls = %d, want 1", stub.calls)
+	}
+	if client.breaker.state != stateClosed {
+		t.Errorf("breaker state = %s, want closed", client.breaker.state)
+	}
+}
+
+func TestResilientClientGivesUpAfterMaxRetries(t *testing.T) {
+	stub := &stubAPI{errs: []error{errUnavailable, errUnavailable, errUnavailable, errUnavailable}}
+	client := newTestResilientClient(stub, ResilienceConfig{MaxRetries: 2, FailureThreshold: 10})
+
+	_, err := client.GetSongDetails(context.Background(), "Muse", "Uprising")
+	if !errors.Is(err, errUnavailable) {
+		t.Fatalf("error = %v, want last upstream error", err)
+	}
+	if stub.calls != 3 {
+		t.Errorf("calls = %d, want 3", stub.calls)
+	}
+}
+
+func TestResilientClientCircuitBreaker(t *testing.T) {
+	stub := &stubAPI{errs: []error{errUnavailable, errUnavailable, errUnavailable}}
+	client := newTestResilientClient(stub, ResilienceConfig{FailureThreshold: 2, OpenTimeout: time.Minute})
+
+	now := time.Now()
+	client.breaker.now = func() time.Time { return now }
+
+	// Две неудачи подряд размыкают цепь
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetSongDetails(context.Background(), "Muse", "Uprising"); !errors.Is(err, errUnavailable) {
+			t.Fatalf("call %d: error = %v, want upstream error", i, err)
+		}
+	}
+	if client.breaker.state != stateOpen {
+		t.Fatalf("breaker state = %s, want open", client.breaker.state)
+	}
+
+	// Пока цепь разомкнута, запросы не доходят до внешнего API
+	if _, err := client.GetSongDetails(context.Background(), "Muse", "Uprising"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("error = %v, want ErrCircuitOpen", err)
+	}
+	if stub.calls != 2 {
+		t.Errorf("calls = %d, want 2", stub.calls)
+	}
+
+	// Неудачный пробный запрос снова размыкает цепь
+	now = now.Add(time.Minute)
+	if _, err := client.GetSongDetails(context.Background(), "Muse", "Uprising"); !errors.Is(err, errUnavailable) {
+		t.Fatalf("probe error = %v, want upstream error", err)
+	}
+	if client.breaker.state != stateOpen {
+		t.Fatalf("breaker state after failed probe = %s, want open", client.breaker.state)
+	}
+
+	// Успешный пробный запрос замыкает цепь
+	now = now.Add(time.Minute)
+	if _, err := client.GetSongDetails(context.Background(), "Muse", "Uprising"); err != nil {
+		t.Fatalf("probe error = %v, want success", err)
+	}
+	if client.breaker.state != stateClosed {
+		t.Errorf("breaker state = %s, want closed", client.breaker.state)
+	}
+}
+
+func TestResilientClientOpensOnConnectionRefused(t *testing.T) {
+	// Адрес закрытого сервера: каждое соединение отклоняется
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	client := newTestResilientClient(NewExternalAPIClient(url, http.MethodGet, time.Second),
+		ResilienceConfig{MaxRetries: 1, FailureThreshold: 2, OpenTimeout: time.Minute})
+
+	_, err := client.GetSongDetails(context.Background(), "Muse", "Uprising")
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		t.Fatalf("error = %v, want connection refused", err)
+	}
+	if client.breaker.state != stateOpen {
+		t.Fatalf("breaker state = %s, want open", client.breaker.state)
+	}
+	if _, err := client.GetSongDetails(context.Background(), "Muse", "Uprising"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("error = %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestResilientClientFailedProbeWithBadResponseKeepsCircuitOpen(t *testing.T) {
+	badResponse := errors.New("failed to parse response: unexpected EOF")
+	stub := &stubAPI{errs: []error{errUnavailable, badResponse}}
+	client := newTestResilientClient(stub, ResilienceConfig{FailureThreshold: 1, OpenTimeout: time.Minute})
+
+	now := time.Now()
+	client.breaker.now = func() time.Time { return now }
+
+	if _, err := client.GetSongDetails(context.Background(), "Muse", "Uprising"); !errors.Is(err, errUnavailable) {
+		t.Fatalf("error = %v, want upstream error", err)
+	}
+
+	// Неразборчивый ответ на пробный запрос - тоже отказ, цепь остается разомкнутой
+	now = now.Add(time.Minute)
+	if _, err := client.GetSongDetails(context.Background(), "Muse", "Uprising"); !errors.Is(err, badResponse) {
+		t.Fatalf("probe error = %v, want parse error", err)
+	}
+	if stub.calls != 2 {
+		t.Errorf("calls = %d, want 2", stub.calls)
+	}
+	if client.breaker.state != stateOpen {
+		t.Errorf("breaker state = %s, want open", client.breaker.state)
+	}
+}
+
+func TestResilientClientStopsOnCanceledContext(t *testing.T) {
+	stub := &stubAPI{errs: []error{errUnavailable, errUnavailable}}
+	client := newTestResilientClient(stub, ResilienceConfig{MaxRetries: 5, FailureThreshold: 10})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := client.GetSongDetails(ctx, "Muse", "Uprising"); err == nil {
+		t.Fatal("GetSongDetails() error = nil, want error")
+	}
+	if stub.calls != 1 {
+		t.Errorf("calls = %d, want 1", stub.calls)
+	}
+}
+
+func TestResilientClientBackoff(t *testing.T) {
+	client := NewResilientClient(t.Name(), &stubAPI{}, ResilienceConfig{BaseBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond})
+
+	for attempt, limit := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 5: 300 * time.Millisecond} {
+		for i := 0; i < 50; i++ {
+			if d := client.backoff(attempt); d <= 0 || d > limit {
+				t.Fatalf("backoff(%d) = %v, want in (0, %v]", attempt, d, limit)
+			}
+		}
+	}
+}
+
+func TestResilientClientMetricsPerName(t *testing.T) {
+	// Размыкатель одного источника не должен перезаписывать состояние другого в метриках
+	failing := NewResilientClient("failing", &stubAPI{errs: []error{errUnavailable}}, ResilienceConfig{FailureThreshold: 1})
+	healthy := NewResilientClient("healthy", &stubAPI{}, ResilienceConfig{FailureThreshold: 1})
+
+	if _, err := failing.GetSongDetails(context.Background(), "Muse", "Uprising"); err == nil {
+		t.Fatal("expected error from failing client")
+	}
+	if _, err := healthy.GetSongDetails(context.Background(), "Muse", "Uprising"); err != nil {
+		t.Fatalf("healthy client: %v", err)
+	}
+
+	for name, want := range map[string]string{"failing": `"open"`, "healthy": `"closed"`} {
+		if got := metricsFor(name).Get("circuit_state").String(); got != want {
+			t.Errorf("%s circuit_state = %s, want %s", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_songs_artist_id;
+ALTER TABLE artists DROP CONSTRAINT IF EXISTS artists_name_key;
@@ -0,0 +1,24 @@
+-- Перед ограничением уникальности артисты с одинаковым именем объединяются в артиста с наименьшим id:
+-- к нему переходят песни дубликатов и первое непустое описание
+UPDATE songs s
+SET artist_id = keep.id
+FROM artists a
+JOIN (SELECT name, MIN(id) AS id FROM artists GROUP BY name HAVING COUNT(*) > 1) keep ON keep.name = a.name
+WHERE s.artist_id = a.id AND a.id <> keep.id;
+
+UPDATE artists k
+SET bio = (
+    SELECT d.bio FROM artists d
+    WHERE d.name = k.name AND NULLIF(d.bio, '') IS NOT NULL
+    ORDER BY d.id
+    LIMIT 1
+)
+WHERE NULLIF(k.bio, '') IS NULL
+    AND k.id IN (SELECT MIN(id) FROM artists GROUP BY name HAVING COUNT(*) > 1);
+
+DELETE FROM artists a USING artists b WHERE a.name = b.name AND a.id > b.id;
+
+-- Имя исполнителя уникально: песни ссылаются на артиста, найденного или созданного по имени
+ALTER TABLE artists ADD CONSTRAINT artists_name_key UNIQUE (name);
+
+CREATE INDEX IF NOT EXISTS idx_songs_artist_id ON songs (artist_id);
@@ -0,0 +1,2 @@
+-- Версия строки для оптимистичной блокировки (ETag / If-Match)
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
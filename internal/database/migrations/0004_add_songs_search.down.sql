@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_songs_search_vector;
+DROP TRIGGER IF EXISTS artists_search_vector ON artists;
+DROP FUNCTION IF EXISTS update_artist_songs_search_vector();
+DROP TRIGGER IF EXISTS songs_search_vector ON songs;
+DROP FUNCTION IF EXISTS update_song_search_vector();
+DROP FUNCTION IF EXISTS song_search_vector(TEXT, TEXT, TEXT);
+ALTER TABLE songs DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,39 @@
+-- Полнотекстовый поиск. Вектор песни объединяет название (вес A), группу (B) и текст (C), чтобы запрос
+-- из слов названия и имени артиста находил песню и обслуживался одним GIN-индексом.
+-- Генерируемая колонка не может ссылаться на artists, поэтому вектор пересчитывают триггеры:
+-- при изменении песни и при переименовании артиста.
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+CREATE OR REPLACE FUNCTION song_search_vector(song_name TEXT, artist_name TEXT, song_lyrics TEXT) RETURNS tsvector AS $$
+    SELECT setweight(to_tsvector('simple', coalesce(song_name, '')), 'A') ||
+           setweight(to_tsvector('simple', coalesce(artist_name, '')), 'B') ||
+           setweight(to_tsvector('simple', coalesce(song_lyrics, '')), 'C')
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION update_song_search_vector() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector := song_search_vector(NEW.name, (SELECT name FROM artists WHERE id = NEW.artist_id), NEW.lyrics);
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER songs_search_vector
+    BEFORE INSERT OR UPDATE OF name, lyrics, artist_id ON songs
+    FOR EACH ROW EXECUTE FUNCTION update_song_search_vector();
+
+-- Версия песни не меняется, поэтому триггер истории такие обновления пропускает
+CREATE OR REPLACE FUNCTION update_artist_songs_search_vector() RETURNS trigger AS $$
+BEGIN
+    UPDATE songs SET search_vector = song_search_vector(name, NEW.name, lyrics) WHERE artist_id = NEW.id;
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER artists_search_vector
+    AFTER UPDATE OF name ON artists
+    FOR EACH ROW WHEN (OLD.name IS DISTINCT FROM NEW.name)
+    EXECUTE FUNCTION update_artist_songs_search_vector();
+
+UPDATE songs s SET search_vector = song_search_vector(s.name, (SELECT name FROM artists WHERE id = s.artist_id), s.lyrics);
+
+CREATE INDEX IF NOT EXISTS idx_songs_search_vector ON songs USING GIN (search_vector);
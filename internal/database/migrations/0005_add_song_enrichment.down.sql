@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_songs_enrichment_queue;
+ALTER TABLE songs DROP CONSTRAINT IF EXISTS songs_enrichment_status_check;
+ALTER TABLE songs
+    DROP COLUMN IF EXISTS enrichment_next_at,
+    DROP COLUMN IF EXISTS enrichment_error,
+    DROP COLUMN IF EXISTS enrichment_attempts,
+    DROP COLUMN IF EXISTS enrichment_status;
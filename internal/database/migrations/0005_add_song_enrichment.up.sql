@@ -0,0 +1,14 @@
+-- Асинхронное обогащение песен данными внешнего API.
+-- pending/failed ждут обработки с enrichment_next_at, processing захвачены обработчиком до enrichment_next_at,
+-- dead - попытки исчерпаны.
+ALTER TABLE songs
+    ADD COLUMN IF NOT EXISTS enrichment_status VARCHAR(20) NOT NULL DEFAULT 'done',
+    ADD COLUMN IF NOT EXISTS enrichment_attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS enrichment_error TEXT,
+    ADD COLUMN IF NOT EXISTS enrichment_next_at TIMESTAMPTZ;
+
+ALTER TABLE songs ADD CONSTRAINT songs_enrichment_status_check
+    CHECK (enrichment_status IN ('pending', 'processing', 'done', 'failed', 'dead'));
+
+CREATE INDEX IF NOT EXISTS idx_songs_enrichment_queue ON songs (enrichment_next_at)
+    WHERE enrichment_status IN ('pending', 'processing', 'failed');
@@ -0,0 +1,9 @@
+-- Кэш ответов внешнего music-info API, переживает перезапуск сервиса.
+-- detail = NULL означает, что внешний API не знает песню (ответ 404).
+CREATE TABLE IF NOT EXISTS external_api_cache (
+    key TEXT PRIMARY KEY,
+    detail JSONB,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_external_api_cache_expires_at ON external_api_cache (expires_at);
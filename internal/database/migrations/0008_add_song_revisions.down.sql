@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS songs_record_revision ON songs;
+DROP FUNCTION IF EXISTS record_song_revision();
+DROP FUNCTION IF EXISTS song_snapshot(songs);
+DROP TABLE IF EXISTS song_revisions;
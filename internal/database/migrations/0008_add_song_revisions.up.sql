@@ -0,0 +1,79 @@
+-- История изменений песен. Ревизию записывает триггер при каждом изменении версии песни,
+-- поэтому в историю попадают все пути записи. Автор передается через set_config('app.author', ..., true),
+-- изменения без автора (фоновые задачи) записываются от имени system.
+-- Переименование артиста меняет поле group у всех его песен. Триггер видит артиста уже переименованным,
+-- поэтому такие ревизии (artist_rename) записывает UpdateArtist, а триггер эти обновления пропускает.
+CREATE TABLE IF NOT EXISTS song_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    song_id INT NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+    version INT NOT NULL,                 -- версия песни после изменения
+    action VARCHAR(20) NOT NULL,          -- create, update, delete, restore, revert, artist_rename
+    author TEXT NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    old_values JSONB,                     -- прежние значения измененных полей, NULL для create
+    new_values JSONB NOT NULL             -- новые значения измененных полей
+);
+
+CREATE INDEX IF NOT EXISTS idx_song_revisions_song_id ON song_revisions (song_id, id);
+
+-- song_snapshot - поля песни в терминах API (JSON-поля models.Song)
+CREATE OR REPLACE FUNCTION song_snapshot(s songs) RETURNS JSONB AS $$
+    SELECT jsonb_build_object(
+        'group', (SELECT name FROM artists WHERE id = s.artist_id),
+        'song', s.name,
+        'genre', s.genre,
+        'release_date', to_char(s.release_date, 'YYYY-MM-DD'),
+        'lyrics', s.lyrics,
+        'link', s.video
+    )
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION record_song_revision() RETURNS trigger AS $$
+DECLARE
+    old_snapshot JSONB;
+    new_snapshot JSONB := song_snapshot(NEW);
+    old_diff JSONB := '{}';
+    new_diff JSONB := '{}';
+    field TEXT;
+    change TEXT;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        change := 'create';
+        old_diff := NULL;
+        new_diff := jsonb_strip_nulls(new_snapshot);
+    ELSE
+        -- Служебные изменения (очередь загрузки данных) не меняют версию и в историю не попадают
+        IF NEW.version = OLD.version THEN
+            RETURN NEW;
+        END IF;
+
+        old_snapshot := song_snapshot(OLD);
+        FOR field IN SELECT jsonb_object_keys(new_snapshot) LOOP
+            IF new_snapshot -> field IS DISTINCT FROM old_snapshot -> field THEN
+                old_diff := old_diff || jsonb_build_object(field, old_snapshot -> field);
+                new_diff := new_diff || jsonb_build_object(field, new_snapshot -> field);
+            END IF;
+        END LOOP;
+
+        change := CASE
+            WHEN OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN 'delete'
+            WHEN OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN 'restore'
+            ELSE COALESCE(NULLIF(current_setting('app.action', true), ''), 'update')
+        END;
+
+        IF change = 'artist_rename' THEN
+            RETURN NEW;
+        END IF;
+    END IF;
+
+    INSERT INTO song_revisions (song_id, version, action, author, old_values, new_values)
+    VALUES (NEW.id, NEW.version, change,
+            COALESCE(NULLIF(current_setting('app.author', true), ''), 'system'),
+            old_diff, new_diff);
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER songs_record_revision
+    AFTER INSERT OR UPDATE ON songs
+    FOR EACH ROW EXECUTE FUNCTION record_song_revision();
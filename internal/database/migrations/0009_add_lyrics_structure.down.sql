@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS songs_reset_lyrics_structure ON songs;
+DROP FUNCTION IF EXISTS reset_lyrics_structure();
+ALTER TABLE songs DROP COLUMN IF EXISTS lyrics_structure;
@@ -0,0 +1,16 @@
+-- Разобранный на части текст песни (пакет internal/lyrics). Заполняется при первом чтении текста
+-- и сбрасывается триггером при любом изменении lyrics, поэтому не расходится с текстом.
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS lyrics_structure JSONB;
+
+CREATE OR REPLACE FUNCTION reset_lyrics_structure() RETURNS trigger AS $$
+BEGIN
+    IF NEW.lyrics IS DISTINCT FROM OLD.lyrics THEN
+        NEW.lyrics_structure := NULL;
+    END IF;
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER songs_reset_lyrics_structure
+    BEFORE UPDATE OF lyrics ON songs
+    FOR EACH ROW EXECUTE FUNCTION reset_lyrics_structure();
@@ -0,0 +1,12 @@
+CREATE OR REPLACE FUNCTION song_snapshot(s songs) RETURNS JSONB AS $$
+    SELECT jsonb_build_object(
+        'group', (SELECT name FROM artists WHERE id = s.artist_id),
+        'song', s.name,
+        'genre', s.genre,
+        'release_date', to_char(s.release_date, 'YYYY-MM-DD'),
+        'lyrics', s.lyrics,
+        'link', s.video
+    )
+$$ LANGUAGE sql STABLE;
+
+ALTER TABLE songs DROP COLUMN IF EXISTS lyrics_synced;
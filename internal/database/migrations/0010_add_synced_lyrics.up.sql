@@ -0,0 +1,15 @@
+-- Текст песни с временными метками строк (LRC), хранится рядом с обычным текстом
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS lyrics_synced JSONB;
+
+-- Текст с временными метками меняет версию песни, поэтому тоже попадает в снимок и в историю изменений
+CREATE OR REPLACE FUNCTION song_snapshot(s songs) RETURNS JSONB AS $$
+    SELECT jsonb_build_object(
+        'group', (SELECT name FROM artists WHERE id = s.artist_id),
+        'song', s.name,
+        'genre', s.genre,
+        'release_date', to_char(s.release_date, 'YYYY-MM-DD'),
+        'lyrics', s.lyrics,
+        'link', s.video,
+        'lyrics_synced', s.lyrics_synced::text
+    )
+$$ LANGUAGE sql STABLE;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS song_lyrics;
+DROP SEQUENCE IF EXISTS song_lyrics_version_seq;
@@ -0,0 +1,16 @@
+-- Переводы текста песни. Язык - тег BCP 47 в нижнем регистре (en, pt-br); оригинальный текст хранится в songs.lyrics
+-- Версия перевода входит в ETag текста песни. Версии берутся из общей последовательности,
+-- чтобы удаленный и заново добавленный перевод не получил прежний ETag.
+CREATE SEQUENCE IF NOT EXISTS song_lyrics_version_seq;
+
+CREATE TABLE IF NOT EXISTS song_lyrics (
+    song_id INT NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+    lang VARCHAR(35) NOT NULL,
+    lyrics TEXT NOT NULL,
+    version BIGINT NOT NULL DEFAULT nextval('song_lyrics_version_seq'),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (song_id, lang)
+);
+
+ALTER SEQUENCE song_lyrics_version_seq OWNED BY song_lyrics.version;
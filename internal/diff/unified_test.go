@@ -0,0 +1,40 @@
+package diff
+
+import "testing"
+
+func TestUnifiedIdentical(t *testing.T) {
+	if got := Unified("a", "b", "one\ntwo\n", "one\r\ntwo", DefaultContext); got != "" {
+		t.Fatalf("expected empty diff, got %q", got)
+	}
+}
+
+func TestUnifiedChangedLine(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n"
+	b := "1\n2\n3\n4\nfive\n6\n7\n8\n9\n"
+
+	want := "--- rev 1\n+++ rev 2\n" +
+		"@@ -2,7 +2,7 @@\n" +
+		" 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n"
+	if got := Unified("rev 1", "rev 2", a, b, DefaultContext); got != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedSeparateHunks(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	b := "A\nb\nc\nd\ne\nf\ng\nh\ni\n"
+
+	want := "--- a\n+++ b\n" +
+		"@@ -1,2 +1,2 @@\n-a\n+A\n b\n" +
+		"@@ -8 +8,2 @@\n h\n+i\n"
+	if got := Unified("a", "b", a, b, 1); got != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedFromEmpty(t *testing.T) {
+	want := "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+x\n+y\n"
+	if got := Unified("a", "b", "", "x\ny", DefaultContext); got != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
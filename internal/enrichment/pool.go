@@ -0,0 +1,169 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"online-library/internal/logger"
+	"online-library/internal/models"
+	"online-library/internal/repository"
+	"sync"
+	"time"
+
+	externalapi "online-library/external_api"
+)
+
+// Config параметры пула обработчиков
+type Config struct {
+	Workers      int           // сколько песен обрабатывается одновременно
+	PollInterval time.Duration // как часто проверять очередь, когда она пуста
+	Lease        time.Duration // на сколько песня захватывается обработчиком
+	MaxAttempts  int           // после стольких неудачных попыток песня переходит в состояние dead
+	RetryBackoff time.Duration // задержка перед первым повтором, удваивается с каждой попыткой
+	MaxBackoff   time.Duration // верхняя граница задержки
+}
+
+// DefaultConfig возвращает параметры, с которыми пул работает, если конфигурация их не задает
+func DefaultConfig() Config {
+	return Config{
+		Workers:      4,
+		PollInterval: 2 * time.Second,
+		Lease:        time.Minute,
+		MaxAttempts:  5,
+		RetryBackoff: 30 * time.Second,
+		MaxBackoff:   30 * time.Minute,
+	}
+}
+
+// Pool загружает данные песен из внешнего API в фоне
+type Pool struct {
+	repo repository.EnrichmentRepository
+	api  externalapi.ExternalAPI
+	cfg  Config
+	now  func() time.Time
+}
+
+func NewPool(repo repository.EnrichmentRepository, api externalapi.ExternalAPI, cfg Config) *Pool {
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+	return &Pool{repo: repo, api: api, cfg: cfg, now: time.Now}
+}
+
+// Run разбирает очередь, пока не будет отменен ctx, и дожидается завершения начатых задач
+func (p *Pool) Run(ctx context.Context) {
+	logger.Log.Infof("Enrichment pool started with %d workers", p.cfg.Workers)
+
+	tasks := make(chan models.EnrichmentTask)
+	var wg sync.WaitGroup
+	for i := 0; i < p.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range tasks {
+				p.process(ctx, task)
+			}
+		}()
+	}
+
+	p.poll(ctx, tasks)
+	close(tasks)
+	wg.Wait()
+	logger.Log.Info("Enrichment pool stopped")
+}
+
+// poll захватывает песни из очереди и раздает их обработчикам
+func (p *Pool) poll(ctx context.Context, tasks chan<- models.EnrichmentTask) {
+	for {
+		claimed, err := p.repo.ClaimEnrichments(p.cfg.Workers, p.cfg.Lease)
+		if err != nil {
+			logger.Log.Errorf("Failed to claim enrichment tasks: %v", err)
+		}
+
+		for _, task := range claimed {
+			select {
+			case tasks <- task:
+			case <-ctx.Done():
+				// Незапущенные задачи вернутся в очередь по истечении lease
+				return
+			}
+		}
+
+		// Очередь могла не опустеть: в этом случае сразу забираем следующую порцию
+		if len(claimed) == p.cfg.Workers {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		timer := time.NewTimer(p.cfg.PollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// process загружает данные одной песни и записывает результат
+func (p *Pool) process(ctx context.Context, task models.EnrichmentTask) {
+	logger.Log.Debugf("Enriching song ID %d: group=%s, song=%s, attempt %d", task.SongID, task.Group, task.Song, task.Attempts)
+
+	if task.Attempts > p.cfg.MaxAttempts {
+		// Прежние захваты истекли без результата: обработчик, вероятно, падает на этой песне
+		logger.Log.Warnf("Enrichment for song ID %d abandoned after %d unfinished attempts", task.SongID, task.Attempts-1)
+		p.fail(task, "enrichment did not finish within the lease", time.Time{}, true)
+		return
+	}
+
+	detail, err := p.api.GetSongDetails(ctx, task.Group, task.Song)
+	if err == nil {
+		if err := p.repo.CompleteEnrichment(task, *detail); err != nil && !errors.Is(err, repository.ErrLeaseLost) {
+			logger.Log.Errorf("Failed to save enrichment for song ID %d: %v", task.SongID, err)
+		}
+		return
+	}
+	if ctx.Err() != nil {
+		// Сервис останавливается: песня вернется в очередь по истечении lease
+		return
+	}
+
+	attempts := task.Attempts
+	// Песню, которой нет во внешнем API, повторно запрашивать бесполезно
+	dead := attempts >= p.cfg.MaxAttempts || errors.Is(err, externalapi.ErrNotFound)
+	retryAt := p.now().Add(p.retryDelay(attempts, err))
+	if dead {
+		logger.Log.Warnf("Enrichment for song ID %d failed permanently after %d attempts: %v", task.SongID, attempts, err)
+	} else {
+		logger.Log.Warnf("Enrichment for song ID %d failed (attempt %d of %d), retry at %s: %v",
+			task.SongID, attempts, p.cfg.MaxAttempts, retryAt.Format(time.RFC3339), err)
+	}
+
+	p.fail(task, err.Error(), retryAt, dead)
+}
+
+// fail записывает неудачную попытку; потерянный захват означает, что песню уже обрабатывает другой обработчик
+func (p *Pool) fail(task models.EnrichmentTask, reason string, retryAt time.Time, dead bool) {
+	if err := p.repo.FailEnrichment(task, reason, retryAt, dead); err != nil && !errors.Is(err, repository.ErrLeaseLost) {
+		logger.Log.Errorf("Failed to record enrichment failure for song ID %d: %v", task.SongID, err)
+	}
+}
+
+// retryDelay возвращает задержку перед следующей попыткой: RetryBackoff*2^(attempts-1),
+// но не меньше времени, на которое разомкнут размыкатель внешнего API
+func (p *Pool) retryDelay(attempts int, err error) time.Duration {
+	delay := p.cfg.RetryBackoff << (attempts - 1)
+	if delay <= 0 || delay > p.cfg.MaxBackoff {
+		delay = p.cfg.MaxBackoff
+	}
+
+	var circuitErr *externalapi.CircuitOpenError
+	if errors.As(err, &circuitErr) && circuitErr.RetryAfter > delay {
+		delay = circuitErr.RetryAfter
+	}
+	return delay
+}
@@ -0,0 +1,167 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	externalapi "online-library/external_api"
+	"online-library/internal/models"
+)
+
+// failure - записанный вызов FailEnrichment
+type failure struct {
+	songID  int
+	retryAt time.Time
+	dead    bool
+}
+
+// stubRepo отдает задачи из queue один раз и запоминает результаты
+type stubRepo struct {
+	mu        sync.Mutex
+	queue     []models.EnrichmentTask
+	completed map[int]models.SongDetail
+	failed    []failure
+}
+
+func (s *stubRepo) ClaimEnrichments(limit int, lease time.Duration) ([]models.EnrichmentTask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := min(limit, len(s.queue))
+	tasks := s.queue[:n]
+	s.queue = s.queue[n:]
+	return tasks, nil
+}
+
+func (s *stubRepo) CompleteEnrichment(task models.EnrichmentTask, detail models.SongDetail) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.completed[task.SongID] = detail
+	return nil
+}
+
+func (s *stubRepo) FailEnrichment(task models.EnrichmentTask, reason string, retryAt time.Time, dead bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed = append(s.failed, failure{songID: task.SongID, retryAt: retryAt, dead: dead})
+	return nil
+}
+
+// stubAPI отвечает ошибкой из errs по названию песни, иначе - данными песни
+type stubAPI struct {
+	errs map[string]error
+}
+
+func (s *stubAPI) GetSongDetails(ctx context.Context, group string, song string) (*models.SongDetail, error) {
+	if err := s.errs[song]; err != nil {
+		return nil, err
+	}
+	return &models.SongDetail{Text: song + " lyrics"}, nil
+}
+
+func newTestPool(repo *stubRepo, api externalapi.ExternalAPI, now time.Time) *Pool {
+	pool := NewPool(repo, api, Config{
+		Workers:      2,
+		PollInterval: time.Millisecond,
+		MaxAttempts:  3,
+		RetryBackoff: time.Minute,
+		MaxBackoff:   time.Hour,
+	})
+	pool.now = func() time.Time { return now }
+	return pool
+}
+
+func TestPoolProcessesQueue(t *testing.T) {
+	unavailable := &externalapi.APIError{StatusCode: http.StatusServiceUnavailable, Status: "503 Service Unavailable"}
+	notFound := &externalapi.APIError{StatusCode: http.StatusNotFound, Status: "404 Not Found"}
+	repo := &stubRepo{
+		completed: map[int]models.SongDetail{},
+		queue: []models.EnrichmentTask{
+			{SongID: 1, Group: "Muse", Song: "Uprising", Attempts: 1},
+			{SongID: 2, Group: "Muse", Song: "Flaky", Attempts: 2},
+			{SongID: 3, Group: "Muse", Song: "Flaky", Attempts: 3},
+			{SongID: 4, Group: "Muse", Song: "Unknown", Attempts: 1},
+			{SongID: 5, Group: "Muse", Song: "Uprising", Attempts: 4},
+		},
+	}
+	api := &stubAPI{errs: map[string]error{"Flaky": unavailable, "Unknown": notFound}}
+	now := time.Now()
+	pool := newTestPool(repo, api, now)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		pool.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		repo.mu.Lock()
+		finished := len(repo.completed)+len(repo.failed) == 5
+		repo.mu.Unlock()
+		if finished {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("pool did not process the queue")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	cancel()
+	<-done
+
+	if _, ok := repo.completed[5]; ok {
+		t.Error("song 5 was enriched after its attempts ran out")
+	}
+	if got := repo.completed[1].Text; got != "Uprising lyrics" {
+		t.Errorf("completed[1].Text = %q, want %q", got, "Uprising lyrics")
+	}
+
+	want := map[int]failure{
+		2: {songID: 2, retryAt: now.Add(2 * time.Minute)}, // вторая попытка: задержка удваивается
+		3: {songID: 3, dead: true},                        // третья попытка из трех
+		4: {songID: 4, dead: true},                        // 404 не повторяется
+		5: {songID: 5, dead: true},                        // захваты исчерпаны без результата
+	}
+	for _, f := range repo.failed {
+		w, ok := want[f.songID]
+		if !ok {
+			t.Errorf("unexpected failure for song %d", f.songID)
+			continue
+		}
+		if f.dead != w.dead {
+			t.Errorf("song %d: dead = %v, want %v", f.songID, f.dead, w.dead)
+		}
+		if !w.dead && !f.retryAt.Equal(w.retryAt) {
+			t.Errorf("song %d: retryAt = %v, want %v", f.songID, f.retryAt, w.retryAt)
+		}
+	}
+}
+
+func TestPoolRetryDelay(t *testing.T) {
+	pool := newTestPool(&stubRepo{}, &stubAPI{}, time.Now())
+
+	tests := []struct {
+		name     string
+		attempts int
+		err      error
+		want     time.Duration
+	}{
+		{"first retry", 1, errors.New("boom"), time.Minute},
+		{"doubles", 3, errors.New("boom"), 4 * time.Minute},
+		{"capped", 20, errors.New("boom"), time.Hour},
+		{"waits for circuit breaker", 1, &externalapi.CircuitOpenError{RetryAfter: 10 * time.Minute}, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pool.retryDelay(tt.attempts, tt.err); got != tt.want {
+				t.Errorf("retryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"online-library/internal/logger"
+	"online-library/internal/models"
+	"online-library/internal/repository"
+)
+
+// ArtistHandler обрабатывает запросы к ресурсу исполнителей.
+type ArtistHandler struct {
+	Repo repository.ArtistRepository
+}
+
+// ResponseArtist структура ответа с данными артиста и страницей его дискографии
+type ResponseArtist struct {
+	models.Artist
+	SongsCount int           `json:"songs_count"` //всего песен у артиста
+	Songs      []models.Song `json:"songs"`       //дискография
+	Page       int           `json:"page"`        //страница
+	PageSize   int           `json:"page_size"`   //размер страницы
+}
+
+func NewArtistHandler(repo repository.ArtistRepository) *ArtistHandler {
+	return &ArtistHandler{Repo: repo}
+}
+
+// artistIDFromPath извлекает ID артиста из пути запроса
+func artistIDFromPath(r *http.Request) (int, error) {
+	artistID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || artistID <= 0 {
+		return 0, errors.New("invalid artist id")
+	}
+	return artistID, nil
+}
+
+// GetArtists возвращает список артистов.
+// @Summary Get Artists
+// @Description Получение списка артистов с возможностью фильтрации по имени.
+// @Tags artists
+// @Accept json
+// @Produce json
+// @Param name query string false "Часть имени артиста, без учета регистра; % и _ ищутся как обычные символы" example("Queen")
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество элементов на странице" default(10) maximum(100)
+// @Success 200 {array} models.Artist "Список артистов"
+// @Failure 500 {object} Problem "Ошибка сервера"
+// @Router /artists [get]
+func (h *ArtistHandler) GetArtists(w http.ResponseWriter, r *http.Request) {
+	logger.Log.Info("GetArtists handler invoked")
+
+	query := r.URL.Query()
+	name := query.Get("name")
+
+	page, limit := parsePage(query, 10)
+
+	artists, err := h.Repo.GetArtists(name, page, limit)
+	if err != nil {
+		logger.Log.Errorf("Failed to fetch artists from DB: %v", err)
+		WriteProblem(w, r, http.StatusInternalServerError, CodeInternal, "Failed to fetch artists")
+		return
+	}
+
+	logger.Log.Infof("Successfully fetched %d artists from DB", len(artists))
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(artists); err != nil {
+		logger.Log.Errorf("Failed to encode response: %v", err)
+		WriteProblem(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+// GetArtist возвращает артиста вместе с дискографией.
+// @Summary Get Artist
+// @Description Получение артиста по ID: биография и постраничная дискография.
+// @Tags artists
+// @Accept json
+// @Produce json
+// @Param id path int true "ID артиста" example(1)
+// @Param page query int false "Номер страницы дискографии" default(1)
+// @Param limit query int false "Количество песен на странице" default(10) maximum(100)
+// @Success 200 {object} ResponseArtist "Артист и его песни"
+// @Failure 400 {object} Problem "Ошибочные параметры запроса"
+// @Failure 404 {object} Problem "Артист не найден"
+// @Failure 500 {object} Problem "Ошибка сервера"
+// @Router /artists/{id} [get]
+func (h *ArtistHandler) GetArtist(w http.ResponseWriter, r *http.Request) {
+	logger.Log.Info("GetArtist handler invoked")
+
+	artistID, err := artistIDFromPath(r)
+	if err != nil {
+		logger.Log.Warnf("Invalid artist id: %s", r.PathValue("id"))
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidID, "Invalid artist id")
+		return
+	}
+
+	query := r.URL.Query()
+	page, limit := parsePage(query, 10)
+
+	artist, err := h.Repo.GetArtistByID(artistID)
+	if err != nil {
+		writeRepoError(w, r, err, "Failed to retrieve artist")
+		return
+	}
+
+	count, err := h.Repo.CountArtistSongs(artistID)
+	if err != nil {
+		WriteProblem(w, r, http.StatusInternalServerError, CodeInternal, "Failed to retrieve discography")
+		return
+	}
+
+	songs, err := h.Repo.GetArtistSongs(artistID, page, limit)
+	if err != nil {
+		WriteProblem(w, r, http.StatusInternalServerError, CodeInternal, "Failed to retrieve discography")
+		return
+	}
+	if songs == nil {
+		songs = []models.Song{}
+	}
+
+	response := ResponseArtist{
+		Artist:     *artist,
+		SongsCount: count,
+		Songs:      songs,
+		Page:       page,
+		PageSize:   limit,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Log.Errorf("Failed to encode response for artist ID %d: %v", artistID, err)
+		WriteProblem(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+// AddArtist добавляет нового артиста.
+// @Summary Add Artist
+// @Description Добавление нового артиста.
+// @Tags artists
+// @Accept json
+// @Produce json
+// @Param artist body models.Artist true "Данные артиста"
+// @Success 201 {object} map[string]int "ID добавленного артиста"
+// @Failure 400 {object} Problem "Ошибочный запрос"
+// @Failure 409 {object} Problem "Артист с таким именем уже существует"
+// @Failure 500 {object} Problem "Ошибка сервера"
+// @Router /artists [post]
+func (h *ArtistHandler) AddArtist(w http.ResponseWriter, r *http.Request) {
+	logger.Log.Info("AddArtist handler invoked")
+
+	var artist models.Artist
+	if err := json.NewDecoder(r.Body).Decode(&artist); err != nil {
+		logger.Log.Errorf("Invalid request payload: %v", err)
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidBody, "Invalid request payload")
+		return
+	}
+
+	if artist.Name == "" {
+		logger.Log.Warn("Name is a required field")
+		WriteProblem(w, r, http.StatusBadRequest, CodeValidationFailed, "Name is a required field")
+		return
+	}
+
+	artistID, err := h.Repo.AddArtist(artist)
+	if err != nil {
+		writeRepoError(w, r, err, "Failed to save artist in database")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]int{"id": artistID})
+}
+
+// UpdateArtist обновляет данные артиста.
+// @Summary Update Artist
+// @Description Обновление имени и биографии артиста по его ID. Переименование меняет версии песен артиста и записывается в историю каждой песни.
+// @Tags artists
+// @Accept json
+// @Produce json
+// @Param id path int true "ID артиста" example(1)
+// @Param artist body models.Artist true "Обновленные данные артиста"
+// @Param X-Author header string false "Автор изменения для истории песен, указывается клиентом и не проверяется"
+// @Success 200 {object} StatusResponse "Артист успешно обновлен"
+// @Failure 400 {object} Problem "Ошибочный запрос"
+// @Failure 404 {object} Problem "Артист не найден"
+// @Failure 409 {object} Problem "Артист с таким именем уже существует"
+// @Failure 500 {object} Problem "Ошибка сервера"
+// @Router /artists/{id} [put]
+func (h *ArtistHandler) UpdateArtist(w http.ResponseWriter, r *http.Request) {
+	logger.Log.Info("UpdateArtist handler invoked")
+
+	artistID, err := artistIDFromPath(r)
+	if err != nil {
+		logger.Log.Warnf("Invalid artist id: %s", r.PathValue("id"))
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidID, "Invalid artist id")
+		return
+	}
+
+	var artist models.Artist
+	if err := json.NewDecoder(r.Body).Decode(&artist); err != nil {
+		logger.Log.Errorf("Invalid request body: %v", err)
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidBody, "Invalid request body")
+		return
+	}
+
+	if artist.Name == "" {
+		logger.Log.Warn("Name is a required field")
+		WriteProblem(w, r, http.StatusBadRequest, CodeValidationFailed, "Name is a required field")
+		return
+	}
+
+	if err := h.Repo.WithAuthor(requestAuthor(r)).UpdateArtist(artistID, artist); err != nil {
+		writeRepoError(w, r, err, "Failed to update artist")
+		return
+	}
+
+	writeStatus(w, artistID, "updated", "Artist updated successfully")
+}
+
+// DeleteArtist удаляет артиста вместе с его песнями.
+// @Summary Delete Artist
+// @Description Удаление артиста по ID. Песни артиста удаляются каскадно, поэтому для артиста с песнями, в том числе с песнями в корзине, требуется confirm=true.
+// @Tags artists
+// @Accept json
+// @Produce json
+// @Param id path int true "ID артиста" example(1)
+// @Param confirm query bool false "Подтверждение каскадного удаления песен"
+// @Param X-Author header string false "Автор изменения для истории песен, указывается клиентом и не проверяется"
+// @Success 200 {object} StatusResponse "Артист успешно удален"
+// @Failure 400 {object} Problem "Ошибочный запрос"
+// @Failure 404 {object} Problem "Артист не найден"
+// @Failure 409 {object} Problem "Требуется подтверждение удаления песен"
+// @Failure 500 {object} Problem "Ошибка сервера"
+// @Router /artists/{id} [delete]
+func (h *ArtistHandler) DeleteArtist(w http.ResponseWriter, r *http.Request) {
+	logger.Log.Info("DeleteArtist handler invoked")
+
+	artistID, err := artistIDFromPath(r)
+	if err != nil {
+		logger.Log.Warnf("Invalid artist id: %s", r.PathValue("id"))
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidID, "Invalid artist id")
+		return
+	}
+
+	confirm, err := queryFlag(r.URL.Query(), "confirm")
+	if err != nil {
+		logger.Log.Warnf("Invalid confirm parameter: %v", err)
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	// Без подтверждения удаляются только артисты без песен, включая песни в корзине
+	if err := h.Repo.WithAuthor(requestAuthor(r)).DeleteArtist(artistID, confirm); err != nil {
+		writeRepoError(w, r, err, "Failed to delete artist")
+		return
+	}
+
+	writeStatus(w, artistID, "deleted", "Artist deleted successfully")
+}
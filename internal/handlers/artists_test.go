@@ -0,0 +1,74 @@
+package handlers_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"online-library/internal/handlers"
+	"online-library/internal/models"
+	"online-library/internal/repository"
+)
+
+func TestGetArtistsEmpty(t *testing.T) {
+	// Репозиторий отдает пустой список, а не nil, и клиент получает []
+	repo := &stubArtistRepo{
+		getArtists: func(name string, page int, limit int) ([]models.Artist, error) {
+			return []models.Artist{}, nil
+		},
+	}
+	rec := serve(t, nil, repo, newRequest(http.MethodGet, "/artists?name=nobody", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if body := strings.TrimSpace(rec.Body.String()); body != "[]" {
+		t.Errorf("body = %s, want []", body)
+	}
+}
+
+func TestDeleteArtist(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		err         error
+		wantCascade bool
+		status      int
+		code        string
+	}{
+		{"songs need confirmation", "/artists/3", repoError(repository.ErrConflict, "artist has 2 songs (2 in trash)"), false, http.StatusConflict, handlers.CodeConflict},
+		{"not found", "/artists/3?confirm=true", repoError(repository.ErrNotFound, "artist with ID 3 not found"), true, http.StatusNotFound, handlers.CodeNotFound},
+		{"deleted", "/artists/3?confirm=1", nil, true, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubArtistRepo{
+				deleteArtist: func(artistID int, cascade bool) error {
+					if artistID != 3 || cascade != tt.wantCascade {
+						t.Errorf("DeleteArtist(%d, %v), want (3, %v)", artistID, cascade, tt.wantCascade)
+					}
+					return tt.err
+				},
+			}
+			req := newRequest(http.MethodDelete, tt.target, "")
+			req.Header.Set("X-Author", "alice")
+			rec := serve(t, nil, repo, req)
+			if repo.author != "alice" {
+				t.Errorf("author = %q, want alice", repo.author)
+			}
+			if tt.err != nil {
+				assertProblem(t, rec, tt.status, tt.code)
+				return
+			}
+			var response handlers.StatusResponse
+			decodeJSON(t, rec, tt.status, &response)
+			if response.Status != "deleted" {
+				t.Errorf("status = %q, want deleted", response.Status)
+			}
+		})
+	}
+
+	t.Run("invalid confirm", func(t *testing.T) {
+		rec := serve(t, nil, nil, newRequest(http.MethodDelete, "/artists/3?confirm=maybe", ""))
+		assertProblem(t, rec, http.StatusBadRequest, handlers.CodeInvalidParameter)
+	})
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"online-library/internal/logger"
+	"online-library/internal/models"
+	"online-library/internal/repository"
+)
+
+// BatchRequest пакетная операция над песнями
+type BatchRequest struct {
+	Action string            `json:"action" enums:"delete,patch"`           //операция
+	IDs    []int             `json:"ids,omitempty"`                         //ID песен; взаимоисключается с filter
+	Filter map[string]string `json:"filter,omitempty" swaggertype:"object"` //фильтр в формате query-параметров GET /songs
+	Patch  json.RawMessage   `json:"patch,omitempty" swaggertype:"object"`  //JSON Merge Patch для action=patch
+	Atomic bool              `json:"atomic,omitempty"`                      //отменить все изменения, если хотя бы одна песня не изменилась
+}
+
+// BatchResponse итог пакетной операции
+type BatchResponse struct {
+	Committed bool                     `json:"committed"` //изменения сохранены
+	Results   []repository.BatchResult `json:"results"`   //итог по каждой песне
+}
+
+// BatchSongs изменяет или удаляет несколько песен.
+// @Summary Batch Songs
+// @Description Удаление (action=delete) или частичное обновление (action=patch, поле patch в формате JSON Merge Patch) песен из списка ids или под фильтром filter (ключи - query-параметры GET /songs, нужен хотя бы один фильтр).
+// @Description Все изменения выполняются одной транзакцией, в ответе - итог по каждой песне. С atomic=true ошибка по любой песне отменяет всю операцию. За раз можно изменить не больше 1000 песен.
+// @Tags songs
+// @Accept json
+// @Produce json
+// @Param request body BatchRequest true "Пакетная операция"
+// @Success 200 {object} BatchResponse "Итог по каждой песне"
+// @Failure 400 {object} Problem "Ошибочный запрос"
+// @Failure 500 {object} Problem "Ошибка сервера"
+// @Router /songs/batch [post]
+func (h *SongHandler) BatchSongs(w http.ResponseWriter, r *http.Request) {
+	logger.Log.Info("BatchSongs handler invoked")
+
+	var req BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Log.Warnf("Invalid request payload: %v", err)
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidBody, "Invalid request payload")
+		return
+	}
+
+	target, err := batchTarget(req)
+	if err != nil {
+		logger.Log.Warnf("Invalid batch target: %v", err)
+		WriteProblem(w, r, http.StatusBadRequest, CodeValidationFailed, err.Error())
+		return
+	}
+
+	var results []repository.BatchResult
+	switch req.Action {
+	case "delete":
+		results, err = h.repoFor(r).DeleteSongs(target, req.Atomic)
+	case "patch":
+		if len(req.Patch) == 0 {
+			WriteProblem(w, r, http.StatusBadRequest, CodeValidationFailed, "patch is required for action patch")
+			return
+		}
+		var patch models.SongPatch
+		patch, err = parseMergePatch(bytes.NewReader(req.Patch))
+		if err != nil {
+			logger.Log.Warnf("Invalid merge patch: %v", err)
+			WriteProblem(w, r, http.StatusBadRequest, CodeInvalidBody, err.Error())
+			return
+		}
+		results, err = h.repoFor(r).PatchSongs(target, patch, req.Atomic)
+	default:
+		logger.Log.Warnf("Invalid batch action: %s", req.Action)
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidParameter, "Invalid action, expected delete or patch")
+		return
+	}
+	if err != nil {
+		writeRepoError(w, r, err, "Failed to apply batch")
+		return
+	}
+
+	response := BatchResponse{Committed: true, Results: results}
+	if response.Results == nil {
+		response.Results = []repository.BatchResult{}
+	}
+	for _, result := range results {
+		if result.Status == repository.BatchRolledBack {
+			response.Committed = false
+			break
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// batchTarget проверяет, что задан ровно один способ выбора песен
+func batchTarget(req BatchRequest) (repository.BatchTarget, error) {
+	switch {
+	case len(req.IDs) > 0 && req.Filter != nil:
+		return repository.BatchTarget{}, errors.New("ids and filter are mutually exclusive")
+	case len(req.IDs) > 0:
+		for _, id := range req.IDs {
+			if id <= 0 {
+				return repository.BatchTarget{}, errors.New("ids must be positive")
+			}
+		}
+		return repository.BatchTarget{IDs: req.IDs}, nil
+	case req.Filter != nil:
+		query := url.Values{}
+		for key, value := range req.Filter {
+			switch key {
+			case "group", "title", "genre", "released_after", "released_before", "has_lyrics", "match":
+			default:
+				return repository.BatchTarget{}, errors.New("unknown filter " + key)
+			}
+			query.Set(key, value)
+		}
+		filter, err := parseSongFilter(query)
+		if err != nil {
+			return repository.BatchTarget{}, err
+		}
+		return repository.BatchTarget{Filter: &filter}, nil
+	}
+	return repository.BatchTarget{}, errors.New("ids or filter is required")
+}
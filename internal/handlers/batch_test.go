@@ -0,0 +1,84 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"online-library/internal/handlers"
+	"online-library/internal/models"
+	"online-library/internal/repository"
+)
+
+func TestBatchSongsAtomicRollback(t *testing.T) {
+	repo := &stubSongRepo{
+		deleteSongs: func(target repository.BatchTarget, atomic bool) ([]repository.BatchResult, error) {
+			if len(target.IDs) != 2 || target.Filter != nil || !atomic {
+				t.Errorf("DeleteSongs(%+v, %v), want ids [1 2] atomically", target, atomic)
+			}
+			return []repository.BatchResult{
+				{ID: 1, Status: repository.BatchRolledBack},
+				{ID: 2, Status: repository.BatchNotFound, Error: "song with ID 2 not found"},
+			}, nil
+		},
+	}
+	rec := serve(t, repo, nil, newRequest(http.MethodPost, "/songs/batch", `{"action": "delete", "ids": [1, 2], "atomic": true}`))
+
+	var response handlers.BatchResponse
+	decodeJSON(t, rec, http.StatusOK, &response)
+	if response.Committed || len(response.Results) != 2 {
+		t.Errorf("response = %+v, want 2 results, not committed", response)
+	}
+}
+
+func TestBatchSongsPatchByFilter(t *testing.T) {
+	repo := &stubSongRepo{
+		patchSongs: func(target repository.BatchTarget, patch models.SongPatch, atomic bool) ([]repository.BatchResult, error) {
+			if target.Filter == nil || target.Filter.Genre != "rock" || patch["genre"] == nil || *patch["genre"] != "Rock" {
+				t.Errorf("PatchSongs(%+v, %v), want genre filter rock and genre Rock", target, patch)
+			}
+			return nil, nil
+		},
+	}
+	rec := serve(t, repo, nil, newRequest(http.MethodPost, "/songs/batch", `{"action": "patch", "filter": {"genre": "rock"}, "patch": {"genre": "Rock"}}`))
+
+	var response handlers.BatchResponse
+	decodeJSON(t, rec, http.StatusOK, &response)
+	if !response.Committed || response.Results == nil {
+		t.Errorf("response = %+v, want committed with empty results", response)
+	}
+}
+
+func TestBatchSongsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		err    error
+		status int
+		code   string
+	}{
+		{"invalid body", `{"action":`, nil, http.StatusBadRequest, handlers.CodeInvalidBody},
+		{"no target", `{"action": "delete"}`, nil, http.StatusBadRequest, handlers.CodeValidationFailed},
+		{"ids and filter", `{"action": "delete", "ids": [1], "filter": {"genre": "rock"}}`, nil, http.StatusBadRequest, handlers.CodeValidationFailed},
+		{"negative id", `{"action": "delete", "ids": [-1]}`, nil, http.StatusBadRequest, handlers.CodeValidationFailed},
+		{"unknown filter", `{"action": "delete", "filter": {"sort": "id"}}`, nil, http.StatusBadRequest, handlers.CodeValidationFailed},
+		{"unknown action", `{"action": "merge", "ids": [1]}`, nil, http.StatusBadRequest, handlers.CodeInvalidParameter},
+		{"patch missing", `{"action": "patch", "ids": [1]}`, nil, http.StatusBadRequest, handlers.CodeValidationFailed},
+		{"invalid patch", `{"action": "patch", "ids": [1], "patch": {"song": null}}`, nil, http.StatusBadRequest, handlers.CodeInvalidBody},
+		{"too many songs", `{"action": "delete", "filter": {"genre": "rock"}}`, repoError(repository.ErrValidation, "filter matches more than 1000 songs"), http.StatusBadRequest, handlers.CodeValidationFailed},
+		{"duplicate", `{"action": "patch", "ids": [1, 2], "patch": {"song": "Same"}}`, repoError(repository.ErrConflict, "song already exists"), http.StatusConflict, handlers.CodeConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubSongRepo{
+				deleteSongs: func(target repository.BatchTarget, atomic bool) ([]repository.BatchResult, error) {
+					return nil, tt.err
+				},
+				patchSongs: func(target repository.BatchTarget, patch models.SongPatch, atomic bool) ([]repository.BatchResult, error) {
+					return nil, tt.err
+				},
+			}
+			rec := serve(t, repo, nil, newRequest(http.MethodPost, "/songs/batch", tt.body))
+			assertProblem(t, rec, tt.status, tt.code)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"online-library/internal/logger"
+	"online-library/internal/repository"
+)
+
+// repoErrorStatus выбирает HTTP-статус по виду ошибки репозитория
+func repoErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, repository.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, repository.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// repoErrorCode выбирает код ошибки по виду ошибки репозитория
+func repoErrorCode(err error) string {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return CodeNotFound
+	case errors.Is(err, repository.ErrConflict):
+		return CodeConflict
+	case errors.Is(err, repository.ErrValidation):
+		return CodeValidationFailed
+	case errors.Is(err, repository.ErrVersionMismatch):
+		return CodeVersionMismatch
+	default:
+		return CodeInternal
+	}
+}
+
+// writeRepoError отвечает клиенту по ошибке репозитория.
+// Для ошибок клиента отдается сообщение репозитория, для остальных - общее сообщение msg.
+func writeRepoError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	status, code := repoErrorStatus(err), repoErrorCode(err)
+	if status == http.StatusInternalServerError {
+		logger.Log.Errorf("%s: %v", msg, err)
+		WriteProblem(w, r, status, code, msg)
+		return
+	}
+
+	logger.Log.Warnf("%s: %v", msg, err)
+	var repoErr *repository.Error
+	if errors.As(err, &repoErr) {
+		WriteProblem(w, r, status, code, repoErr.Message)
+		return
+	}
+	WriteProblem(w, r, status, code, msg)
+}
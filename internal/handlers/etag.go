@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"online-library/internal/logger"
+	"online-library/internal/models"
+)
+
+// songETag формирует значение ETag по версии песни
+func songETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// lyricsETag формирует ETag представления текста песни: к версии песни добавляются язык и версия перевода
+// и представление, отличное от JSON с куплетами: тип содержимого (text/html) или формат (lrc, json-timed).
+// Так у каждого представления свой ETag, а ETag меняется вместе с переводом.
+// Версия песни остается в начале, поэтому ETag текста подходит для If-Match при изменении песни.
+func lyricsETag(songLyrics *models.SongLyrics, representation string) string {
+	tag := strconv.Itoa(songLyrics.Version)
+	if songLyrics.Lang != "" {
+		tag += "-" + songLyrics.Lang + "." + strconv.FormatInt(songLyrics.LangVersion, 10)
+	}
+	if representation != mediaJSON {
+		tag += "-" + strings.TrimPrefix(representation, "text/")
+	}
+	return `"` + tag + `"`
+}
+
+// parseIfMatch разбирает заголовок If-Match: список ETag через запятую или "*".
+// Отсутствующий заголовок и "*" дают unconditional - запись меняется без проверки версии.
+// If-Match сравнивает ETag строго (RFC 7232, 3.1), поэтому слабые ETag пропускаются и ни с чем не совпадают:
+// заголовок только из слабых ETag дает пустой список версий и ответ 412.
+// ETag представления текста (lyricsETag) дает версию песни, из которой он получен.
+func parseIfMatch(r *http.Request) (versions []int, unconditional bool, err error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return nil, true, nil
+	}
+
+	for value != "" {
+		weak := strings.HasPrefix(value, "W/")
+		value = strings.TrimPrefix(value, "W/")
+		if !strings.HasPrefix(value, `"`) {
+			return nil, false, errors.New("If-Match must be a list of quoted ETags")
+		}
+		end := strings.IndexByte(value[1:], '"')
+		if end < 0 {
+			return nil, false, errors.New("If-Match must be a list of quoted ETags")
+		}
+		tag := value[1 : end+1]
+		value = strings.TrimSpace(value[end+2:])
+		if value != "" {
+			if value[0] != ',' {
+				return nil, false, errors.New("If-Match ETags must be separated by commas")
+			}
+			value = strings.TrimSpace(value[1:])
+		}
+
+		prefix, _, _ := strings.Cut(tag, "-")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, false, errors.New("If-Match does not contain a song version")
+		}
+		if !weak {
+			versions = append(versions, version)
+		}
+	}
+	return versions, false, nil
+}
+
+// ifMatchVersion возвращает версию песни, с которой репозиторий сверит запись: 0 - без проверки.
+// Из списка ETag выбирается совпавший с текущей версией, репозиторий повторно сверит ее при записи.
+// Если заголовок ошибочен или ни один сильный ETag не совпал, пишет ответ 400 или 412 и возвращает false.
+func (h *SongHandler) ifMatchVersion(w http.ResponseWriter, r *http.Request, songID int) (int, bool) {
+	versions, unconditional, err := parseIfMatch(r)
+	if err != nil {
+		logger.Log.Warnf("Invalid If-Match header for song ID %d: %v", songID, err)
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidHeader, err.Error())
+		return 0, false
+	}
+	if unconditional {
+		return 0, true
+	}
+	if len(versions) == 1 {
+		return versions[0], true
+	}
+
+	if len(versions) > 0 {
+		song, err := h.Repo.GetSongByID(songID)
+		if err != nil {
+			writeRepoError(w, r, err, "Failed to retrieve song")
+			return 0, false
+		}
+		if slices.Contains(versions, song.Version) {
+			return song.Version, true
+		}
+	}
+
+	logger.Log.Warnf("If-Match for song ID %d does not match the current version", songID)
+	WriteProblem(w, r, http.StatusPreconditionFailed, CodeVersionMismatch, "Song has been modified, If-Match does not match the current version")
+	return 0, false
+}
@@ -0,0 +1,152 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"online-library/internal/handlers"
+	"online-library/internal/lyrics"
+	"online-library/internal/models"
+	"online-library/internal/repository"
+)
+
+// lyricsRepo отдает текст песни версии 5; с запрошенным языком - перевод версии langVersion
+func lyricsRepo(langVersion int64) *stubSongRepo {
+	return &stubSongRepo{
+		getLyricsIn: func(songID int, langs []string) (*models.SongLyrics, error) {
+			text := "First line\nSecond line\n\nThird line"
+			songLyrics := &models.SongLyrics{SongID: songID, Song: "Song", Lyrics: text, Version: 5, Structure: lyrics.Parse(text)}
+			if len(langs) > 0 {
+				songLyrics.Lang = langs[0]
+				songLyrics.LangVersion = langVersion
+			}
+			return songLyrics, nil
+		},
+	}
+}
+
+func TestLyricsETag(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		accept string
+		etag   string
+	}{
+		{"original json", "/songs/1/lyrics", "application/json", `"5"`},
+		{"original text", "/songs/1/lyrics", "text/plain", `"5-plain"`},
+		{"original markdown", "/songs/1/lyrics", "text/markdown", `"5-markdown"`},
+		{"original html", "/songs/1/lyrics", "text/html", `"5-html"`},
+		{"translation", "/songs/1/lyrics?lang=en", "application/json", `"5-en.12"`},
+		{"translated html", "/songs/1/lyrics?lang=pt-br", "text/html", `"5-pt-br.12-html"`},
+		{"stanza", "/songs/1/lyrics/stanzas/1?lang=en", "", `"5-en.12"`},
+		{"lines", "/songs/1/lyrics/lines?lang=en", "", `"5-en.12"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest(http.MethodGet, tt.target, "")
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := serve(t, lyricsRepo(12), nil, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+			if etag := rec.Header().Get("ETag"); etag != tt.etag {
+				t.Errorf("ETag = %s, want %s", etag, tt.etag)
+			}
+		})
+	}
+}
+
+func TestLyricsETagChangesWithTranslation(t *testing.T) {
+	// Изменение перевода не меняет версию песни, но меняет ETag текста на этом языке
+	etags := make(map[string]bool)
+	for _, version := range []int64{3, 4} {
+		rec := serve(t, lyricsRepo(version), nil, newRequest(http.MethodGet, "/songs/1/lyrics?lang=en", ""))
+		etags[rec.Header().Get("ETag")] = true
+	}
+	if len(etags) != 2 {
+		t.Errorf("ETag did not change with translation version: %v", etags)
+	}
+}
+
+func TestIfMatchAcceptsLyricsETag(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch string
+		version int
+	}{
+		{"song etag", `"5"`, 5},
+		{"translated html etag", `"5-pt-br.12-html"`, 5},
+		{"any", "*", 0},
+		{"list with current version", `"3", W/"5", "5-plain"`, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubSongRepo{
+				getSong: func(songID int) (*models.Song, error) {
+					return &models.Song{SongID: songID, Version: 5}, nil
+				},
+				patchSong: func(songID int, patch models.SongPatch, expectedVersion int) (*models.Song, error) {
+					if expectedVersion != tt.version {
+						t.Errorf("expectedVersion = %d, want %d", expectedVersion, tt.version)
+					}
+					return &models.Song{SongID: songID, Version: 6}, nil
+				},
+			}
+			req := newRequest(http.MethodPatch, "/songs/1", `{"genre": "rock"}`)
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+			req.Header.Set("If-Match", tt.ifMatch)
+			rec := serve(t, repo, nil, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestIfMatchInvalid(t *testing.T) {
+	for _, value := range []string{`"abc"`, `"0"`, `5`, `"-en.1"`, `"5" "6"`, `"5", *`, `"5`} {
+		req := newRequest(http.MethodPatch, "/songs/1", `{"genre": "rock"}`)
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		req.Header.Set("If-Match", value)
+		rec := serve(t, nil, nil, req)
+		assertProblem(t, rec, http.StatusBadRequest, handlers.CodeInvalidHeader)
+	}
+}
+
+func TestIfMatchPreconditionFailed(t *testing.T) {
+	// Единственный сильный ETag сверяет репозиторий, слабый рядом с ним не учитывается
+	repo := &stubSongRepo{
+		patchSong: func(songID int, patch models.SongPatch, expectedVersion int) (*models.Song, error) {
+			if expectedVersion != 3 {
+				t.Errorf("expectedVersion = %d, want 3", expectedVersion)
+			}
+			return nil, repoError(repository.ErrVersionMismatch, "song version is 5, not 3")
+		},
+	}
+	req := newRequest(http.MethodPatch, "/songs/1", `{"genre": "rock"}`)
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", `"3", W/"5"`)
+	assertProblem(t, serve(t, repo, nil, req), http.StatusPreconditionFailed, handlers.CodeVersionMismatch)
+
+	// Слабые ETag не совпадают при строгом сравнении, даже если версия в них текущая
+	for _, value := range []string{`W/"5"`, `W/"5-plain", W/"5"`, `"3", "4"`} {
+		t.Run(value, func(t *testing.T) {
+			repo := &stubSongRepo{
+				getSong: func(songID int) (*models.Song, error) {
+					return &models.Song{SongID: songID, Version: 5}, nil
+				},
+				patchSong: func(songID int, patch models.SongPatch, expectedVersion int) (*models.Song, error) {
+					t.Errorf("PatchSong called with If-Match %s", value)
+					return nil, nil
+				},
+			}
+			req := newRequest(http.MethodPatch, "/songs/1", `{"genre": "rock"}`)
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+			req.Header.Set("If-Match", value)
+			rec := serve(t, repo, nil, req)
+			assertProblem(t, rec, http.StatusPreconditionFailed, handlers.CodeVersionMismatch)
+		})
+	}
+}
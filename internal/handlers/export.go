@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"online-library/internal/logger"
+	"online-library/internal/models"
+)
+
+// exportFlushEvery - через сколько песен выгрузка отправляется клиенту, не дожидаясь заполнения буфера
+const exportFlushEvery = 100
+
+// exportCSVColumns - колонки CSV-выгрузки; файл можно снова загрузить через POST /songs/import
+var exportCSVColumns = []string{"id", "group", "song", "genre", "release_date", "lyrics", "link"}
+
+// ExportSongs выгружает каталог песен.
+// @Summary Export Songs
+// @Description Потоковая выгрузка всех песен под фильтром в JSON (массив), CSV или JSON Lines. Фильтры и сортировка - как в GET /songs, пагинации нет. CSV-выгрузку можно загрузить обратно через POST /songs/import.
+// @Tags songs
+// @Produce json
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "Формат выгрузки" Enums(json, csv, ndjson) default(json)
+// @Param group query string false "Название группы" example("Queen")
+// @Param title query string false "Название песни" example("Bohemian Rhapsody")
+// @Param match query string false "Сравнение group и title: подстрока или точное совпадение" Enums(substring, exact) default(substring)
+// @Param genre query string false "Жанр" example("Rock")
+// @Param released_after query string false "Дата выпуска не раньше (YYYY-MM-DD или DD.MM.YYYY)" example("1970-01-01")
+// @Param released_before query string false "Дата выпуска не позже (YYYY-MM-DD или DD.MM.YYYY)" example("1999-12-31")
+// @Param has_lyrics query bool false "Только песни с текстом (true) или без текста (false)"
+// @Param sort query string false "Сортировка: поля через запятую, минус - по убыванию. Поля: id, song, group, genre, release_date" example("-release_date,song")
+// @Success 200 {array} models.Song "Песни под фильтром"
+// @Header 200 {string} Content-Disposition "Имя файла выгрузки"
+// @Failure 400 {object} Problem "Ошибочные параметры запроса"
+// @Failure 500 {object} Problem "Ошибка сервера"
+// @Router /songs/export [get]
+func (h *SongHandler) ExportSongs(w http.ResponseWriter, r *http.Request) {
+	logger.Log.Info("ExportSongs handler invoked")
+
+	query := r.URL.Query()
+	filter, err := parseSongFilter(query)
+	if err != nil {
+		logger.Log.Warnf("Invalid query parameters: %v", err)
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	format := query.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	encoder, contentType, ok := newSongEncoder(format, w)
+	if !ok {
+		logger.Log.Warnf("Unsupported export format: %s", format)
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidParameter, "Invalid format parameter, expected json, csv or ndjson")
+		return
+	}
+
+	controller := http.NewResponseController(w)
+	started := false
+	start := func() error {
+		started = true
+		filename := fmt.Sprintf("songs-%s.%s", time.Now().Format("2006-01-02"), format)
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+		return encoder.begin()
+	}
+
+	count := 0
+	for song, err := range h.Repo.ExportSongs(r.Context(), filter) {
+		if err != nil {
+			if !started {
+				writeRepoError(w, r, err, "Failed to export songs")
+				return
+			}
+			// Заголовки уже отправлены: остается оборвать выгрузку, чтобы клиент не принял ее за полную
+			logger.Log.Errorf("Export aborted after %d songs: %v", count, err)
+			panic(http.ErrAbortHandler)
+		}
+		if !started {
+			if err := start(); err != nil {
+				logger.Log.Warnf("Failed to write export: %v", err)
+				return
+			}
+		}
+		if err := encoder.write(song); err != nil {
+			// Клиент отключился
+			logger.Log.Warnf("Failed to write export: %v", err)
+			return
+		}
+		count++
+		if count%exportFlushEvery == 0 {
+			encoder.flush()
+			controller.Flush()
+		}
+	}
+
+	if !started {
+		if err := start(); err != nil {
+			logger.Log.Warnf("Failed to write export: %v", err)
+			return
+		}
+	}
+	if err := encoder.end(); err != nil {
+		logger.Log.Warnf("Failed to write export: %v", err)
+		return
+	}
+	logger.Log.Infof("Exported %d songs as %s", count, format)
+}
+
+// songEncoder записывает песни в ответ по одной
+type songEncoder interface {
+	begin() error
+	write(song models.Song) error
+	flush()
+	end() error
+}
+
+// newSongEncoder создает кодировщик выгрузки и возвращает Content-Type формата
+func newSongEncoder(format string, w io.Writer) (songEncoder, string, bool) {
+	switch format {
+	case "json":
+		return &jsonArrayEncoder{w: w, enc: json.NewEncoder(w)}, "application/json", true
+	case "ndjson":
+		return &ndjsonEncoder{enc: json.NewEncoder(w)}, "application/x-ndjson", true
+	case "csv":
+		return &csvEncoder{w: csv.NewWriter(w)}, "text/csv; charset=utf-8", true
+	}
+	return nil, "", false
+}
+
+// jsonArrayEncoder пишет JSON-массив, не собирая его в памяти
+type jsonArrayEncoder struct {
+	w     io.Writer
+	enc   *json.Encoder
+	count int
+}
+
+func (e *jsonArrayEncoder) begin() error {
+	_, err := io.WriteString(e.w, "[")
+	return err
+}
+
+func (e *jsonArrayEncoder) write(song models.Song) error {
+	if e.count > 0 {
+		if _, err := io.WriteString(e.w, ","); err != nil {
+			return err
+		}
+	}
+	e.count++
+	return e.enc.Encode(song)
+}
+
+func (e *jsonArrayEncoder) flush() {}
+
+func (e *jsonArrayEncoder) end() error {
+	_, err := io.WriteString(e.w, "]\n")
+	return err
+}
+
+// ndjsonEncoder пишет по одной песне в строке
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonEncoder) begin() error { return nil }
+
+func (e *ndjsonEncoder) write(song models.Song) error { return e.enc.Encode(song) }
+
+func (e *ndjsonEncoder) flush() {}
+
+func (e *ndjsonEncoder) end() error { return nil }
+
+// csvEncoder пишет CSV с заголовком exportCSVColumns
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvEncoder) begin() error {
+	return e.w.Write(exportCSVColumns)
+}
+
+func (e *csvEncoder) write(song models.Song) error {
+	return e.w.Write([]string{strconv.Itoa(song.SongID), song.Group, song.Song, song.Genre,
+		song.ReleaseDate, song.Lyrics, song.Link})
+}
+
+func (e *csvEncoder) flush() {
+	e.w.Flush()
+}
+
+func (e *csvEncoder) end() error {
+	e.w.Flush()
+	return e.w.Error()
+}
@@ -0,0 +1,90 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"net/http"
+	"strings"
+	"testing"
+
+	"online-library/internal/handlers"
+	"online-library/internal/models"
+	"online-library/internal/repository"
+)
+
+// exportRepo выгружает songs, после них - ошибку err, если она задана
+func exportRepo(songs []models.Song, err error) *stubSongRepo {
+	return &stubSongRepo{
+		exportSongs: func(ctx context.Context, filter repository.SongFilter) iter.Seq2[models.Song, error] {
+			return func(yield func(models.Song, error) bool) {
+				for _, song := range songs {
+					if !yield(song, nil) {
+						return
+					}
+				}
+				if err != nil {
+					yield(models.Song{}, err)
+				}
+			}
+		},
+	}
+}
+
+func TestExportSongsFormats(t *testing.T) {
+	songs := []models.Song{{SongID: 1, Group: "Muse", Song: "Uprising"}, {SongID: 2, Group: "Queen", Song: "Innuendo, Part 2"}}
+	tests := []struct {
+		format      string
+		contentType string
+		body        string
+	}{
+		{"json", "application/json", "[{\"group\":\"Muse\",\"song\":\"Uprising\",\"song_id\":1}\n,{\"group\":\"Queen\""},
+		{"ndjson", "application/x-ndjson", "{\"group\":\"Muse\",\"song\":\"Uprising\",\"song_id\":1}\n{\"group\":\"Queen\""},
+		{"csv", "text/csv; charset=utf-8", "id,group,song,genre,release_date,lyrics,link\n1,Muse,Uprising,,,,\n2,Queen,\"Innuendo, Part 2\",,,,\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			rec := serve(t, exportRepo(songs, nil), nil, newRequest(http.MethodGet, "/songs/export?format="+tt.format, ""))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", ct, tt.contentType)
+			}
+			if !strings.HasPrefix(rec.Body.String(), tt.body) {
+				t.Errorf("body = %q, want prefix %q", rec.Body.String(), tt.body)
+			}
+			if disposition := rec.Header().Get("Content-Disposition"); !strings.Contains(disposition, "."+tt.format) {
+				t.Errorf("Content-Disposition = %q, want .%s file", disposition, tt.format)
+			}
+		})
+	}
+}
+
+func TestExportSongsEmpty(t *testing.T) {
+	rec := serve(t, exportRepo(nil, nil), nil, newRequest(http.MethodGet, "/songs/export", ""))
+	if rec.Code != http.StatusOK || rec.Body.String() != "[]\n" {
+		t.Errorf("status = %d, body = %q, want 200 and []", rec.Code, rec.Body)
+	}
+}
+
+func TestExportSongsErrors(t *testing.T) {
+	assertProblem(t, serve(t, nil, nil, newRequest(http.MethodGet, "/songs/export?format=xml", "")), http.StatusBadRequest, handlers.CodeInvalidParameter)
+	assertProblem(t, serve(t, nil, nil, newRequest(http.MethodGet, "/songs/export?sort=lyrics", "")), http.StatusBadRequest, handlers.CodeInvalidParameter)
+
+	// Ошибка до первой песни отдается как problem+json
+	repo := exportRepo(nil, repoError(repository.ErrValidation, "invalid filter"))
+	assertProblem(t, serve(t, repo, nil, newRequest(http.MethodGet, "/songs/export", "")), http.StatusBadRequest, handlers.CodeValidationFailed)
+}
+
+func TestExportSongsAbortsMidStream(t *testing.T) {
+	// После начала выгрузки ошибка обрывает ответ, чтобы клиент не принял неполный файл за полный
+	repo := exportRepo([]models.Song{{SongID: 1}}, errors.New("connection lost"))
+	defer func() {
+		if recovered := recover(); recovered != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", recovered)
+		}
+	}()
+	serve(t, repo, nil, newRequest(http.MethodGet, "/songs/export", ""))
+	t.Error("export finished, want aborted response")
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"online-library/internal/repository"
+)
+
+// parseSongFilter читает из query-параметров фильтры и сортировку списка песен
+func parseSongFilter(query url.Values) (repository.SongFilter, error) {
+	filter := repository.SongFilter{
+		Group:          query.Get("group"),
+		Title:          query.Get("title"),
+		Genre:          query.Get("genre"),
+		ReleasedAfter:  query.Get("released_after"),
+		ReleasedBefore: query.Get("released_before"),
+	}
+
+	if value := query.Get("has_lyrics"); value != "" {
+		hasLyrics, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid has_lyrics parameter %q", value)
+		}
+		filter.HasLyrics = &hasLyrics
+	}
+
+	switch match := query.Get("match"); match {
+	case "", "substring":
+	case "exact":
+		filter.Exact = true
+	default:
+		return filter, fmt.Errorf("invalid match parameter %q, expected substring or exact", match)
+	}
+
+	sort, err := repository.ParseSort(query.Get("sort"))
+	if err != nil {
+		return filter, err
+	}
+	filter.Sort = sort
+
+	return filter, nil
+}
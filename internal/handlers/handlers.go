@@ -1,15 +1,19 @@
 package handlers
 
 import (
-	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
 
 	"net/http"
 
 	externalapi "online-library/external_api"
 
 	"online-library/internal/logger"
+	"online-library/internal/lyrics"
 	"online-library/internal/models"
 	"online-library/internal/repository"
 	"strconv"
@@ -19,10 +23,29 @@ import (
 // SongHandlerInterface определяет контракт для обработки запросов песен.
 type SongHandlerInterface interface {
 	GetSongs(w http.ResponseWriter, r *http.Request)
+	GetSong(w http.ResponseWriter, r *http.Request)
+	SearchSongs(w http.ResponseWriter, r *http.Request)
 	GetSongLyrics(w http.ResponseWriter, r *http.Request)
 	AddSong(w http.ResponseWriter, r *http.Request)
 	UpdateSong(w http.ResponseWriter, r *http.Request)
+	PatchSong(w http.ResponseWriter, r *http.Request)
 	DeleteSong(w http.ResponseWriter, r *http.Request)
+	RequeueEnrichment(w http.ResponseWriter, r *http.Request)
+	ImportSongs(w http.ResponseWriter, r *http.Request)
+	ExportSongs(w http.ResponseWriter, r *http.Request)
+	BatchSongs(w http.ResponseWriter, r *http.Request)
+	GetTrash(w http.ResponseWriter, r *http.Request)
+	RestoreSong(w http.ResponseWriter, r *http.Request)
+	GetSongRevisions(w http.ResponseWriter, r *http.Request)
+	DiffSongRevisions(w http.ResponseWriter, r *http.Request)
+	RevertSong(w http.ResponseWriter, r *http.Request)
+	GetLyricsStanza(w http.ResponseWriter, r *http.Request)
+	GetLyricsLines(w http.ResponseWriter, r *http.Request)
+	PutSyncedLyrics(w http.ResponseWriter, r *http.Request)
+	DeleteSyncedLyrics(w http.ResponseWriter, r *http.Request)
+	GetTranslations(w http.ResponseWriter, r *http.Request)
+	PutTranslation(w http.ResponseWriter, r *http.Request)
+	DeleteTranslation(w http.ResponseWriter, r *http.Request)
 }
 
 // SongHandler реализует SongHandlerInterface.
@@ -33,11 +56,15 @@ type SongHandler struct {
 
 // ResponseLyrics структура ответа с текстом песни и пагинацией
 type ResponseLyrics struct {
-	Song     string   `json:"song"`      //название песни
-	SongID   string   `json:"song_id"`   //id песни
-	Lyrics   []string `json:"lyrics"`    //текст песни
-	Page     int      `json:"page"`      //страница
-	PageSize int      `json:"page_size"` //размер страницы
+	Song         string           `json:"song"`           //название песни
+	SongID       string           `json:"song_id"`        //id песни
+	Lang         string           `json:"lang,omitempty"` //язык перевода, нет у оригинального текста
+	Lyrics       []string         `json:"lyrics"`         //текст куплетов страницы без заголовков
+	Sections     []lyrics.Section `json:"sections"`       //куплеты страницы с видом и номерами строк
+	TotalStanzas int              `json:"total_stanzas"`  //всего куплетов
+	TotalLines   int              `json:"total_lines"`    //всего строк текста
+	Page         int              `json:"page"`           //страница
+	PageSize     int              `json:"page_size"`      //размер страницы
 }
 
 func NewSongHandler(repo repository.SongRepository, api externalapi.ExternalAPI) *SongHandler {
@@ -47,149 +74,203 @@ func NewSongHandler(repo repository.SongRepository, api externalapi.ExternalAPI)
 	}
 }
 
+// songIDFromPath извлекает ID песни из пути запроса
+func songIDFromPath(r *http.Request) (int, error) {
+	songID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || songID <= 0 {
+		return 0, errors.New("invalid song id")
+	}
+	return songID, nil
+}
+
 // GetSongs возвращает список песен с фильтрацией.
 // @Summary Get Songs
-// @Description Получение списка песен с возможностью фильтрации по группе и названию.
+// @Description Получение списка песен с фильтрацией и сортировкой. Все фильтры необязательны. Поддерживается пагинация номером страницы и курсором (keyset): курсор не дает дублей и пропусков при вставке новых песен.
 // @Tags songs
 // @Accept json
 // @Produce json
 // @Param group query string false "Название группы" example("Queen")
 // @Param title query string false "Название песни" example("Bohemian Rhapsody")
+// @Param match query string false "Сравнение group и title: подстрока или точное совпадение" Enums(substring, exact) default(substring)
+// @Param genre query string false "Жанр" example("Rock")
+// @Param released_after query string false "Дата выпуска не раньше (YYYY-MM-DD или DD.MM.YYYY)" example("1970-01-01")
+// @Param released_before query string false "Дата выпуска не позже (YYYY-MM-DD или DD.MM.YYYY)" example("1999-12-31")
+// @Param has_lyrics query bool false "Только песни с текстом (true) или без текста (false)"
+// @Param sort query string false "Сортировка: поля через запятую, минус - по убыванию. Поля: id, song, group, genre, release_date" example("-release_date,song")
 // @Param page query int false "Номер страницы" default(1)
-// @Param limit query int false "Количество элементов на странице" default(10)
-// @Success 200 {array} models.Song "Список песен"
-// @Failure 400 {object} map[string]string "Ошибочные параметры запроса"
-// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Param limit query int false "Количество элементов на странице" default(10) maximum(100)
+// @Param cursor query string false "Курсор из next_cursor предыдущего ответа; заменяет page"
+// @Success 200 {object} SongListResponse "Страница списка песен"
+// @Failure 400 {object} Problem "Ошибочные параметры запроса"
+// @Failure 500 {object} Problem "Ошибка сервера"
 // @Router /songs [get]
 func (h *SongHandler) GetSongs(w http.ResponseWriter, r *http.Request) {
 	logger.Log.Info("GetSong handler invoked")
 
 	// Чтение query параметров
-	filters := r.URL.Query()
-	group := filters.Get("group")
-	title := filters.Get("title")
-	pageStr := filters.Get("page")
-	limitStr := filters.Get("limit")
-
-	logger.Log.Debugf("Received query parameters: group=%s, title=%s, page=%s, limit=%s", group, title, pageStr, limitStr)
-
-	if group == "" || title == "" || pageStr == "" || limitStr == "" {
-		logger.Log.Warn("Missing query parameters")
-		http.Error(w, "Missing parameters", http.StatusBadRequest)
+	query := r.URL.Query()
+	filter, err := parseSongFilter(query)
+	if err != nil {
+		logger.Log.Warnf("Invalid query parameters: %v", err)
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return
 	}
 
-	page, err := strconv.Atoi(pageStr)
-	if err != nil || page < 1 {
-		logger.Log.Warn("Invalid or missing page parameter, defaulting to 1")
-		page = 1 //по умолчанию
-	}
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit < 1 {
-		logger.Log.Warn("Invalid or missing limit parameter, defaulting to 10")
-		limit = 10 //по умолчанию
+	// Номер страницы не используется при пагинации курсором
+	page, limit := parsePage(query, 10)
+	filter.Cursor = query.Get("cursor")
+	if filter.Cursor == "" {
+		filter.Page = page
 	}
+	filter.Limit = limit
 
 	//Получение данных из БД
-	logger.Log.Debugf("Fetching songs from DB: group=%s, title=%s, page=%d, limit=%d", group, title, page, limit)
-	songs, err := h.Repo.GetFilteredSongs(group, title, page, limit)
+	logger.Log.Debugf("Fetching songs from DB: %+v", filter)
+	list, err := h.Repo.GetFilteredSongs(filter)
 	if err != nil {
-		logger.Log.Errorf("Failed to fetch songs from DB: %v", err)
-		http.Error(w, "Failed to fetch songs", http.StatusInternalServerError)
+		writeRepoError(w, r, err, "Failed to fetch songs")
 		return
 	}
 
-	logger.Log.Infof("Successfully fetched %d songs from DB", len(songs))
+	logger.Log.Infof("Successfully fetched %d of %d songs from DB", len(list.Songs), list.Total)
+
+	response := SongListResponse{
+		Items:      list.Songs,
+		Total:      list.Total,
+		Page:       filter.Page,
+		Limit:      filter.Limit,
+		NextCursor: list.NextCursor,
+		Links:      pageLinks(r, filter.Page, filter.Limit, list.Total, filter.Cursor, list.NextCursor),
+	}
+	if response.Items == nil {
+		response.Items = []models.Song{}
+	}
 
 	//Ответ клиенту
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(songs); err != nil {
+	if err := json.NewEncoder(w).Encode(response); err != nil {
 		logger.Log.Errorf("Failed to encode response: %v", err)
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		WriteProblem(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
+
+// GetSong возвращает все данные песни.
+// @Summary Get Song
+// @Description Получение метаданных песни по ID. Поле enrichment_status показывает, загружены ли данные из внешнего API: pending, processing, done, failed (будет повтор) или dead (попытки исчерпаны).
+// @Tags songs
+// @Accept json
+// @Produce json
+// @Param id path int true "ID песни" example(1)
+// @Success 200 {object} models.Song "Данные песни"
+// @Header 200 {string} ETag "Версия песни"
+// @Failure 400 {object} Problem "Ошибочные параметры запроса"
+// @Failure 404 {object} Problem "Песня не найдена"
+// @Failure 500 {object} Problem "Ошибка сервера"
+// @Router /songs/{id} [get]
+func (h *SongHandler) GetSong(w http.ResponseWriter, r *http.Request) {
+	logger.Log.Info("GetSong handler invoked")
+
+	songID, err := songIDFromPath(r)
+	if err != nil {
+		logger.Log.Warnf("Invalid song id: %s", r.PathValue("id"))
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidID, "Invalid song id")
+		return
+	}
+
+	song, err := h.Repo.GetSongByID(songID)
+	if err != nil {
+		writeRepoError(w, r, err, "Failed to retrieve song")
+		return
+	}
+
+	w.Header().Set("ETag", songETag(song.Version))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(song); err != nil {
+		logger.Log.Errorf("Failed to encode response for song ID %d: %v", songID, err)
+		WriteProblem(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
 	}
 }
 
 // GetSongLyrics возвращает текст песни с возможностью пагинации.
 // @Summary Get Song Lyrics
-// @Description Получение текста песни по ID с возможностью разбивки на страницы.
+// @Description Получение текста песни по ID с возможностью разбивки на страницы. Текст разбирается на куплеты (verse, chorus, bridge и т. д.) по пустым строкам и заголовкам вида [Chorus]; строки пронумерованы по всей песне.
 // @Tags songs
 // @Accept json
-// @Produce json
-// @Param id query int true "ID песни" example(1)
+// @Produce json,plain,text/markdown,html
+// @Param id path int true "ID песни" example(1)
+// @Param Accept header string false "Представление текста: application/json, text/plain, text/markdown или text/html; страницы куплетов одинаковы для всех" default(application/json)
+// @Param lang query string false "Языки перевода через запятую в порядке предпочтения; важнее Accept-Language" example(en)
+// @Param Accept-Language header string false "Предпочитаемые языки текста; без перевода отдается оригинал"
+// @Param format query string false "json - куплеты; json-timed - строки с временными метками; lrc - файл LRC целиком" Enums(json, json-timed, lrc) default(json)
 // @Param page query int false "Номер страницы" default(1)
-// @Param limit query int false "Количество куплетов на странице" default(5)
+// @Param limit query int false "Количество куплетов на странице (для json-timed - строк, по умолчанию 50)" default(5) maximum(100)
 // @Success 200 {object} ResponseLyrics "Текст песни с пагинацией"
-// @Failure 400 {object} map[string]string "Ошибочные параметры запроса"
-// @Failure 404 {object} map[string]string "Песня не найдена"
-// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Success 200 {object} TimedLyricsResponse "Строки с временными метками (format=json-timed)"
+// @Header 200 {string} ETag "Версия песни, перевода и формата текста"
+// @Failure 400 {object} Problem "Ошибочные параметры запроса"
+// @Failure 404 {object} Problem "Песня не найдена"
+// @Failure 406 {object} Problem "Ни одно представление не подходит под Accept"
+// @Failure 500 {object} Problem "Ошибка сервера"
 // @Router /songs/{id}/lyrics [get]
 func (h *SongHandler) GetSongLyrics(w http.ResponseWriter, r *http.Request) {
 	logger.Log.Info("GetSongLyrics handler invoked")
 
-	query := r.URL.Query()
-	// Получение ID песни из URL
-	songIDStr := query.Get("id")
-	if songIDStr == "" {
-		logger.Log.Error("Missing query id parameter")
-		http.Error(w, "Missing query id parameter", http.StatusBadRequest)
+	// Получение ID песни из пути
+	songID, err := songIDFromPath(r)
+	if err != nil {
+		logger.Log.Warnf("Invalid song id: %s", r.PathValue("id"))
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidID, "Invalid song id")
 		return
 	}
 
-	logger.Log.Debugf("Received query id parameter: id=%s", songIDStr)
-	songID, err := strconv.Atoi(songIDStr)
-	if err != nil || songID <= 0 {
-		logger.Log.Warnf("Invalid query id parameter: id=%s", songIDStr)
-		http.Error(w, "Invalid query id parameter", http.StatusBadRequest)
+	query := r.URL.Query()
+
+	// Текст с временными метками отдается отдельными обработчиками,
+	// без format представление выбирается по заголовку Accept
+	mediaType := mediaJSON
+	switch format := query.Get("format"); format {
+	case lyricsFormatJSON:
+	case "":
+		w.Header().Add("Vary", "Accept")
+		mediaType = negotiateMediaType(r.Header.Get("Accept"), lyricsMediaTypes)
+		if mediaType == "" {
+			logger.Log.Warnf("No acceptable lyrics representation for Accept: %q", r.Header.Get("Accept"))
+			WriteProblem(w, r, http.StatusNotAcceptable, CodeNotAcceptable, "Not acceptable, supported types: "+strings.Join(lyricsMediaTypes, ", "))
+			return
+		}
+	case lyricsFormatLRC, lyricsFormatTimed:
+		h.getSyncedLyrics(w, r, songID, format)
+		return
+	default:
+		logger.Log.Warnf("Unsupported lyrics format: %q", format)
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidParameter, "Unsupported format, expected json, lrc or json-timed")
 		return
 	}
 
 	//Извлечение параметров для пагинации
-	page, err := strconv.Atoi(query.Get("page"))
-	if err != nil || page < 1 {
-		logger.Log.Warn("Invalid or missing page parameter, defaulting to 1")
-		page = 1
-	}
-
-	size, err := strconv.Atoi(query.Get("limit"))
-	if err != nil || size < 1 {
-		logger.Log.Warn("Invalid or missing limit parameter, defaulting to 5")
-		size = 5
-	}
+	page, size := parsePage(query, 5)
 
 	//Извлечение текста песни из базы данных
 	logger.Log.Debugf("Fetching lyrics for song ID %d with pagination: page=%d, size=%d", songID, page, size)
-	song, lyrics, err := h.Repo.GetSongLyricsByID(songID)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			// Если песня вообще не найдена
-			logger.Log.Warnf("Song with ID %d not found", songID)
-			http.Error(w, "Song not found", http.StatusNotFound)
-		} else {
-			// Любая другая ошибка
-			logger.Log.Errorf("Failed to retrieve song details for ID %d: %v", songID, err)
-			http.Error(w, "Failed to retrieve song details", http.StatusInternalServerError)
-		}
+	songLyrics, ok := h.lyricsIn(w, r, songID)
+	if !ok {
 		return
 	}
+	song, lyrics := songLyrics.Song, songLyrics.Lyrics
 
 	// Проверяем, есть ли текст песни
-	if lyrics == "" {
+	if strings.TrimSpace(lyrics) == "" {
 		logger.Log.Infof("No lyrics found for song ID %d", songID)
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"song":    song,
-			"song_id": songID,
-			"error":   "Lyrics not found",
-		})
+		WriteProblem(w, r, http.StatusNotFound, CodeLyricsNotFound, "Lyrics not found")
 		return
 	}
 
 	// Если песня и текст найдены:
 
-	//Разделение текста на куплеты
-	stanzas := strings.Split(lyrics, "\n\n") //Разбиваем текст по двойным переносам строк
-	totalStanzas := len(stanzas)
+	//Текст, разобранный на куплеты
+	sections := songLyrics.Structure.Sections
+	totalStanzas := len(sections)
 
 	//Определение границ пагинации
 	start := (page - 1) * size
@@ -197,7 +278,7 @@ func (h *SongHandler) GetSongLyrics(w http.ResponseWriter, r *http.Request) {
 
 	if start >= totalStanzas {
 		logger.Log.Warn("Page out of range")
-		http.Error(w, "Page out of range", http.StatusBadRequest)
+		WriteProblem(w, r, http.StatusBadRequest, CodePageOutOfRange, "Page out of range")
 		return
 	}
 
@@ -205,62 +286,132 @@ func (h *SongHandler) GetSongLyrics(w http.ResponseWriter, r *http.Request) {
 		end = totalStanzas
 	}
 
-	//Формирование ответа
+	//Формирование ответа; ETag есть только у самого представления, не у ответов об ошибках
 	logger.Log.Infof("Successfully retrieved lyrics for song ID %d", songID)
+	w.Header().Set("ETag", lyricsETag(songLyrics, mediaType))
+	if mediaType != mediaJSON {
+		writeLyricsDocument(w, mediaType, songLyrics, sections[start:end])
+		return
+	}
+	stanzas := make([]string, 0, end-start)
+	for _, section := range sections[start:end] {
+		stanzas = append(stanzas, section.Text())
+	}
 	response := ResponseLyrics{
-		Song:     song,
-		SongID:   songIDStr,
-		Lyrics:   stanzas[start:end],
-		Page:     page,
-		PageSize: size,
+		Song:         song,
+		SongID:       strconv.Itoa(songID),
+		Lang:         songLyrics.Lang,
+		Lyrics:       stanzas,
+		Sections:     sections[start:end],
+		TotalStanzas: totalStanzas,
+		TotalLines:   songLyrics.Structure.LineCount,
+		Page:         page,
+		PageSize:     size,
 	}
 
 	//Отправка ответа клиенту
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		logger.Log.Errorf("Failed to encode responsefor song ID %d: %v", songID, err)
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		WriteProblem(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
 		return
 	}
 }
 
 // AddSong добавляет новую песню в базу данных.
 // @Summary Add Song
-// @Description Добавление новой песни в базу данных. Данные о песне подтягиваются из внешнего API.
+// @Description Добавление новой песни в базу данных. Артист находится по имени группы или создается, данные о песне подтягиваются из внешнего API.
+// @Description С async=true песня сохраняется сразу со статусом enrichment_status=pending, а данные загружаются в фоне; статус можно узнать через GET /songs/{id}.
+// @Description С source=manual сохраняются данные клиента (release_date, lyrics, link) без обращения к внешнему API; с fill_missing=true внешний API дополняет только незаполненные поля.
 // @Tags songs
 // @Accept json
 // @Produce json
 // @Param song body models.Song true "Данные песни"
-// @Success 201 {object} map[string]int "ID добавленной песни"
-// @Failure 400 {object} map[string]string "Ошибочный запрос"
-// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Param async query bool false "Сохранить песню сразу и загрузить данные из внешнего API в фоне"
+// @Param source query string false "Откуда брать данные песни: из внешнего API или из запроса" Enums(external, manual) default(external)
+// @Param fill_missing query bool false "Для source=manual: дополнить незаполненные поля из внешнего API"
+// @Success 201 {object} map[string]interface{} "ID добавленной песни (для source=manual - и enrichment_status)"
+// @Success 202 {object} map[string]interface{} "Песня сохранена, данные загружаются в фоне"
+// @Header 202 {string} Location "Адрес песни для проверки статуса"
+// @Failure 400 {object} Problem "Ошибочный запрос"
+// @Failure 404 {object} Problem "Песня не найдена во внешнем API"
+// @Failure 500 {object} Problem "Ошибка сервера"
+// @Failure 502 {object} Problem "Внешний API ответил ошибкой"
+// @Failure 503 {object} Problem "Запросы к внешнему API временно приостановлены"
 // @Router /songs [post]
 func (h *SongHandler) AddSong(w http.ResponseWriter, r *http.Request) {
 	var song models.Song
 	if err := json.NewDecoder(r.Body).Decode(&song); err != nil {
 		logger.Log.Errorf("Invalid request payload: %v", err)
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidBody, "Invalid request payload")
 		return
 	}
 	logger.Log.Debugf("Received query parameters: group=%s, song=%s", song.Group, song.Song)
 
 	if song.Group == "" || song.Song == "" {
 		logger.Log.Warn("Group and Song are required fields")
-		http.Error(w, "Group and Song are required fields", http.StatusBadRequest)
+		WriteProblem(w, r, http.StatusBadRequest, CodeValidationFailed, "Group and Song are required fields")
+		return
+	}
+
+	query := r.URL.Query()
+	async, err := queryFlag(query, "async")
+	if err != nil {
+		logger.Log.Warnf("Invalid async parameter: %v", err)
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return
+	}
+	switch source := query.Get("source"); source {
+	case "", "external":
+		// Данные песни берутся из внешнего API, переданные клиентом поля не используются
+		song.ReleaseDate, song.Lyrics, song.Link = "", "", ""
+		song.EnrichmentStatus = models.EnrichmentDone
+	case "manual":
+		fill, err := queryFlag(query, "fill_missing")
+		if err != nil {
+			logger.Log.Warnf("Invalid fill_missing parameter: %v", err)
+			WriteProblem(w, r, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+			return
+		}
+		h.addSongManual(w, r, song, fill, async)
+		return
+	default:
+		logger.Log.Warnf("Invalid source parameter: %s", source)
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidParameter, "Invalid source parameter, expected external or manual")
+		return
 	}
 
-	apiSongDetails, err := h.ExternalAPI.GetSongDetails(song.Group, song.Song)
+	if async {
+		h.addSongAsync(w, r, song)
+		return
+	}
+
+	apiSongDetails, err := h.ExternalAPI.GetSongDetails(r.Context(), song.Group, song.Song)
 	if err != nil {
+		if errors.Is(err, externalapi.ErrNotFound) {
+			logger.Log.Warnf("Song %q by %q not found in external API", song.Song, song.Group)
+			WriteProblem(w, r, http.StatusNotFound, CodeExternalNotFound, "Song not found in external API")
+			return
+		}
+		var circuitErr *externalapi.CircuitOpenError
+		if errors.As(err, &circuitErr) {
+			logger.Log.Warn("External API is temporarily disabled by circuit breaker")
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(circuitErr.RetryAfter.Seconds()))))
+			WriteProblem(w, r, http.StatusServiceUnavailable, CodeExternalUnavailable, "External API is temporarily unavailable")
+			return
+		}
 		logger.Log.Errorf("Failed to fetch song details from external API: %v", err)
-		http.Error(w, "Failed to fetch song details from external API", http.StatusInternalServerError)
+		WriteProblem(w, r, http.StatusBadGateway, CodeExternalError, "Failed to fetch song details from external API")
 		return
 	}
 
 	// Сохранение в базе данных
-	songID, err := h.Repo.AddSong(song.Group, song.Song, apiSongDetails.ReleaseDate, apiSongDetails.Text, apiSongDetails.Link)
+	song.ReleaseDate = apiSongDetails.ReleaseDate
+	song.Lyrics = apiSongDetails.Text
+	song.Link = apiSongDetails.Link
+	songID, err := h.repoFor(r).AddSong(song)
 	if err != nil {
-		logger.Log.Errorf("Failed to save song in database: %v", err)
-		http.Error(w, "Failed to save song in database", http.StatusInternalServerError)
+		writeRepoError(w, r, err, "Failed to save song in database")
 		return
 	}
 
@@ -270,35 +421,139 @@ func (h *SongHandler) AddSong(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]int{"id": songID})
 }
 
+// addSongManual сохраняет данные песни, переданные клиентом.
+// С fill внешний API дополняет незаполненные поля; если он недоступен, песня сохраняется
+// и дополняется в фоне.
+func (h *SongHandler) addSongManual(w http.ResponseWriter, r *http.Request, song models.Song, fill bool, async bool) {
+	if err := validateSong(song); err != nil {
+		logger.Log.Warnf("Invalid song data: %v", err)
+		WriteProblem(w, r, http.StatusBadRequest, CodeValidationFailed, err.Error())
+		return
+	}
+
+	song.EnrichmentStatus = models.EnrichmentDone
+	if fill && !songComplete(song) {
+		if async {
+			h.addSongAsync(w, r, song)
+			return
+		}
+
+		detail, err := h.ExternalAPI.GetSongDetails(r.Context(), song.Group, song.Song)
+		switch {
+		case err == nil:
+			fillMissing(&song, detail)
+			// Некорректная дата из внешнего API не должна мешать сохранить данные клиента
+			if song.ReleaseDate != "" {
+				if _, err := repository.ParseReleaseDate(song.ReleaseDate); err != nil {
+					logger.Log.Warnf("Ignoring release date from external API: %v", err)
+					song.ReleaseDate = ""
+				}
+			}
+		case errors.Is(err, externalapi.ErrNotFound):
+			logger.Log.Infof("Song %q by %q not found in external API, saving client data only", song.Song, song.Group)
+		default:
+			logger.Log.Warnf("External API unavailable, missing fields will be filled in background: %v", err)
+			song.EnrichmentStatus = models.EnrichmentPending
+		}
+	}
+
+	songID, err := h.repoFor(r).AddSong(song)
+	if err != nil {
+		writeRepoError(w, r, err, "Failed to save song in database")
+		return
+	}
+	logger.Log.Infof("Song ID %d added from client data", songID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/songs/"+strconv.Itoa(songID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":                songID,
+		"enrichment_status": song.EnrichmentStatus,
+	})
+}
+
+// addSongAsync сохраняет песню без данных внешнего API и ставит ее в очередь на их загрузку
+func (h *SongHandler) addSongAsync(w http.ResponseWriter, r *http.Request, song models.Song) {
+	song.EnrichmentStatus = models.EnrichmentPending
+	songID, err := h.repoFor(r).AddSong(song)
+	if err != nil {
+		writeRepoError(w, r, err, "Failed to save song in database")
+		return
+	}
+	logger.Log.Infof("Song ID %d queued for enrichment", songID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/songs/"+strconv.Itoa(songID))
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":                songID,
+		"enrichment_status": models.EnrichmentPending,
+	})
+}
+
+// RequeueEnrichment повторно ставит песню в очередь загрузки данных из внешнего API.
+// @Summary Requeue Song Enrichment
+// @Description Сбрасывает счетчик попыток и возвращает песню в очередь фоновой загрузки данных, например после перехода в состояние dead.
+// @Tags songs
+// @Produce json
+// @Param id path int true "ID песни" example(1)
+// @Success 202 {object} models.Song "Песня поставлена в очередь"
+// @Failure 400 {object} Problem "Ошибочный ID"
+// @Failure 404 {object} Problem "Песня не найдена"
+// @Failure 409 {object} Problem "Данные песни уже загружаются"
+// @Failure 500 {object} Problem "Ошибка сервера"
+// @Router /songs/{id}/enrichment [post]
+func (h *SongHandler) RequeueEnrichment(w http.ResponseWriter, r *http.Request) {
+	logger.Log.Info("RequeueEnrichment handler invoked")
+
+	songID, err := songIDFromPath(r)
+	if err != nil {
+		logger.Log.Warnf("Invalid song id: %s", r.PathValue("id"))
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidID, "Invalid song id")
+		return
+	}
+
+	song, err := h.Repo.RequeueEnrichment(songID)
+	if err != nil {
+		writeRepoError(w, r, err, "Failed to requeue enrichment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", songETag(song.Version))
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(song)
+}
+
 // UpdateSong обновляет данные существующей песни.
 // @Summary Update Song
-// @Description Обновление данных песни в базе по её ID.
+// @Description Полная замена данных песни по её ID: непереданные поля очищаются. Для частичного обновления используйте PATCH.
 // @Tags songs
 // @Accept json
 // @Produce json
-// @Param id query int true "ID песни" example(1)
+// @Param id path int true "ID песни" example(1)
+// @Param If-Match header string false "ETag песни, полученный при чтении"
 // @Param song body models.Song true "Обновленные данные песни"
-// @Success 200 {string} string "Песня успешно обновлена"
-// @Failure 400 {object} map[string]string "Ошибочный запрос"
-// @Failure 404 {object} map[string]string "Песня не найдена"
-// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Success 200 {object} StatusResponse "Песня успешно обновлена"
+// @Failure 400 {object} Problem "Ошибочный запрос"
+// @Failure 404 {object} Problem "Песня не найдена"
+// @Failure 412 {object} Problem "Песня изменена другим клиентом"
+// @Failure 500 {object} Problem "Ошибка сервера"
 // @Router /songs/{id} [put]
 func (h *SongHandler) UpdateSong(w http.ResponseWriter, r *http.Request) {
 	logger.Log.Info("UpdateSong handler invoked")
 
-	// Извлечение song_id из запроса
-	query := r.URL.Query()
-	songIDStr := query.Get("id")
-	if songIDStr == "" {
-		logger.Log.Error("Missing query id parameter")
-		http.Error(w, "Missing query id parameter'", http.StatusBadRequest)
+	// Извлечение song_id из пути
+	songID, err := songIDFromPath(r)
+	if err != nil {
+		logger.Log.Warnf("Invalid song id: %s", r.PathValue("id"))
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidID, "Invalid song id")
 		return
 	}
 
-	songID, err := strconv.Atoi(songIDStr)
-	if err != nil || songID <= 0 {
-		logger.Log.Warnf("Invalid query id parameter: id=%s", songIDStr)
-		http.Error(w, "Invalid query id parameter", http.StatusBadRequest)
+	expectedVersion, ok := h.ifMatchVersion(w, r, songID)
+	if !ok {
 		return
 	}
 
@@ -306,82 +561,164 @@ func (h *SongHandler) UpdateSong(w http.ResponseWriter, r *http.Request) {
 	var updatedSong models.Song
 	if err := json.NewDecoder(r.Body).Decode(&updatedSong); err != nil {
 		logger.Log.Errorf("Invalid request body: %v", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidBody, "Invalid request body")
 		return
 	}
 
 	// Проверка обязательных полей
 	if updatedSong.Group == "" || updatedSong.Song == "" {
 		logger.Log.Error("Group and Song fields are required")
-		http.Error(w, "Group and Song fields are required", http.StatusBadRequest)
+		WriteProblem(w, r, http.StatusBadRequest, CodeValidationFailed, "Group and Song fields are required")
 		return
 	}
 
-	logger.Log.Debugf("Received parameters: group=%s, song=%s, releaseDate=%s, lyrics=%s, link=%s", updatedSong.Group, updatedSong.Song, updatedSong.ReleaseDate, updatedSong.Lyrics, updatedSong.Link)
+	logger.Log.Debugf("Received parameters: group=%s, song=%s, genre=%s, releaseDate=%s, lyrics=%s, link=%s", updatedSong.Group, updatedSong.Song, updatedSong.Genre, updatedSong.ReleaseDate, updatedSong.Lyrics, updatedSong.Link)
 	// Вызов метода репозитория для обновления записи
-	err = h.Repo.UpdateSong(songID, updatedSong.Group, updatedSong.Song, updatedSong.ReleaseDate, updatedSong.Lyrics, updatedSong.Link)
+	err = h.repoFor(r).UpdateSong(songID, updatedSong, expectedVersion)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			logger.Log.Warnf("Song with ID %d not found", songID)
-			http.Error(w, "Song not found", http.StatusNotFound)
-		} else {
-			logger.Log.Errorf("Failed to update song with ID %d: %v", songID, err)
-			http.Error(w, "Failed to update song", http.StatusInternalServerError)
-		}
+		writeRepoError(w, r, err, "Failed to update song")
 		return
 	}
 
 	// Успешный ответ
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("Song updated successfully"))
+	writeStatus(w, songID, "updated", "Song updated successfully")
+}
 
+// PatchSong частично обновляет песню.
+// @Summary Patch Song
+// @Description Частичное обновление песни телом JSON Merge Patch (RFC 7386): переданные поля заменяются, null очищает поле, остальные поля не меняются. Поля group и song нельзя очистить.
+// @Tags songs
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path int true "ID песни" example(1)
+// @Param If-Match header string false "ETag песни, полученный при чтении"
+// @Param patch body models.Song true "Изменяемые поля песни"
+// @Success 200 {object} models.Song "Песня после изменения"
+// @Failure 400 {object} Problem "Ошибочный запрос"
+// @Failure 404 {object} Problem "Песня не найдена"
+// @Failure 415 {object} Problem "Неподдерживаемый тип содержимого"
+// @Failure 412 {object} Problem "Песня изменена другим клиентом"
+// @Failure 500 {object} Problem "Ошибка сервера"
+// @Router /songs/{id} [patch]
+func (h *SongHandler) PatchSong(w http.ResponseWriter, r *http.Request) {
+	logger.Log.Info("PatchSong handler invoked")
+
+	songID, err := songIDFromPath(r)
+	if err != nil {
+		logger.Log.Warnf("Invalid song id: %s", r.PathValue("id"))
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidID, "Invalid song id")
+		return
+	}
+
+	expectedVersion, ok := h.ifMatchVersion(w, r, songID)
+	if !ok {
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/merge-patch+json" && mediaType != "application/json" {
+		logger.Log.Warnf("Unsupported content type for patch: %q", mediaType)
+		WriteProblem(w, r, http.StatusUnsupportedMediaType, CodeUnsupportedMedia, "Content-Type must be application/merge-patch+json")
+		return
+	}
+
+	patch, err := parseMergePatch(r.Body)
+	if err != nil {
+		logger.Log.Warnf("Invalid merge patch for song ID %d: %v", songID, err)
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidBody, "Invalid merge patch: "+err.Error())
+		return
+	}
+
+	song, err := h.repoFor(r).PatchSong(songID, patch, expectedVersion)
+	if err != nil {
+		writeRepoError(w, r, err, "Failed to update song")
+		return
+	}
+
+	w.Header().Set("ETag", songETag(song.Version))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(song); err != nil {
+		logger.Log.Errorf("Failed to encode response for song ID %d: %v", songID, err)
+		WriteProblem(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
 }
 
-// DeleteSong удаляет песню из базы данных по её ID.
+// parseMergePatch разбирает тело JSON Merge Patch и проверяет изменяемые поля
+func parseMergePatch(body io.Reader) (models.SongPatch, error) {
+	var raw map[string]json.RawMessage
+	if err := json.NewDecoder(body).Decode(&raw); err != nil {
+		return nil, errors.New("body must be a JSON object")
+	}
+	if raw == nil {
+		return nil, errors.New("body must be a JSON object")
+	}
+
+	patch := make(models.SongPatch, len(raw))
+	for field, value := range raw {
+		switch field {
+		case "group", "song", "genre", "lyrics", "release_date", "link":
+		default:
+			return nil, fmt.Errorf("field %q cannot be patched", field)
+		}
+
+		// null удаляет значение поля
+		if string(value) == "null" {
+			if field == "group" || field == "song" {
+				return nil, fmt.Errorf("field %q cannot be null", field)
+			}
+			patch[field] = nil
+			continue
+		}
+
+		var str string
+		if err := json.Unmarshal(value, &str); err != nil {
+			return nil, fmt.Errorf("field %q must be a string", field)
+		}
+		if str == "" && (field == "group" || field == "song") {
+			return nil, fmt.Errorf("field %q cannot be empty", field)
+		}
+		patch[field] = &str
+	}
+	return patch, nil
+}
+
+// DeleteSong перемещает песню в корзину по её ID.
 // @Summary Delete Song
-// @Description Удаление песни из базы данных по её ID.
+// @Description Удаление песни по её ID. Песня перемещается в корзину (GET /songs/trash), откуда ее можно восстановить через POST /songs/{id}/restore, пока не истек срок хранения.
 // @Tags songs
 // @Accept json
 // @Produce json
-// @Param id query int true "ID песни" example(1)
-// @Success 200 {string} string "Песня успешно удалена"
-// @Failure 400 {object} map[string]string "Ошибочный запрос"
-// @Failure 404 {object} map[string]string "Песня не найдена"
-// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Param id path int true "ID песни" example(1)
+// @Param If-Match header string false "ETag песни, полученный при чтении"
+// @Success 200 {object} StatusResponse "Песня успешно удалена"
+// @Failure 400 {object} Problem "Ошибочный запрос"
+// @Failure 404 {object} Problem "Песня не найдена"
+// @Failure 412 {object} Problem "Песня изменена другим клиентом"
+// @Failure 500 {object} Problem "Ошибка сервера"
 // @Router /songs/{id} [delete]
 func (h *SongHandler) DeleteSong(w http.ResponseWriter, r *http.Request) {
 	logger.Log.Info("DeleteSong handler invoked")
 
-	// Извлечение song_id из запроса
-	query := r.URL.Query()
-	songIDStr := query.Get("id")
-	if songIDStr == "" {
-		logger.Log.Error("Missing query id parameter")
-		http.Error(w, "Missing query id parameter'", http.StatusBadRequest)
+	// Извлечение song_id из пути
+	songID, err := songIDFromPath(r)
+	if err != nil {
+		logger.Log.Warnf("Invalid song id: %s", r.PathValue("id"))
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidID, "Invalid song id")
 		return
 	}
 
-	songID, err := strconv.Atoi(songIDStr)
-	if err != nil || songID <= 0 {
-		logger.Log.Warnf("Invalid query id parameter: id=%s", songIDStr)
-		http.Error(w, "Invalid query id parameter", http.StatusBadRequest)
+	expectedVersion, ok := h.ifMatchVersion(w, r, songID)
+	if !ok {
 		return
 	}
 
 	// Вызов метода репозитория для удаления записи
-	err = h.Repo.DeleteSong(songID)
+	err = h.repoFor(r).DeleteSong(songID, expectedVersion)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			logger.Log.Warnf("Song with ID %d not found", songID)
-			http.Error(w, "Song not found", http.StatusNotFound)
-		} else {
-			logger.Log.Errorf("Failed to delete song with ID %d: %v", songID, err)
-			http.Error(w, "Failed to delete song", http.StatusInternalServerError)
-		}
+		writeRepoError(w, r, err, "Failed to delete song")
 		return
 	}
 
 	// Успешный ответ
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("Song deleted successfully"))
+	writeStatus(w, songID, "deleted", "Song deleted successfully")
 }
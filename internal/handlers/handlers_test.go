@@ -0,0 +1,230 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	externalapi "online-library/external_api"
+	"online-library/internal/handlers"
+	"online-library/internal/lyrics"
+	"online-library/internal/models"
+	"online-library/internal/repository"
+	"online-library/internal/routes"
+)
+
+// stubSongRepo отвечает функциями, заданными в тесте; вызов незаданного метода - ошибка теста (паника на nil)
+type stubSongRepo struct {
+	repository.SongRepository
+
+	getDeletedSongs func(page int, limit int) (*repository.SongList, error)
+	restoreSong     func(songID int) (*models.Song, error)
+	setSynced       func(songID int, synced *lyrics.Synced, expectedVersion int) (int, error)
+	importSongs     func(rows []repository.ImportRow, dryRun bool) ([]repository.ImportResult, error)
+	revertSong      func(songID int, revisionID int64, expectedVersion int) (*models.Song, error)
+	getRevisions    func(songID int, page int, limit int) (*repository.RevisionList, error)
+	revisionState   func(songID int, revisionID int64) (models.SongPatch, error)
+	getLyricsIn     func(songID int, langs []string) (*models.SongLyrics, error)
+	getLyrics       func(songID int) (*models.SongLyrics, error)
+	patchSong       func(songID int, patch models.SongPatch, expectedVersion int) (*models.Song, error)
+	getSong         func(songID int) (*models.Song, error)
+	getSongs        func(filter repository.SongFilter) (*repository.SongList, error)
+	searchSongs     func(query string, page int, limit int) (*repository.SongSearchList, error)
+	patchSongs      func(target repository.BatchTarget, patch models.SongPatch, atomic bool) ([]repository.BatchResult, error)
+	deleteSongs     func(target repository.BatchTarget, atomic bool) ([]repository.BatchResult, error)
+	exportSongs     func(ctx context.Context, filter repository.SongFilter) iter.Seq2[models.Song, error]
+	getTranslations func(songID int) ([]models.LyricsTranslation, error)
+	putTranslation  func(songID int, lang string, text string) (*models.LyricsTranslation, bool, error)
+	delTranslation  func(songID int, lang string) error
+	updateSong      func(songID int, song models.Song, expectedVersion int) error
+	deleteSong      func(songID int, expectedVersion int) error
+
+	author string // автор, переданный через WithAuthor
+}
+
+func (s *stubSongRepo) WithAuthor(author string) repository.SongRepository {
+	s.author = author
+	return s
+}
+
+func (s *stubSongRepo) GetDeletedSongs(page int, limit int) (*repository.SongList, error) {
+	return s.getDeletedSongs(page, limit)
+}
+
+func (s *stubSongRepo) RestoreSong(songID int) (*models.Song, error) {
+	return s.restoreSong(songID)
+}
+
+func (s *stubSongRepo) SetSyncedLyrics(songID int, synced *lyrics.Synced, expectedVersion int) (int, error) {
+	return s.setSynced(songID, synced, expectedVersion)
+}
+
+func (s *stubSongRepo) ImportSongs(rows []repository.ImportRow, dryRun bool) ([]repository.ImportResult, error) {
+	return s.importSongs(rows, dryRun)
+}
+
+func (s *stubSongRepo) RevertSong(songID int, revisionID int64, expectedVersion int) (*models.Song, error) {
+	return s.revertSong(songID, revisionID, expectedVersion)
+}
+
+func (s *stubSongRepo) GetSongLyricsIn(songID int, langs []string) (*models.SongLyrics, error) {
+	return s.getLyricsIn(songID, langs)
+}
+
+func (s *stubSongRepo) GetSongLyricsByID(songID int) (*models.SongLyrics, error) {
+	return s.getLyrics(songID)
+}
+
+func (s *stubSongRepo) PatchSong(songID int, patch models.SongPatch, expectedVersion int) (*models.Song, error) {
+	return s.patchSong(songID, patch, expectedVersion)
+}
+
+func (s *stubSongRepo) GetSongByID(songID int) (*models.Song, error) {
+	return s.getSong(songID)
+}
+
+func (s *stubSongRepo) GetFilteredSongs(filter repository.SongFilter) (*repository.SongList, error) {
+	return s.getSongs(filter)
+}
+
+func (s *stubSongRepo) SearchSongs(query string, page int, limit int) (*repository.SongSearchList, error) {
+	return s.searchSongs(query, page, limit)
+}
+
+func (s *stubSongRepo) PatchSongs(target repository.BatchTarget, patch models.SongPatch, atomic bool) ([]repository.BatchResult, error) {
+	return s.patchSongs(target, patch, atomic)
+}
+
+func (s *stubSongRepo) DeleteSongs(target repository.BatchTarget, atomic bool) ([]repository.BatchResult, error) {
+	return s.deleteSongs(target, atomic)
+}
+
+func (s *stubSongRepo) ExportSongs(ctx context.Context, filter repository.SongFilter) iter.Seq2[models.Song, error] {
+	return s.exportSongs(ctx, filter)
+}
+
+func (s *stubSongRepo) GetTranslations(songID int) ([]models.LyricsTranslation, error) {
+	return s.getTranslations(songID)
+}
+
+func (s *stubSongRepo) PutTranslation(songID int, lang string, text string) (*models.LyricsTranslation, bool, error) {
+	return s.putTranslation(songID, lang, text)
+}
+
+func (s *stubSongRepo) DeleteTranslation(songID int, lang string) error {
+	return s.delTranslation(songID, lang)
+}
+
+func (s *stubSongRepo) UpdateSong(songID int, song models.Song, expectedVersion int) error {
+	return s.updateSong(songID, song, expectedVersion)
+}
+
+func (s *stubSongRepo) DeleteSong(songID int, expectedVersion int) error {
+	return s.deleteSong(songID, expectedVersion)
+}
+
+func (s *stubSongRepo) GetSongRevisions(songID int, page int, limit int) (*repository.RevisionList, error) {
+	return s.getRevisions(songID, page, limit)
+}
+
+func (s *stubSongRepo) GetRevisionState(songID int, revisionID int64) (models.SongPatch, error) {
+	return s.revisionState(songID, revisionID)
+}
+
+// stubArtistRepo - то же для репозитория артистов
+type stubArtistRepo struct {
+	repository.ArtistRepository
+
+	getArtists   func(name string, page int, limit int) ([]models.Artist, error)
+	deleteArtist func(artistID int, cascade bool) error
+	updateArtist func(artistID int, artist models.Artist) error
+
+	author string // автор, переданный через WithAuthor
+}
+
+func (s *stubArtistRepo) WithAuthor(author string) repository.ArtistRepository {
+	s.author = author
+	return s
+}
+
+func (s *stubArtistRepo) UpdateArtist(artistID int, artist models.Artist) error {
+	return s.updateArtist(artistID, artist)
+}
+
+func (s *stubArtistRepo) GetArtists(name string, page int, limit int) ([]models.Artist, error) {
+	return s.getArtists(name, page, limit)
+}
+
+func (s *stubArtistRepo) DeleteArtist(artistID int, cascade bool) error {
+	return s.deleteArtist(artistID, cascade)
+}
+
+// stubAPI - внешний API, который не должен вызываться
+type stubAPI struct {
+	externalapi.ExternalAPI
+}
+
+// serve выполняет запрос через полный маршрутизатор сервиса
+func serve(t *testing.T, songs repository.SongRepository, artists repository.ArtistRepository, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	if songs == nil {
+		songs = &stubSongRepo{}
+	}
+	if artists == nil {
+		artists = &stubArtistRepo{}
+	}
+	rec := httptest.NewRecorder()
+	routes.NewHandler(songs, artists, stubAPI{}).ServeHTTP(rec, req)
+	return rec
+}
+
+func newRequest(method string, target string, body string) *http.Request {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	return httptest.NewRequest(method, target, reader)
+}
+
+// assertProblem проверяет, что ответ - ошибка application/problem+json с заданными статусом и кодом
+func assertProblem(t *testing.T, rec *httptest.ResponseRecorder, status int, code string) handlers.Problem {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("status = %d, want %d; body: %s", rec.Code, status, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("Content-Type = %q, want application/problem+json", ct)
+	}
+	var problem handlers.Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("failed to decode problem: %v; body: %s", err, rec.Body)
+	}
+	if problem.Code != code {
+		t.Errorf("code = %q, want %q", problem.Code, code)
+	}
+	if problem.Status != status {
+		t.Errorf("problem status = %d, want %d", problem.Status, status)
+	}
+	return problem
+}
+
+// decodeJSON разбирает успешный JSON-ответ в v
+func decodeJSON(t *testing.T, rec *httptest.ResponseRecorder, status int, v interface{}) {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("status = %d, want %d; body: %s", rec.Code, status, rec.Body)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("failed to decode response: %v; body: %s", err, rec.Body)
+	}
+}
+
+// repoError - типизированная ошибка репозитория вида kind
+func repoError(kind error, message string) error {
+	return &repository.Error{Kind: kind, Message: message}
+}
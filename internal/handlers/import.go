@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"online-library/internal/logger"
+	"online-library/internal/models"
+	"online-library/internal/repository"
+)
+
+// Ограничения импорта
+const (
+	importBatchSize = 500      //сколько строк импорта добавляется одной транзакцией
+	maxImportSize   = 32 << 20 //размер тела запроса, 32 МБ
+	maxImportRows   = 50000    //число строк данных: отчет и проверка повторов держатся в памяти
+)
+
+// ImportReport отчет об импорте песен
+type ImportReport struct {
+	DryRun  bool                      `json:"dry_run"` //изменения не сохранены
+	Total   int                       `json:"total"`
+	Created int                       `json:"created"`
+	Skipped int                       `json:"skipped"`
+	Failed  int                       `json:"failed"`
+	Rows    []repository.ImportResult `json:"rows"` //итог по каждой строке, по возрастанию номера строки
+}
+
+// ImportAbortedProblem ошибка, прервавшая импорт на середине.
+// @Description Ошибка application/problem+json с отчетом о строках, обработанных до нее: порции, вошедшие в отчет со статусом created, уже сохранены.
+type ImportAbortedProblem struct {
+	Problem
+	Report ImportReport `json:"report"`
+}
+
+func (r *ImportReport) add(results ...repository.ImportResult) {
+	for _, result := range results {
+		r.Total++
+		switch result.Status {
+		case repository.ImportCreated:
+			r.Created++
+		case repository.ImportSkipped:
+			r.Skipped++
+		default:
+			r.Failed++
+		}
+		r.Rows = append(r.Rows, result)
+	}
+}
+
+// ImportSongs добавляет песни из CSV или JSON Lines.
+// @Summary Import Songs
+// @Description Потоковый импорт песен. Формат определяется по Content-Type (text/csv или application/x-ndjson) либо параметру format.
+// @Description CSV должен начинаться со строки заголовка с колонками group, song, genre, release_date, lyrics, link (обязательны group и song, колонка id из выгрузки игнорируется); в JSON Lines каждая строка - объект песни.
+// @Description Данные из внешнего API не запрашиваются; песня, которая уже есть у артиста или повторяется в файле, пропускается. Ответ содержит итог по каждой строке.
+// @Description Строки сохраняются порциями по 500. Тело ограничено 32 МБ и 50000 строками. Если импорт прервался на середине, ответ об ошибке содержит отчет report: уже сохраненные строки в нем отмечены created, строки прерванной порции - failed.
+// @Tags songs
+// @Accept plain
+// @Produce json
+// @Param format query string false "Формат данных, если Content-Type его не определяет" Enums(csv, ndjson)
+// @Param dry_run query bool false "Только проверить данные, ничего не сохраняя"
+// @Success 200 {object} ImportReport "Отчет об импорте"
+// @Failure 400 {object} ImportAbortedProblem "Ошибочные данные или параметры"
+// @Failure 413 {object} ImportAbortedProblem "Превышен размер или число строк"
+// @Failure 415 {object} Problem "Неподдерживаемый формат"
+// @Failure 500 {object} ImportAbortedProblem "Ошибка сервера"
+// @Router /songs/import [post]
+func (h *SongHandler) ImportSongs(w http.ResponseWriter, r *http.Request) {
+	logger.Log.Info("ImportSongs handler invoked")
+
+	query := r.URL.Query()
+	dryRun, err := queryFlag(query, "dry_run")
+	if err != nil {
+		logger.Log.Warnf("Invalid dry_run parameter: %v", err)
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	format, err := importFormat(r)
+	if err != nil {
+		logger.Log.Warnf("Unsupported import format: %v", err)
+		WriteProblem(w, r, http.StatusUnsupportedMediaType, CodeUnsupportedMedia, err.Error())
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxImportSize)
+	var reader songReader
+	switch format {
+	case "csv":
+		reader, err = newCSVSongReader(body)
+	default:
+		reader = newNDJSONSongReader(body)
+	}
+	if err != nil {
+		logger.Log.Warnf("Invalid import data: %v", err)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			WriteProblem(w, r, http.StatusRequestEntityTooLarge, CodePayloadTooLarge, "Import data exceeds 32 MB")
+			return
+		}
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidBody, err.Error())
+		return
+	}
+
+	report := ImportReport{DryRun: dryRun, Rows: []repository.ImportResult{}}
+	seen := make(map[string]int) //ключ песни - номер строки, где она встретилась впервые
+	batch := make([]repository.ImportRow, 0, importBatchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		results, err := h.repoFor(r).ImportSongs(batch, dryRun)
+		if err != nil {
+			return err
+		}
+		report.add(results...)
+		batch = batch[:0]
+		return nil
+	}
+
+	// abort прерывает импорт: строки несохраненной порции отмечаются как не импортированные,
+	// а отчет о предыдущих порциях отдается вместе с ошибкой
+	abort := func(status int, code string, detail string) {
+		for _, row := range batch {
+			report.add(repository.ImportResult{Line: row.Line, Status: repository.ImportFailed, Error: "not imported: import aborted"})
+		}
+		sort.SliceStable(report.Rows, func(i, j int) bool { return report.Rows[i].Line < report.Rows[j].Line })
+		logger.Log.Warnf("Import aborted after %d rows (created=%d): %s", report.Total, report.Created, detail)
+		writeProblemBody(w, status, ImportAbortedProblem{Problem: newProblem(r, status, code, detail), Report: report})
+	}
+	abortOnRepoError := func(err error) {
+		status := repoErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			logger.Log.Errorf("Failed to import songs: %v", err)
+			abort(status, CodeInternal, "Failed to import songs")
+			return
+		}
+		abort(status, repoErrorCode(err), err.Error())
+	}
+
+	rows := 0
+	for {
+		line, song, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var rowErr *importRowError
+		if err != nil && !errors.As(err, &rowErr) {
+			logger.Log.Warnf("Failed to read import data: %v", err)
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				abort(http.StatusRequestEntityTooLarge, CodePayloadTooLarge, "Import data exceeds 32 MB")
+				return
+			}
+			abort(http.StatusBadRequest, CodeInvalidBody, "Failed to read import data: "+err.Error())
+			return
+		}
+
+		if rows++; rows > maxImportRows {
+			abort(http.StatusRequestEntityTooLarge, CodePayloadTooLarge,
+				fmt.Sprintf("Import is limited to %d rows, rows from line %d were not read", maxImportRows, line))
+			return
+		}
+		if rowErr != nil {
+			report.add(repository.ImportResult{Line: rowErr.line, Status: repository.ImportFailed, Error: rowErr.err.Error()})
+			continue
+		}
+
+		if err := validateSong(song); err != nil {
+			report.add(repository.ImportResult{Line: line, Status: repository.ImportFailed, Error: err.Error()})
+			continue
+		}
+
+		key := song.Group + "\x00" + song.Song
+		if first, ok := seen[key]; ok {
+			report.add(repository.ImportResult{Line: line, Status: repository.ImportSkipped,
+				Error: fmt.Sprintf("duplicate of line %d", first)})
+			continue
+		}
+		seen[key] = line
+
+		song.EnrichmentStatus = models.EnrichmentDone
+
+		batch = append(batch, repository.ImportRow{Line: line, Song: song})
+		if len(batch) == importBatchSize {
+			if err := flush(); err != nil {
+				abortOnRepoError(err)
+				return
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		abortOnRepoError(err)
+		return
+	}
+
+	sort.SliceStable(report.Rows, func(i, j int) bool { return report.Rows[i].Line < report.Rows[j].Line })
+	logger.Log.Infof("Import finished: total=%d created=%d skipped=%d failed=%d dry_run=%v",
+		report.Total, report.Created, report.Skipped, report.Failed, dryRun)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// importFormat определяет формат импорта по параметру format или Content-Type
+func importFormat(r *http.Request) (string, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		switch format {
+		case "csv", "ndjson":
+			return format, nil
+		}
+		return "", fmt.Errorf("unsupported format %q, expected csv or ndjson", format)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return "", errors.New("Content-Type must be text/csv or application/x-ndjson")
+	}
+	switch mediaType {
+	case "text/csv":
+		return "csv", nil
+	case "application/x-ndjson", "application/jsonl", "application/jsonlines", "application/x-jsonlines":
+		return "ndjson", nil
+	}
+	return "", fmt.Errorf("unsupported Content-Type %q, expected text/csv or application/x-ndjson", mediaType)
+}
+
+// songReader читает песни из тела запроса по одной
+type songReader interface {
+	// Next возвращает номер строки и песню, в конце данных - io.EOF.
+	// Ошибка в отдельной строке возвращается как *importRowError, после нее чтение можно продолжать.
+	Next() (int, models.Song, error)
+}
+
+// importRowError ошибка формата одной строки импорта
+type importRowError struct {
+	line int
+	err  error
+}
+
+func (e *importRowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.line, e.err)
+}
+
+// csvColumns - колонки CSV, совпадают с JSON-полями models.Song.
+// Колонка id из выгрузки GET /songs/export игнорируется: ID назначает база.
+var csvColumns = map[string]func(song *models.Song, value string){
+	"id":           func(song *models.Song, value string) {},
+	"group":        func(song *models.Song, value string) { song.Group = value },
+	"song":         func(song *models.Song, value string) { song.Song = value },
+	"genre":        func(song *models.Song, value string) { song.Genre = value },
+	"release_date": func(song *models.Song, value string) { song.ReleaseDate = value },
+	"lyrics":       func(song *models.Song, value string) { song.Lyrics = value },
+	"link":         func(song *models.Song, value string) { song.Link = value },
+}
+
+type csvSongReader struct {
+	reader  *csv.Reader
+	columns []func(song *models.Song, value string)
+}
+
+// newCSVSongReader читает заголовок и проверяет, что все колонки известны
+func newCSVSongReader(body io.Reader) (*csvSongReader, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := make([]func(song *models.Song, value string), len(header))
+	found := make(map[string]bool)
+	for i, name := range header {
+		// Excel добавляет в начало файла BOM
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		set, ok := csvColumns[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown CSV column %q", name)
+		}
+		columns[i] = set
+		found[name] = true
+	}
+	if !found["group"] || !found["song"] {
+		return nil, errors.New("CSV header must contain group and song columns")
+	}
+
+	return &csvSongReader{reader: reader, columns: columns}, nil
+}
+
+func (c *csvSongReader) Next() (int, models.Song, error) {
+	record, err := c.reader.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return parseErr.StartLine, models.Song{}, &importRowError{line: parseErr.StartLine, err: parseErr.Err}
+	}
+	if err != nil {
+		return 0, models.Song{}, err
+	}
+
+	line, _ := c.reader.FieldPos(0)
+	if len(record) != len(c.columns) {
+		return line, models.Song{}, &importRowError{line: line,
+			err: fmt.Errorf("expected %d fields, got %d", len(c.columns), len(record))}
+	}
+
+	var song models.Song
+	for i, value := range record {
+		c.columns[i](&song, strings.TrimSpace(value))
+	}
+	return line, song, nil
+}
+
+type ndjsonSongReader struct {
+	reader *bufio.Reader
+	line   int
+}
+
+func newNDJSONSongReader(body io.Reader) *ndjsonSongReader {
+	return &ndjsonSongReader{reader: bufio.NewReader(body)}
+}
+
+func (n *ndjsonSongReader) Next() (int, models.Song, error) {
+	for {
+		data, err := n.reader.ReadBytes('\n')
+		if len(data) == 0 && err != nil {
+			return 0, models.Song{}, err
+		}
+		n.line++
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+
+		var song models.Song
+		if err := json.Unmarshal(data, &song); err != nil {
+			return n.line, models.Song{}, &importRowError{line: n.line, err: fmt.Errorf("invalid JSON: %w", err)}
+		}
+		song.Group, song.Song = strings.TrimSpace(song.Group), strings.TrimSpace(song.Song)
+		return n.line, song, nil
+	}
+}
@@ -0,0 +1,137 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"online-library/internal/handlers"
+	"online-library/internal/repository"
+)
+
+// createAll - ImportSongs, который добавляет все строки порции
+func createAll(rows []repository.ImportRow, dryRun bool) ([]repository.ImportResult, error) {
+	results := make([]repository.ImportResult, len(rows))
+	for i, row := range rows {
+		results[i] = repository.ImportResult{Line: row.Line, Status: repository.ImportCreated, SongID: row.Line}
+	}
+	return results, nil
+}
+
+// ndjsonSongs возвращает n строк JSON Lines с разными песнями
+func ndjsonSongs(n int) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "{\"group\":\"Muse\",\"song\":\"Song %d\"}\n", i)
+	}
+	return b.String()
+}
+
+func importRequest(body string) *http.Request {
+	req := newRequest(http.MethodPost, "/songs/import", body)
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	return req
+}
+
+func TestImportSongsReport(t *testing.T) {
+	var dryRuns []bool
+	repo := &stubSongRepo{
+		importSongs: func(rows []repository.ImportRow, dryRun bool) ([]repository.ImportResult, error) {
+			dryRuns = append(dryRuns, dryRun)
+			for _, row := range rows {
+				if row.Song.EnrichmentStatus != "done" {
+					t.Errorf("line %d: enrichment status = %q, want done", row.Line, row.Song.EnrichmentStatus)
+				}
+			}
+			return createAll(rows, dryRun)
+		},
+	}
+	body := `{"group":"Muse","song":"Uprising","enrichment_status":"dead"}
+{"group":"Muse"}
+{"group":"Muse","song":"Uprising"}
+not json
+`
+	req := newRequest(http.MethodPost, "/songs/import?dry_run=true", body)
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rec := serve(t, repo, nil, req)
+
+	var report handlers.ImportReport
+	decodeJSON(t, rec, http.StatusOK, &report)
+	if report.Total != 4 || report.Created != 1 || report.Skipped != 1 || report.Failed != 2 || !report.DryRun {
+		t.Errorf("report = %+v, want 4 rows: 1 created, 1 skipped, 2 failed, dry run", report)
+	}
+	for i, row := range report.Rows {
+		if row.Line != i+1 {
+			t.Errorf("rows[%d].Line = %d, want %d", i, row.Line, i+1)
+		}
+	}
+	if len(dryRuns) != 1 || !dryRuns[0] {
+		t.Errorf("ImportSongs dry runs = %v, want [true]", dryRuns)
+	}
+}
+
+func TestImportSongsAbortedReturnsPartialReport(t *testing.T) {
+	calls := 0
+	repo := &stubSongRepo{
+		importSongs: func(rows []repository.ImportRow, dryRun bool) ([]repository.ImportResult, error) {
+			if calls++; calls > 1 {
+				return nil, errors.New("connection reset")
+			}
+			return createAll(rows, dryRun)
+		},
+	}
+
+	// Первая порция из 500 строк сохраняется, на второй база отказывает
+	rec := serve(t, repo, nil, importRequest(ndjsonSongs(503)))
+	problem := assertProblem(t, rec, http.StatusInternalServerError, handlers.CodeInternal)
+	if problem.RequestID == "" {
+		t.Error("problem has no request id")
+	}
+
+	var body handlers.ImportAbortedProblem
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	report := body.Report
+	if report.Total != 503 || report.Created != 500 || report.Failed != 3 {
+		t.Errorf("report: total=%d created=%d failed=%d, want 503, 500, 3", report.Total, report.Created, report.Failed)
+	}
+	if last := report.Rows[len(report.Rows)-1]; last.Line != 503 || last.Status != repository.ImportFailed {
+		t.Errorf("last row = %+v, want line 503 failed", last)
+	}
+}
+
+func TestImportSongsRowLimit(t *testing.T) {
+	repo := &stubSongRepo{importSongs: createAll}
+
+	rec := serve(t, repo, nil, importRequest(ndjsonSongs(50001)))
+	assertProblem(t, rec, http.StatusRequestEntityTooLarge, handlers.CodePayloadTooLarge)
+
+	var body handlers.ImportAbortedProblem
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	if body.Report.Total != 50000 || body.Report.Created != 50000 {
+		t.Errorf("report: total=%d created=%d, want 50000 stored rows", body.Report.Total, body.Report.Created)
+	}
+}
+
+func TestImportSongsRejectsUnknownFormat(t *testing.T) {
+	req := newRequest(http.MethodPost, "/songs/import", "a,b")
+	req.Header.Set("Content-Type", "application/xml")
+	assertProblem(t, serve(t, nil, nil, req), http.StatusUnsupportedMediaType, handlers.CodeUnsupportedMedia)
+}
+
+func TestImportSongsRejectsUnknownCSVColumn(t *testing.T) {
+	req := newRequest(http.MethodPost, "/songs/import?format=csv", "group,song,rating\nMuse,Uprising,5\n")
+	assertProblem(t, serve(t, nil, nil, req), http.StatusBadRequest, handlers.CodeInvalidBody)
+}
+
+func TestImportSongsRejectsInvalidDryRun(t *testing.T) {
+	req := newRequest(http.MethodPost, "/songs/import?dry_run=maybe", "group,song\nMuse,Uprising\n")
+	req.Header.Set("Content-Type", "text/csv")
+	assertProblem(t, serve(t, nil, nil, req), http.StatusBadRequest, handlers.CodeInvalidParameter)
+}
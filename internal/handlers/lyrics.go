@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"online-library/internal/logger"
+	"online-library/internal/lyrics"
+	"online-library/internal/models"
+)
+
+// StanzaResponse ответ с одним куплетом песни
+type StanzaResponse struct {
+	Song         string         `json:"song"`           //название песни
+	SongID       int            `json:"song_id"`        //id песни
+	Lang         string         `json:"lang,omitempty"` //язык перевода, нет у оригинального текста
+	Stanza       lyrics.Section `json:"stanza"`
+	TotalStanzas int            `json:"total_stanzas"` //всего куплетов
+}
+
+// LinesResponse ответ с диапазоном строк текста песни
+type LinesResponse struct {
+	Song       string        `json:"song"`           //название песни
+	SongID     int           `json:"song_id"`        //id песни
+	Lang       string        `json:"lang,omitempty"` //язык перевода, нет у оригинального текста
+	From       int           `json:"from"`           //номер первой строки
+	To         int           `json:"to"`             //номер последней строки
+	Lines      []lyrics.Line `json:"lines"`
+	TotalLines int           `json:"total_lines"` //всего строк текста
+}
+
+// GetLyricsStanza возвращает один куплет песни.
+// @Summary Get Lyrics Stanza
+// @Description Куплет (или припев, бридж) песни по номеру с видом части и номерами строк.
+// @Tags songs
+// @Produce json
+// @Param id path int true "ID песни" example(1)
+// @Param lang query string false "Языки перевода через запятую; важнее Accept-Language"
+// @Param index path int true "Номер куплета, с 1" example(2)
+// @Success 200 {object} StanzaResponse "Куплет песни"
+// @Header 200 {string} ETag "Версия песни и перевода"
+// @Failure 400 {object} Problem "Ошибочные параметры запроса"
+// @Failure 404 {object} Problem "Песня, текст или куплет не найден"
+// @Failure 500 {object} Problem "Ошибка сервера"
+// @Router /songs/{id}/lyrics/stanzas/{index} [get]
+func (h *SongHandler) GetLyricsStanza(w http.ResponseWriter, r *http.Request) {
+	logger.Log.Info("GetLyricsStanza handler invoked")
+
+	songID, err := songIDFromPath(r)
+	if err != nil {
+		logger.Log.Warnf("Invalid song id: %s", r.PathValue("id"))
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidID, "Invalid song id")
+		return
+	}
+	index, err := strconv.Atoi(r.PathValue("index"))
+	if err != nil || index < 1 {
+		logger.Log.Warnf("Invalid stanza index: %s", r.PathValue("index"))
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidParameter, "Invalid stanza index")
+		return
+	}
+
+	songLyrics, ok := h.structuredLyrics(w, r, songID)
+	if !ok {
+		return
+	}
+
+	stanza, found := songLyrics.Structure.Section(index)
+	if !found {
+		logger.Log.Warnf("Stanza %d of song ID %d not found", index, songID)
+		WriteProblem(w, r, http.StatusNotFound, CodeNotFound, "Stanza not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", lyricsETag(songLyrics, mediaJSON))
+	json.NewEncoder(w).Encode(StanzaResponse{
+		Song:         songLyrics.Song,
+		SongID:       songID,
+		Lang:         songLyrics.Lang,
+		Stanza:       stanza,
+		TotalStanzas: len(songLyrics.Structure.Sections),
+	})
+}
+
+// GetLyricsLines возвращает диапазон строк текста песни.
+// @Summary Get Lyrics Lines
+// @Description Строки текста песни с номерами от from до to включительно. Строки нумеруются с 1 по всей песне без заголовков и пустых строк; to за концом текста обрезается.
+// @Tags songs
+// @Produce json
+// @Param id path int true "ID песни" example(1)
+// @Param lang query string false "Языки перевода через запятую; важнее Accept-Language"
+// @Param from query int false "Номер первой строки" default(1)
+// @Param to query int false "Номер последней строки; по умолчанию до конца текста"
+// @Success 200 {object} LinesResponse "Строки текста"
+// @Header 200 {string} ETag "Версия песни и перевода"
+// @Failure 400 {object} Problem "Ошибочный диапазон строк"
+// @Failure 404 {object} Problem "Песня или текст не найден"
+// @Failure 500 {object} Problem "Ошибка сервера"
+// @Router /songs/{id}/lyrics/lines [get]
+func (h *SongHandler) GetLyricsLines(w http.ResponseWriter, r *http.Request) {
+	logger.Log.Info("GetLyricsLines handler invoked")
+
+	songID, err := songIDFromPath(r)
+	if err != nil {
+		logger.Log.Warnf("Invalid song id: %s", r.PathValue("id"))
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidID, "Invalid song id")
+		return
+	}
+
+	query := r.URL.Query()
+	from, to := 1, 0 // 0 - до конца текста
+	if value := query.Get("from"); value != "" {
+		if from, err = strconv.Atoi(value); err != nil || from < 1 {
+			logger.Log.Warnf("Invalid from parameter: %q", value)
+			WriteProblem(w, r, http.StatusBadRequest, CodeInvalidParameter, "Invalid from parameter")
+			return
+		}
+	}
+	if value := query.Get("to"); value != "" {
+		if to, err = strconv.Atoi(value); err != nil || to < from {
+			logger.Log.Warnf("Invalid to parameter: %q", value)
+			WriteProblem(w, r, http.StatusBadRequest, CodeInvalidParameter, "Invalid to parameter")
+			return
+		}
+	}
+
+	songLyrics, ok := h.structuredLyrics(w, r, songID)
+	if !ok {
+		return
+	}
+
+	total := songLyrics.Structure.LineCount
+	if from > total {
+		logger.Log.Warnf("Line %d is out of range for song ID %d (%d lines)", from, songID, total)
+		WriteProblem(w, r, http.StatusBadRequest, CodePageOutOfRange, "Line range out of range")
+		return
+	}
+	if to == 0 || to > total {
+		to = total
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", lyricsETag(songLyrics, mediaJSON))
+	json.NewEncoder(w).Encode(LinesResponse{
+		Song:       songLyrics.Song,
+		SongID:     songID,
+		Lang:       songLyrics.Lang,
+		From:       from,
+		To:         to,
+		Lines:      songLyrics.Structure.Lines(from, to),
+		TotalLines: total,
+	})
+}
+
+// structuredLyrics загружает разобранный текст песни; при ошибке ответ уже отправлен
+func (h *SongHandler) structuredLyrics(w http.ResponseWriter, r *http.Request, songID int) (*models.SongLyrics, bool) {
+	logger.Log.Debugf("Fetching structured lyrics for song ID %d", songID)
+	songLyrics, ok := h.lyricsIn(w, r, songID)
+	if !ok {
+		return nil, false
+	}
+	if songLyrics.Structure == nil || len(songLyrics.Structure.Sections) == 0 {
+		logger.Log.Infof("No lyrics found for song ID %d", songID)
+		WriteProblem(w, r, http.StatusNotFound, CodeLyricsNotFound, "Lyrics not found")
+		return nil, false
+	}
+	return songLyrics, true
+}
+
+// writeLyricsDocument отдает страницу куплетов простым текстом, Markdown или HTML
+func writeLyricsDocument(w http.ResponseWriter, mediaType string, songLyrics *models.SongLyrics, sections []lyrics.Section) {
+	var body string
+	switch mediaType {
+	case mediaMarkdown:
+		body = lyrics.RenderMarkdown(songLyrics.Song, sections)
+	case mediaHTML:
+		body = lyrics.RenderHTML(songLyrics.Song, songLyrics.Lang, sections)
+	default:
+		body = lyrics.RenderText(sections)
+	}
+
+	w.Header().Set("Content-Type", mediaType+"; charset=utf-8")
+	io.WriteString(w, body)
+}
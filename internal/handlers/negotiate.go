@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// Представления текста песни, которые отдает GET /songs/{id}/lyrics
+const (
+	mediaJSON     = "application/json"
+	mediaText     = "text/plain"
+	mediaMarkdown = "text/markdown"
+	mediaHTML     = "text/html"
+)
+
+// lyricsMediaTypes представления текста в порядке предпочтения сервера при равном весе
+var lyricsMediaTypes = []string{mediaJSON, mediaText, mediaMarkdown, mediaHTML}
+
+// negotiateMediaType выбирает из offers тип с наибольшим весом q по заголовку Accept.
+// Вес типа определяет самый конкретный подходящий диапазон: text/html важнее text/*, а text/* - */*.
+// Пустой Accept принимает первый тип; если ни один тип не подходит, возвращается пустая строка.
+func negotiateMediaType(accept string, offers []string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	type mediaRange struct {
+		typ, subtype string
+		q            float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{typ, subtype, q})
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		typ, subtype, _ := strings.Cut(offer, "/")
+		q, specificity := 0.0, -1
+		for _, rng := range ranges {
+			var s int
+			switch {
+			case rng.typ == typ && rng.subtype == subtype:
+				s = 2
+			case rng.typ == typ && rng.subtype == "*":
+				s = 1
+			case rng.typ == "*" && rng.subtype == "*":
+				s = 0
+			default:
+				continue
+			}
+			if s > specificity {
+				q, specificity = rng.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
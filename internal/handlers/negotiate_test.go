@@ -0,0 +1,102 @@
+package handlers_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"online-library/internal/handlers"
+	"online-library/internal/lyrics"
+	"online-library/internal/models"
+)
+
+func TestGetSongLyricsNegotiation(t *testing.T) {
+	tests := []struct {
+		accept      string
+		contentType string
+	}{
+		{"", "application/json"},
+		{"*/*", "application/json"},
+		{"text/*", "text/plain; charset=utf-8"},
+		{"text/*;q=0.5, text/markdown", "text/markdown; charset=utf-8"},
+		{"text/html, application/json;q=0.9", "text/html; charset=utf-8"},
+		{"application/json;q=0.1, text/*;q=0.2, text/plain;q=0", "text/markdown; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			req := newRequest(http.MethodGet, "/songs/1/lyrics", "")
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := serve(t, lyricsRepo(0), nil, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", ct, tt.contentType)
+			}
+			if vary := strings.Join(rec.Header().Values("Vary"), ", "); !strings.Contains(vary, "Accept") {
+				t.Errorf("Vary = %q, want Accept", vary)
+			}
+		})
+	}
+}
+
+func TestGetSongLyricsNotAcceptable(t *testing.T) {
+	for _, accept := range []string{"image/png", "text/html;q=0, application/json;q=0, text/*;q=0"} {
+		req := newRequest(http.MethodGet, "/songs/1/lyrics", "")
+		req.Header.Set("Accept", accept)
+		rec := serve(t, lyricsRepo(0), nil, req)
+		problem := assertProblem(t, rec, http.StatusNotAcceptable, handlers.CodeNotAcceptable)
+		if !strings.Contains(problem.Detail, "text/markdown") {
+			t.Errorf("detail = %q, want the supported types", problem.Detail)
+		}
+	}
+}
+
+func TestGetSongLyricsHTMLEscapes(t *testing.T) {
+	repo := &stubSongRepo{
+		getLyricsIn: func(songID int, langs []string) (*models.SongLyrics, error) {
+			text := "<script>alert(1)</script>"
+			return &models.SongLyrics{SongID: songID, Song: "A & B", Lyrics: text, Version: 1, Structure: lyrics.Parse(text)}, nil
+		},
+	}
+	req := newRequest(http.MethodGet, "/songs/1/lyrics", "")
+	req.Header.Set("Accept", "text/html")
+	rec := serve(t, repo, nil, req)
+	body := rec.Body.String()
+	if strings.Contains(body, "<script>") || !strings.Contains(body, "&lt;script&gt;") || !strings.Contains(body, "A &amp; B") {
+		t.Errorf("body is not escaped: %s", body)
+	}
+}
+
+func TestGetSongLyricsErrors(t *testing.T) {
+	emptyLyrics := &stubSongRepo{
+		getLyricsIn: func(songID int, langs []string) (*models.SongLyrics, error) {
+			return &models.SongLyrics{SongID: songID, Song: "Song", Version: 1}, nil
+		},
+	}
+	tests := []struct {
+		name   string
+		repo   *stubSongRepo
+		target string
+		status int
+		code   string
+	}{
+		{"unknown format", lyricsRepo(0), "/songs/1/lyrics?format=xml", http.StatusBadRequest, handlers.CodeInvalidParameter},
+		{"page out of range", lyricsRepo(0), "/songs/1/lyrics?page=9", http.StatusBadRequest, handlers.CodePageOutOfRange},
+		{"no lyrics", emptyLyrics, "/songs/1/lyrics", http.StatusNotFound, handlers.CodeLyricsNotFound},
+		{"no stanza", lyricsRepo(0), "/songs/1/lyrics/stanzas/9", http.StatusNotFound, handlers.CodeNotFound},
+		{"lines out of range", lyricsRepo(0), "/songs/1/lyrics/lines?from=99", http.StatusBadRequest, handlers.CodePageOutOfRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(t, tt.repo, nil, newRequest(http.MethodGet, tt.target, ""))
+			assertProblem(t, rec, tt.status, tt.code)
+			// ETag относится к представлению текста, у ответа об ошибке его нет
+			if etag := rec.Header().Get("ETag"); etag != "" {
+				t.Errorf("ETag = %s on error response", etag)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"online-library/internal/logger"
+	"online-library/internal/models"
+)
+
+// maxPageLimit - наибольший размер страницы, больший limit уменьшается до него
+const maxPageLimit = 100
+
+// PageLinks ссылки для навигации по страницам списка
+type PageLinks struct {
+	Self string `json:"self"`
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// SongListResponse конверт ответа со страницей списка песен
+type SongListResponse struct {
+	Items      []models.Song `json:"items"`                 //песни текущей страницы
+	Total      int           `json:"total"`                 //всего песен под фильтром
+	Page       int           `json:"page,omitempty"`        //номер страницы, не указывается при пагинации курсором
+	Limit      int           `json:"limit"`                 //размер страницы
+	NextCursor string        `json:"next_cursor,omitempty"` //курсор следующей страницы
+	Links      PageLinks     `json:"links"`
+}
+
+// SongSearchResponse конверт ответа со страницей результатов поиска
+type SongSearchResponse struct {
+	Items []models.SongSearchResult `json:"items"` //найденные песни текущей страницы
+	Total int                       `json:"total"` //всего найдено песен
+	Page  int                       `json:"page"`  //номер страницы
+	Limit int                       `json:"limit"` //размер страницы
+	Links PageLinks                 `json:"links"`
+}
+
+// parsePage читает номер и размер страницы из query-параметров page и limit.
+// Ошибочные и отсутствующие значения заменяются на 1 и defaultLimit, limit ограничен maxPageLimit.
+func parsePage(query url.Values, defaultLimit int) (page, limit int) {
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		logger.Log.Warn("Invalid or missing page parameter, defaulting to 1")
+		page = 1
+	}
+	limit, err = strconv.Atoi(query.Get("limit"))
+	if err != nil || limit < 1 {
+		logger.Log.Warnf("Invalid or missing limit parameter, defaulting to %d", defaultLimit)
+		limit = defaultLimit
+	}
+	if limit > maxPageLimit {
+		logger.Log.Warnf("Limit %d exceeds maximum, using %d", limit, maxPageLimit)
+		limit = maxPageLimit
+	}
+	return page, limit
+}
+
+// pageLinks строит ссылки на текущую, следующую и предыдущую страницы.
+// При пагинации курсором (cursor не пуст) ссылки на предыдущую страницу нет.
+func pageLinks(r *http.Request, page, limit, total int, cursor, nextCursor string) PageLinks {
+	withQuery := func(update func(url.Values)) string {
+		query := r.URL.Query()
+		update(query)
+		return r.URL.Path + "?" + query.Encode()
+	}
+
+	links := PageLinks{Self: r.URL.RequestURI()}
+
+	if cursor != "" {
+		if nextCursor != "" {
+			links.Next = withQuery(func(q url.Values) {
+				q.Del("page")
+				q.Set("cursor", nextCursor)
+			})
+		}
+		return links
+	}
+
+	if page*limit < total {
+		links.Next = withQuery(func(q url.Values) { q.Set("page", strconv.Itoa(page+1)) })
+	}
+	if page > 1 {
+		links.Prev = withQuery(func(q url.Values) { q.Set("page", strconv.Itoa(page-1)) })
+	}
+	return links
+}
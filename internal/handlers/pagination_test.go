@@ -0,0 +1,115 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"online-library/internal/handlers"
+	"online-library/internal/models"
+	"online-library/internal/repository"
+)
+
+func TestGetSongsCursorPagination(t *testing.T) {
+	repo := &stubSongRepo{
+		getSongs: func(filter repository.SongFilter) (*repository.SongList, error) {
+			if filter.Cursor != "abc" || filter.Page != 0 || filter.Limit != 2 {
+				t.Errorf("filter = %+v, want cursor abc, no page, limit 2", filter)
+			}
+			return &repository.SongList{Songs: []models.Song{{SongID: 3}, {SongID: 4}}, Total: 9, NextCursor: "def"}, nil
+		},
+	}
+	rec := serve(t, repo, nil, newRequest(http.MethodGet, "/songs?cursor=abc&limit=2&page=3", ""))
+
+	var response handlers.SongListResponse
+	decodeJSON(t, rec, http.StatusOK, &response)
+	if response.NextCursor != "def" || response.Page != 0 {
+		t.Errorf("next_cursor = %q, page = %d, want def and no page", response.NextCursor, response.Page)
+	}
+	// Ссылка на следующую страницу заменяет курсор и не содержит номер страницы; назад курсор не ведет
+	if want := "/songs?cursor=def&limit=2"; response.Links.Next != want {
+		t.Errorf("links.next = %q, want %q", response.Links.Next, want)
+	}
+	if response.Links.Prev != "" {
+		t.Errorf("links.prev = %q, want none", response.Links.Prev)
+	}
+}
+
+func TestGetSongsPageLinks(t *testing.T) {
+	repo := &stubSongRepo{
+		getSongs: func(filter repository.SongFilter) (*repository.SongList, error) {
+			return &repository.SongList{Total: 5}, nil
+		},
+	}
+	rec := serve(t, repo, nil, newRequest(http.MethodGet, "/songs?page=2&limit=2", ""))
+
+	var response handlers.SongListResponse
+	decodeJSON(t, rec, http.StatusOK, &response)
+	if response.Items == nil {
+		t.Error("items = null, want []")
+	}
+	if response.Links.Next != "/songs?limit=2&page=3" || response.Links.Prev != "/songs?limit=2&page=1" {
+		t.Errorf("links = %+v", response.Links)
+	}
+}
+
+func TestGetSongsInvalidCursor(t *testing.T) {
+	repo := &stubSongRepo{
+		getSongs: func(filter repository.SongFilter) (*repository.SongList, error) {
+			return nil, repoError(repository.ErrValidation, `cursor does not match sort order "id"`)
+		},
+	}
+	rec := serve(t, repo, nil, newRequest(http.MethodGet, "/songs?cursor=abc&sort=id", ""))
+	problem := assertProblem(t, rec, http.StatusBadRequest, handlers.CodeValidationFailed)
+	if problem.Detail == "" {
+		t.Error("problem detail is empty, want the repository message")
+	}
+}
+
+func TestPageLimitClamped(t *testing.T) {
+	tests := []struct {
+		target string
+		limit  int
+	}{
+		{"/songs?limit=1000", 100},
+		{"/songs?limit=100", 100},
+		{"/songs?limit=-5", 10},
+		{"/songs?limit=abc", 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			repo := &stubSongRepo{
+				getSongs: func(filter repository.SongFilter) (*repository.SongList, error) {
+					if filter.Limit != tt.limit {
+						t.Errorf("limit = %d, want %d", filter.Limit, tt.limit)
+					}
+					return &repository.SongList{}, nil
+				},
+			}
+			rec := serve(t, repo, nil, newRequest(http.MethodGet, tt.target, ""))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", rec.Code)
+			}
+		})
+	}
+
+	// Остальные списки ограничены тем же максимумом
+	artists := &stubArtistRepo{
+		getArtists: func(name string, page int, limit int) ([]models.Artist, error) {
+			if limit != 100 {
+				t.Errorf("artists limit = %d, want 100", limit)
+			}
+			return []models.Artist{}, nil
+		},
+	}
+	serve(t, nil, artists, newRequest(http.MethodGet, "/artists?limit=500", ""))
+
+	songs := &stubSongRepo{
+		searchSongs: func(query string, page int, limit int) (*repository.SongSearchList, error) {
+			if limit != 100 {
+				t.Errorf("search limit = %d, want 100", limit)
+			}
+			return &repository.SongSearchList{}, nil
+		},
+	}
+	serve(t, songs, nil, newRequest(http.MethodGet, "/songs/search?q=love&limit=500", ""))
+}
@@ -0,0 +1,71 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"online-library/internal/handlers"
+	"online-library/internal/models"
+	"online-library/internal/repository"
+)
+
+func TestPatchSongMergePatch(t *testing.T) {
+	var got models.SongPatch
+	repo := &stubSongRepo{
+		patchSong: func(songID int, patch models.SongPatch, expectedVersion int) (*models.Song, error) {
+			got = patch
+			return &models.Song{SongID: songID, Song: "Song", Genre: "rock", Version: 3}, nil
+		},
+	}
+	req := newRequest(http.MethodPatch, "/songs/1", `{"genre": "rock", "link": null}`)
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	rec := serve(t, repo, nil, req)
+
+	var song models.Song
+	decodeJSON(t, rec, http.StatusOK, &song)
+	if etag := rec.Header().Get("ETag"); etag != `"3"` {
+		t.Errorf("ETag = %s, want \"3\"", etag)
+	}
+	// Переданное значение заменяет поле, null очищает его, остальные поля не попадают в патч
+	if len(got) != 2 || got["genre"] == nil || *got["genre"] != "rock" {
+		t.Errorf("patch = %v, want genre=rock and link=null", got)
+	}
+	if link, ok := got["link"]; !ok || link != nil {
+		t.Errorf("link in patch = %v (present %v), want null", link, ok)
+	}
+}
+
+func TestPatchSongErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		err         error
+		status      int
+		code        string
+	}{
+		{"wrong content type", "text/plain", `{"genre": "rock"}`, nil, http.StatusUnsupportedMediaType, handlers.CodeUnsupportedMedia},
+		{"not an object", "application/merge-patch+json", `["genre"]`, nil, http.StatusBadRequest, handlers.CodeInvalidBody},
+		{"unknown field", "application/merge-patch+json", `{"version": "2"}`, nil, http.StatusBadRequest, handlers.CodeInvalidBody},
+		{"required field null", "application/merge-patch+json", `{"song": null}`, nil, http.StatusBadRequest, handlers.CodeInvalidBody},
+		{"required field empty", "application/merge-patch+json", `{"group": ""}`, nil, http.StatusBadRequest, handlers.CodeInvalidBody},
+		{"not a string", "application/merge-patch+json", `{"genre": 5}`, nil, http.StatusBadRequest, handlers.CodeInvalidBody},
+		{"not found", "application/merge-patch+json", `{"genre": "rock"}`, repoError(repository.ErrNotFound, "song with ID 1 not found"), http.StatusNotFound, handlers.CodeNotFound},
+		{"duplicate", "application/json", `{"song": "Taken"}`, repoError(repository.ErrConflict, "song already exists"), http.StatusConflict, handlers.CodeConflict},
+		{"stale version", "application/merge-patch+json", `{"genre": "rock"}`, repoError(repository.ErrVersionMismatch, "song version is 4, not 3"), http.StatusPreconditionFailed, handlers.CodeVersionMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubSongRepo{
+				patchSong: func(songID int, patch models.SongPatch, expectedVersion int) (*models.Song, error) {
+					return nil, tt.err
+				},
+			}
+			req := newRequest(http.MethodPatch, "/songs/1", tt.body)
+			req.Header.Set("Content-Type", tt.contentType)
+			req.Header.Set("If-Match", `"3"`)
+			rec := serve(t, repo, nil, req)
+			assertProblem(t, rec, tt.status, tt.code)
+		})
+	}
+}
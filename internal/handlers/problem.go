@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"regexp"
+
+	"online-library/internal/logger"
+)
+
+// Коды ошибок в поле code ответа application/problem+json. По коду клиент различает ошибки,
+// не разбирая текст detail.
+const (
+	CodeInvalidID           = "invalid_id"               //ID в пути не является положительным числом
+	CodeInvalidParameter    = "invalid_parameter"        //ошибочный параметр запроса
+	CodeInvalidHeader       = "invalid_header"           //ошибочный заголовок, например If-Match
+	CodeInvalidBody         = "invalid_body"             //тело запроса не разбирается
+	CodeValidationFailed    = "validation_failed"        //данные разобраны, но не прошли проверку
+	CodePageOutOfRange      = "page_out_of_range"        //страница или диапазон строк за концом списка
+	CodeNotFound            = "not_found"                //ресурс не найден
+	CodeLyricsNotFound      = "lyrics_not_found"         //у песни нет текста в запрошенном виде
+	CodeConflict            = "conflict"                 //запрос противоречит состоянию ресурса
+	CodeVersionMismatch     = "version_mismatch"         //версия ресурса не совпадает с If-Match
+	CodeNotAcceptable       = "not_acceptable"           //нет представления, подходящего под Accept
+	CodeUnsupportedMedia    = "unsupported_media_type"   //неподдерживаемый Content-Type
+	CodePayloadTooLarge     = "payload_too_large"        //тело запроса слишком большое
+	CodeMethodNotAllowed    = "method_not_allowed"       //метод не поддерживается ресурсом
+	CodeExternalNotFound    = "external_not_found"       //песня не найдена во внешнем API
+	CodeExternalUnavailable = "external_api_unavailable" //внешний API временно недоступен
+	CodeExternalError       = "external_api_error"       //внешний API вернул ошибку
+	CodeInternal            = "internal_error"           //ошибка сервера
+)
+
+// Problem описание ошибки по RFC 7807.
+// @Description Ошибка в формате application/problem+json с машиночитаемым кодом и ID запроса.
+type Problem struct {
+	Type      string `json:"type" example:"about:blank"` //тип ошибки; about:blank - смысл ошибки передает статус
+	Title     string `json:"title" example:"Not Found"`  //описание HTTP-статуса
+	Status    int    `json:"status" example:"404"`       //HTTP-статус
+	Detail    string `json:"detail,omitempty" example:"song with ID 1 not found"`
+	Instance  string `json:"instance,omitempty" example:"/songs/1"` //путь запроса
+	Code      string `json:"code" example:"not_found"`              //машиночитаемый код ошибки
+	RequestID string `json:"request_id,omitempty" example:"3f2b8c0e9a1d4e7f"`
+}
+
+// StatusResponse тело успешного ответа на операции, которые не возвращают ресурс
+type StatusResponse struct {
+	ID      int    `json:"id" example:"1"`
+	Status  string `json:"status" example:"updated"` //updated, deleted
+	Message string `json:"message" example:"Song updated successfully"`
+}
+
+// WriteProblem отвечает клиенту ошибкой в формате application/problem+json
+func WriteProblem(w http.ResponseWriter, r *http.Request, status int, code string, detail string) {
+	writeProblemBody(w, status, newProblem(r, status, code, detail))
+}
+
+// newProblem описывает ошибку запроса r
+func newProblem(r *http.Request, status int, code string, detail string) Problem {
+	return Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: RequestIDFrom(r.Context()),
+	}
+}
+
+// writeProblemBody отвечает ошибкой с телом problem - Problem или структурой,
+// которая встраивает Problem и добавляет поля-расширения (RFC 7807, раздел 3.2)
+func writeProblemBody(w http.ResponseWriter, status int, problem interface{}) {
+	w.Header().Del("Content-Length")
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		logger.Log.Errorf("Failed to encode problem response: %v", err)
+	}
+}
+
+// writeStatus отвечает клиенту телом StatusResponse
+func writeStatus(w http.ResponseWriter, id int, status string, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(StatusResponse{ID: id, Status: status, Message: message})
+}
+
+// requestIDHeader заголовок с ID запроса
+const requestIDHeader = "X-Request-ID"
+
+// requestIDPattern допустимый ID запроса от клиента или прокси
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+type requestIDKey struct{}
+
+// RequestID присваивает каждому запросу ID: берет его из заголовка X-Request-ID или создает новый.
+// ID возвращается клиенту в том же заголовке и попадает в ответы с ошибками.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// RequestIDFrom возвращает ID запроса, присвоенный RequestID; без него - пустую строку
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// newRequestID создает случайный ID запроса
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
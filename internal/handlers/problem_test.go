@@ -0,0 +1,73 @@
+package handlers_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"online-library/internal/handlers"
+	"online-library/internal/models"
+)
+
+func TestUnknownRouteProblem(t *testing.T) {
+	rec := serve(t, nil, nil, newRequest(http.MethodGet, "/albums", ""))
+	problem := assertProblem(t, rec, http.StatusNotFound, handlers.CodeNotFound)
+	if problem.Instance != "/albums" || problem.Title != "Not Found" || problem.Type != "about:blank" {
+		t.Errorf("problem = %+v", problem)
+	}
+}
+
+func TestMethodNotAllowedProblem(t *testing.T) {
+	rec := serve(t, nil, nil, newRequest(http.MethodPost, "/songs/1", ""))
+	assertProblem(t, rec, http.StatusMethodNotAllowed, handlers.CodeMethodNotAllowed)
+	allow := rec.Header().Get("Allow")
+	for _, method := range []string{"GET", "PUT", "PATCH", "DELETE"} {
+		if !strings.Contains(allow, method) {
+			t.Errorf("Allow = %q, want %s", allow, method)
+		}
+	}
+}
+
+func TestRequestIDInProblem(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{"client id", "req-42.a_b", true},
+		{"invalid id replaced", "bad id\n", false},
+		{"generated", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest(http.MethodGet, "/songs/abc", "")
+			if tt.header != "" {
+				req.Header.Set("X-Request-ID", tt.header)
+			}
+			rec := serve(t, nil, nil, req)
+			problem := assertProblem(t, rec, http.StatusBadRequest, handlers.CodeInvalidID)
+
+			id := rec.Header().Get("X-Request-ID")
+			if id == "" || problem.RequestID != id {
+				t.Errorf("X-Request-ID = %q, request_id = %q, want the same non-empty id", id, problem.RequestID)
+			}
+			if (id == tt.header) != tt.keep {
+				t.Errorf("X-Request-ID = %q, client sent %q, keep = %v", id, tt.header, tt.keep)
+			}
+		})
+	}
+}
+
+func TestInternalErrorHidesDetails(t *testing.T) {
+	repo := &stubSongRepo{
+		getSong: func(songID int) (*models.Song, error) {
+			return nil, errors.New("pq: password authentication failed for user \"library\"")
+		},
+	}
+	rec := serve(t, repo, nil, newRequest(http.MethodGet, "/songs/1", ""))
+	problem := assertProblem(t, rec, http.StatusInternalServerError, handlers.CodeInternal)
+	if strings.Contains(problem.Detail, "pq:") {
+		t.Errorf("detail = %q leaks the database error", problem.Detail)
+	}
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"online-library/internal/diff"
+	"online-library/internal/logger"
+	"online-library/internal/models"
+	"online-library/internal/repository"
+)
+
+// authorHeader заголовок, которым клиент подписывает изменения песен в истории.
+// Сервис не проверяет подлинность клиентов, поэтому автор в истории - имя, которое назвал сам клиент,
+// а не подтвержденная личность.
+const authorHeader = "X-Author"
+
+// maxAuthorLength ограничение длины имени автора изменений
+const maxAuthorLength = 100
+
+// RevisionListResponse конверт ответа со страницей истории изменений песни
+type RevisionListResponse struct {
+	Items []models.SongRevision `json:"items"` //ревизии, последние идут первыми
+	Total int                   `json:"total"` //всего ревизий у песни
+	Page  int                   `json:"page"`
+	Limit int                   `json:"limit"`
+	Links PageLinks             `json:"links"`
+}
+
+// repoFor возвращает репозиторий, изменения через который записываются в историю от имени автора запроса
+func (h *SongHandler) repoFor(r *http.Request) repository.SongRepository {
+	return h.Repo.WithAuthor(requestAuthor(r))
+}
+
+// requestAuthor возвращает автора изменений из заголовка X-Author; без заголовка - anonymous
+func requestAuthor(r *http.Request) string {
+	author := strings.TrimSpace(r.Header.Get(authorHeader))
+	if author == "" {
+		author = "anonymous"
+	}
+	if len([]rune(author)) > maxAuthorLength {
+		author = string([]rune(author)[:maxAuthorLength])
+	}
+	return author
+}
+
+// GetSongRevisions возвращает историю изменений песни.
+// @Summary Get Song Revisions
+// @Description История изменений песни: кто, когда и какие поля поменял, последние изменения идут первыми. Автор берется из заголовка X-Author запроса, изменившего песню, и не проверяется: это имя, которое назвал клиент, поэтому история не годится как аудит. Фоновые изменения записываются от имени system. Переименование артиста записывается в историю каждой его песни с действием artist_rename.
+// @Tags songs
+// @Produce json
+// @Param id path int true "ID песни" example(1)
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество элементов на странице" default(10) maximum(100)
+// @Success 200 {object} RevisionListResponse "Страница истории"
+// @Failure 400 {object} Problem "Ошибочный ID"
+// @Failure 404 {object} Problem "Песня не найдена"
+// @Failure 500 {object} Problem "Ошибка сервера"
+// @Router /songs/{id}/revisions [get]
+func (h *SongHandler) GetSongRevisions(w http.ResponseWriter, r *http.Request) {
+	logger.Log.Info("GetSongRevisions handler invoked")
+
+	songID, err := songIDFromPath(r)
+	if err != nil {
+		logger.Log.Warnf("Invalid song id: %s", r.PathValue("id"))
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidID, "Invalid song id")
+		return
+	}
+
+	query := r.URL.Query()
+	page, limit := parsePage(query, 10)
+
+	list, err := h.Repo.GetSongRevisions(songID, page, limit)
+	if err != nil {
+		writeRepoError(w, r, err, "Failed to fetch song revisions")
+		return
+	}
+
+	response := RevisionListResponse{
+		Items: list.Revisions,
+		Total: list.Total,
+		Page:  page,
+		Limit: limit,
+		Links: pageLinks(r, page, limit, list.Total, "", ""),
+	}
+	if response.Items == nil {
+		response.Items = []models.SongRevision{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// DiffSongRevisions возвращает различия текста песни между двумя ревизиями.
+// @Summary Diff Song Lyrics
+// @Description Различия текста песни между ревизиями from и to в формате unified diff. Без to текст сравнивается с текущим. Одинаковые тексты дают пустой ответ.
+// @Tags songs
+// @Produce plain
+// @Param id path int true "ID песни" example(1)
+// @Param from query int true "ID ревизии, с которой сравнивать"
+// @Param to query int false "ID ревизии, с которой сравнивать; по умолчанию текущий текст"
+// @Param context query int false "Сколько неизмененных строк показывать вокруг изменений" default(3)
+// @Success 200 {string} string "Unified diff текста"
+// @Failure 400 {object} Problem "Ошибочные параметры"
+// @Failure 404 {object} Problem "Песня или ревизия не найдена"
+// @Failure 500 {object} Problem "Ошибка сервера"
+// @Router /songs/{id}/revisions/diff [get]
+func (h *SongHandler) DiffSongRevisions(w http.ResponseWriter, r *http.Request) {
+	logger.Log.Info("DiffSongRevisions handler invoked")
+
+	songID, err := songIDFromPath(r)
+	if err != nil {
+		logger.Log.Warnf("Invalid song id: %s", r.PathValue("id"))
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidID, "Invalid song id")
+		return
+	}
+
+	query := r.URL.Query()
+	from, err := strconv.ParseInt(query.Get("from"), 10, 64)
+	if err != nil || from <= 0 {
+		logger.Log.Warnf("Invalid from parameter: %q", query.Get("from"))
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidParameter, "Invalid or missing from parameter")
+		return
+	}
+	var to int64 // 0 - текущее состояние песни
+	if value := query.Get("to"); value != "" {
+		to, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || to <= 0 {
+			logger.Log.Warnf("Invalid to parameter: %q", value)
+			WriteProblem(w, r, http.StatusBadRequest, CodeInvalidParameter, "Invalid to parameter")
+			return
+		}
+	}
+	context := diff.DefaultContext
+	if value := query.Get("context"); value != "" {
+		context, err = strconv.Atoi(value)
+		if err != nil || context < 0 {
+			logger.Log.Warnf("Invalid context parameter: %q", value)
+			WriteProblem(w, r, http.StatusBadRequest, CodeInvalidParameter, "Invalid context parameter")
+			return
+		}
+	}
+
+	fromState, err := h.Repo.GetRevisionState(songID, from)
+	if err != nil {
+		writeRepoError(w, r, err, "Failed to fetch revision")
+		return
+	}
+	toState, err := h.Repo.GetRevisionState(songID, to)
+	if err != nil {
+		writeRepoError(w, r, err, "Failed to fetch revision")
+		return
+	}
+
+	toName := "current"
+	if to > 0 {
+		toName = fmt.Sprintf("revision %d", to)
+	}
+	result := diff.Unified(fmt.Sprintf("revision %d", from), toName,
+		stateValue(fromState, "lyrics"), stateValue(toState, "lyrics"), context)
+
+	logger.Log.Debugf("Lyrics diff of song ID %d between revisions %d and %d: %d bytes", songID, from, to, len(result))
+	w.Header().Set("Content-Type", "text/x-diff; charset=utf-8")
+	w.Write([]byte(result))
+}
+
+// RevertSong откатывает песню к ревизии.
+// @Summary Revert Song
+// @Description Возвращает полям песни значения, которые были у них сразу после указанной ревизии. Откат записывается в историю как новая ревизия. Переименования артиста (artist_rename) откат не отменяет: песня остается у того же артиста. Заголовок If-Match включает проверку версии.
+// @Tags songs
+// @Produce json
+// @Param id path int true "ID песни" example(1)
+// @Param revision path int true "ID ревизии"
+// @Param If-Match header string false "ETag песни, полученный ранее"
+// @Param X-Author header string false "Автор изменения для истории, указывается клиентом и не проверяется"
+// @Success 200 {object} models.Song "Песня после отката"
+// @Header 200 {string} ETag "Новая версия песни"
+// @Failure 400 {object} Problem "Ошибочные параметры"
+// @Failure 404 {object} Problem "Песня или ревизия не найдена"
+// @Failure 412 {object} Problem "Версия песни не совпадает с If-Match"
+// @Failure 500 {object} Problem "Ошибка сервера"
+// @Router /songs/{id}/revisions/{revision}/revert [post]
+func (h *SongHandler) RevertSong(w http.ResponseWriter, r *http.Request) {
+	logger.Log.Info("RevertSong handler invoked")
+
+	songID, err := songIDFromPath(r)
+	if err != nil {
+		logger.Log.Warnf("Invalid song id: %s", r.PathValue("id"))
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidID, "Invalid song id")
+		return
+	}
+	revisionID, err := strconv.ParseInt(r.PathValue("revision"), 10, 64)
+	if err != nil || revisionID <= 0 {
+		logger.Log.Warnf("Invalid revision id: %s", r.PathValue("revision"))
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidID, "Invalid revision id")
+		return
+	}
+
+	expectedVersion, ok := h.ifMatchVersion(w, r, songID)
+	if !ok {
+		return
+	}
+
+	song, err := h.repoFor(r).RevertSong(songID, revisionID, expectedVersion)
+	if err != nil {
+		writeRepoError(w, r, err, "Failed to revert song")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", songETag(song.Version))
+	json.NewEncoder(w).Encode(song)
+}
+
+// stateValue возвращает значение поля из состояния песни, пустое поле дает пустую строку
+func stateValue(state models.SongPatch, field string) string {
+	if value := state[field]; value != nil {
+		return *value
+	}
+	return ""
+}
@@ -0,0 +1,173 @@
+package handlers_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"online-library/internal/handlers"
+	"online-library/internal/models"
+	"online-library/internal/repository"
+)
+
+func TestRevertSong(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifMatch     string
+		author      string
+		err         error
+		wantVersion int
+		wantAuthor  string
+		status      int
+		code        string
+	}{
+		{"reverted", `"4"`, "alice", nil, 4, "alice", http.StatusOK, ""},
+		{"anonymous author", "", "", nil, 0, "anonymous", http.StatusOK, ""},
+		{"stale version", `"3"`, "alice", repoError(repository.ErrVersionMismatch, "song version is 4, not 3"), 3, "alice", http.StatusPreconditionFailed, handlers.CodeVersionMismatch},
+		{"missing revision", "", "alice", repoError(repository.ErrNotFound, "revision 9 not found"), 0, "alice", http.StatusNotFound, handlers.CodeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubSongRepo{
+				revertSong: func(songID int, revisionID int64, expectedVersion int) (*models.Song, error) {
+					if songID != 7 || revisionID != 9 || expectedVersion != tt.wantVersion {
+						t.Errorf("RevertSong(%d, %d, %d), want (7, 9, %d)", songID, revisionID, expectedVersion, tt.wantVersion)
+					}
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return &models.Song{SongID: 7, Version: 5}, nil
+				},
+			}
+			req := newRequest(http.MethodPost, "/songs/7/revisions/9/revert", "")
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			if tt.author != "" {
+				req.Header.Set("X-Author", tt.author)
+			}
+			rec := serve(t, repo, nil, req)
+			if repo.author != tt.wantAuthor {
+				t.Errorf("author = %q, want %q", repo.author, tt.wantAuthor)
+			}
+			if tt.err != nil {
+				assertProblem(t, rec, tt.status, tt.code)
+				return
+			}
+			var song models.Song
+			decodeJSON(t, rec, tt.status, &song)
+			if etag := rec.Header().Get("ETag"); etag != `"5"` {
+				t.Errorf("ETag = %s, want \"5\"", etag)
+			}
+		})
+	}
+}
+
+func TestRevertSongInvalidIfMatch(t *testing.T) {
+	req := newRequest(http.MethodPost, "/songs/7/revisions/9/revert", "")
+	req.Header.Set("If-Match", "4")
+	rec := serve(t, nil, nil, req)
+	assertProblem(t, rec, http.StatusBadRequest, handlers.CodeInvalidHeader)
+}
+
+func TestUpdateArtistAuthor(t *testing.T) {
+	// Переименование артиста попадает в историю песен, поэтому обработчик передает автора запроса
+	var updated models.Artist
+	repo := &stubArtistRepo{
+		updateArtist: func(artistID int, artist models.Artist) error {
+			if artistID != 3 {
+				t.Errorf("artistID = %d, want 3", artistID)
+			}
+			updated = artist
+			return nil
+		},
+	}
+	req := newRequest(http.MethodPut, "/artists/3", `{"name": "Muse"}`)
+	req.Header.Set("X-Author", "  bob  ")
+	rec := serve(t, nil, repo, req)
+	var response handlers.StatusResponse
+	decodeJSON(t, rec, http.StatusOK, &response)
+	if updated.Name != "Muse" {
+		t.Errorf("name = %q, want Muse", updated.Name)
+	}
+	if repo.author != "bob" {
+		t.Errorf("author = %q, want bob", repo.author)
+	}
+}
+
+func TestGetSongRevisions(t *testing.T) {
+	repo := &stubSongRepo{
+		getRevisions: func(songID int, page int, limit int) (*repository.RevisionList, error) {
+			if songID != 7 || page != 2 || limit != 1 {
+				t.Errorf("GetSongRevisions(%d, %d, %d), want (7, 2, 1)", songID, page, limit)
+			}
+			return &repository.RevisionList{Revisions: []models.SongRevision{{RevisionID: 2, SongID: 7}}, Total: 3}, nil
+		},
+	}
+	rec := serve(t, repo, nil, newRequest(http.MethodGet, "/songs/7/revisions?page=2&limit=1", ""))
+
+	var response handlers.RevisionListResponse
+	decodeJSON(t, rec, http.StatusOK, &response)
+	if len(response.Items) != 1 || response.Links.Next == "" || response.Links.Prev == "" {
+		t.Errorf("response = %+v, want one item with next and prev links", response)
+	}
+}
+
+func TestGetSongRevisionsNotFound(t *testing.T) {
+	repo := &stubSongRepo{
+		getRevisions: func(songID int, page int, limit int) (*repository.RevisionList, error) {
+			return nil, repoError(repository.ErrNotFound, "song with ID 7 not found")
+		},
+	}
+	rec := serve(t, repo, nil, newRequest(http.MethodGet, "/songs/7/revisions", ""))
+	assertProblem(t, rec, http.StatusNotFound, handlers.CodeNotFound)
+}
+
+// revisionStates отдает состояние песни после ревизии по ее ID; 0 - текущее состояние
+func revisionStates(lyricsByRevision map[int64]string) *stubSongRepo {
+	return &stubSongRepo{
+		revisionState: func(songID int, revisionID int64) (models.SongPatch, error) {
+			text, ok := lyricsByRevision[revisionID]
+			if !ok {
+				return nil, repoError(repository.ErrNotFound, "revision not found")
+			}
+			return models.SongPatch{"lyrics": &text}, nil
+		},
+	}
+}
+
+func TestDiffSongRevisions(t *testing.T) {
+	repo := revisionStates(map[int64]string{1: "one\ntwo", 0: "one\nthree"})
+	rec := serve(t, repo, nil, newRequest(http.MethodGet, "/songs/7/revisions/diff?from=1", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"--- revision 1", "+++ current", "-two", "+three"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("diff does not contain %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestDiffSongRevisionsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		status int
+		code   string
+	}{
+		{"missing from", "/songs/7/revisions/diff", http.StatusBadRequest, handlers.CodeInvalidParameter},
+		{"invalid to", "/songs/7/revisions/diff?from=1&to=x", http.StatusBadRequest, handlers.CodeInvalidParameter},
+		{"negative context", "/songs/7/revisions/diff?from=1&context=-1", http.StatusBadRequest, handlers.CodeInvalidParameter},
+		{"unknown from", "/songs/7/revisions/diff?from=5", http.StatusNotFound, handlers.CodeNotFound},
+		{"unknown to", "/songs/7/revisions/diff?from=1&to=5", http.StatusNotFound, handlers.CodeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := revisionStates(map[int64]string{1: "one", 0: "two"})
+			rec := serve(t, repo, nil, newRequest(http.MethodGet, tt.target, ""))
+			assertProblem(t, rec, tt.status, tt.code)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"online-library/internal/handlers"
+	"online-library/internal/routes"
+)
+
+func TestDeprecatedQueryRoute(t *testing.T) {
+	repo := &stubSongRepo{
+		deleteSong: func(songID int, expectedVersion int) error {
+			if songID != 4 {
+				t.Errorf("DeleteSong(%d), want 4", songID)
+			}
+			return nil
+		},
+	}
+	rec := serve(t, repo, nil, newRequest(http.MethodDelete, "/songs/?id=4", ""))
+
+	var response handlers.StatusResponse
+	decodeJSON(t, rec, http.StatusOK, &response)
+	if rec.Header().Get("Deprecation") != "true" {
+		t.Error("Deprecation header is missing")
+	}
+	if link := rec.Header().Get("Link"); link != `</songs/4>; rel="successor-version"` {
+		t.Errorf("Link = %q", link)
+	}
+}
+
+func TestDeprecatedQueryRouteLyricsSuccessor(t *testing.T) {
+	rec := serve(t, lyricsRepo(0), nil, newRequest(http.MethodGet, "/songs/?id=1", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if link := rec.Header().Get("Link"); link != `</songs/1/lyrics>; rel="successor-version"` {
+		t.Errorf("Link = %q", link)
+	}
+}
+
+func TestDeprecatedQueryRouteErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		status int
+		code   string
+	}{
+		{"missing id", http.MethodGet, "/songs/", http.StatusBadRequest, handlers.CodeInvalidParameter},
+		{"invalid id", http.MethodGet, "/songs/?id=-1", http.StatusBadRequest, handlers.CodeInvalidID},
+		{"unsupported method", http.MethodPost, "/songs/?id=1", http.StatusMethodNotAllowed, handlers.CodeMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(t, nil, nil, newRequest(tt.method, tt.target, ""))
+			assertProblem(t, rec, tt.status, tt.code)
+		})
+	}
+}
+
+func TestMetricsOnlyOnAdminHandler(t *testing.T) {
+	// Метрики не публикуются на публичном API
+	rec := serve(t, nil, nil, newRequest(http.MethodGet, "/debug/vars", ""))
+	assertProblem(t, rec, http.StatusNotFound, handlers.CodeNotFound)
+
+	rec = httptest.NewRecorder()
+	routes.NewAdminHandler().ServeHTTP(rec, newRequest(http.MethodGet, "/debug/vars", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("admin status = %d, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"online-library/internal/logger"
+	"online-library/internal/models"
+)
+
+// SearchSongs выполняет полнотекстовый поиск песен.
+// @Summary Search Songs
+// @Description Полнотекстовый поиск по тексту, названию песни и группе. Результаты упорядочены по релевантности, в snippet совпадения выделены тегами <b></b>. Поддерживается синтаксис web-поиска: "точная фраза", OR, -исключение.
+// @Tags songs
+// @Accept json
+// @Produce json
+// @Param q query string true "Поисковый запрос" example("love")
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество элементов на странице" default(10) maximum(100)
+// @Success 200 {object} SongSearchResponse "Страница найденных песен"
+// @Failure 400 {object} Problem "Ошибочные параметры запроса"
+// @Failure 500 {object} Problem "Ошибка сервера"
+// @Router /songs/search [get]
+func (h *SongHandler) SearchSongs(w http.ResponseWriter, r *http.Request) {
+	logger.Log.Info("SearchSongs handler invoked")
+
+	query := r.URL.Query()
+	text := strings.TrimSpace(query.Get("q"))
+	if text == "" {
+		logger.Log.Warn("Missing search query")
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidParameter, "Missing q parameter")
+		return
+	}
+
+	page, limit := parsePage(query, 10)
+
+	list, err := h.Repo.SearchSongs(text, page, limit)
+	if err != nil {
+		writeRepoError(w, r, err, "Failed to search songs")
+		return
+	}
+
+	logger.Log.Infof("Search for %q returned %d of %d songs", text, len(list.Results), list.Total)
+
+	response := SongSearchResponse{
+		Items: list.Results,
+		Total: list.Total,
+		Page:  page,
+		Limit: limit,
+		Links: pageLinks(r, page, limit, list.Total, "", ""),
+	}
+	if response.Items == nil {
+		response.Items = []models.SongSearchResult{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Log.Errorf("Failed to encode response: %v", err)
+		WriteProblem(w, r, http.StatusInternalServerError, CodeInternal, "Failed to encode response")
+	}
+}
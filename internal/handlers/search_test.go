@@ -0,0 +1,38 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"online-library/internal/handlers"
+	"online-library/internal/repository"
+)
+
+func TestSearchSongs(t *testing.T) {
+	repo := &stubSongRepo{
+		searchSongs: func(query string, page int, limit int) (*repository.SongSearchList, error) {
+			if query != "love me" || page != 1 || limit != 10 {
+				t.Errorf("SearchSongs(%q, %d, %d), want (\"love me\", 1, 10)", query, page, limit)
+			}
+			return &repository.SongSearchList{Total: 0}, nil
+		},
+	}
+	rec := serve(t, repo, nil, newRequest(http.MethodGet, "/songs/search?q=+love+me+&page=0", ""))
+
+	var response handlers.SongSearchResponse
+	decodeJSON(t, rec, http.StatusOK, &response)
+	if response.Items == nil {
+		t.Error("items = null, want []")
+	}
+}
+
+func TestSearchSongsErrors(t *testing.T) {
+	assertProblem(t, serve(t, nil, nil, newRequest(http.MethodGet, "/songs/search?q=++", "")), http.StatusBadRequest, handlers.CodeInvalidParameter)
+
+	repo := &stubSongRepo{
+		searchSongs: func(query string, page int, limit int) (*repository.SongSearchList, error) {
+			return nil, repoError(repository.ErrValidation, "search query has no words")
+		},
+	}
+	assertProblem(t, serve(t, repo, nil, newRequest(http.MethodGet, "/songs/search?q=the", "")), http.StatusBadRequest, handlers.CodeValidationFailed)
+}
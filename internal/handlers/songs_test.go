@@ -0,0 +1,116 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"online-library/internal/handlers"
+	"online-library/internal/models"
+	"online-library/internal/repository"
+)
+
+func TestGetSongETag(t *testing.T) {
+	repo := &stubSongRepo{
+		getSong: func(songID int) (*models.Song, error) {
+			return &models.Song{SongID: songID, Song: "Song", Version: 7}, nil
+		},
+	}
+	rec := serve(t, repo, nil, newRequest(http.MethodGet, "/songs/2", ""))
+	var song models.Song
+	decodeJSON(t, rec, http.StatusOK, &song)
+	if etag := rec.Header().Get("ETag"); etag != `"7"` {
+		t.Errorf("ETag = %s, want \"7\"", etag)
+	}
+}
+
+func TestGetSongErrors(t *testing.T) {
+	repo := &stubSongRepo{
+		getSong: func(songID int) (*models.Song, error) {
+			return nil, repoError(repository.ErrNotFound, "song with ID 2 not found")
+		},
+	}
+	assertProblem(t, serve(t, repo, nil, newRequest(http.MethodGet, "/songs/2", "")), http.StatusNotFound, handlers.CodeNotFound)
+	assertProblem(t, serve(t, nil, nil, newRequest(http.MethodGet, "/songs/abc", "")), http.StatusBadRequest, handlers.CodeInvalidID)
+}
+
+func TestUpdateSongPreconditions(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifMatch     string
+		body        string
+		err         error
+		wantVersion int
+		status      int
+		code        string
+	}{
+		{"updated", `"3"`, `{"group": "Muse", "song": "Uprising"}`, nil, 3, http.StatusOK, ""},
+		{"without If-Match", "", `{"group": "Muse", "song": "Uprising"}`, nil, 0, http.StatusOK, ""},
+		{"stale version", `"3"`, `{"group": "Muse", "song": "Uprising"}`, repoError(repository.ErrVersionMismatch, "song version is 4, not 3"), 3, http.StatusPreconditionFailed, handlers.CodeVersionMismatch},
+		{"not found", `"3"`, `{"group": "Muse", "song": "Uprising"}`, repoError(repository.ErrNotFound, "song with ID 2 not found"), 3, http.StatusNotFound, handlers.CodeNotFound},
+		{"duplicate", "", `{"group": "Muse", "song": "Uprising"}`, repoError(repository.ErrConflict, "song already exists"), 0, http.StatusConflict, handlers.CodeConflict},
+		{"missing fields", "", `{"group": "Muse"}`, nil, 0, http.StatusBadRequest, handlers.CodeValidationFailed},
+		{"invalid If-Match", "3", `{"group": "Muse", "song": "Uprising"}`, nil, 0, http.StatusBadRequest, handlers.CodeInvalidHeader},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubSongRepo{
+				updateSong: func(songID int, song models.Song, expectedVersion int) error {
+					if songID != 2 || expectedVersion != tt.wantVersion {
+						t.Errorf("UpdateSong(%d, _, %d), want (2, _, %d)", songID, expectedVersion, tt.wantVersion)
+					}
+					return tt.err
+				},
+			}
+			req := newRequest(http.MethodPut, "/songs/2", tt.body)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			rec := serve(t, repo, nil, req)
+			if tt.code != "" {
+				assertProblem(t, rec, tt.status, tt.code)
+				return
+			}
+			var response handlers.StatusResponse
+			decodeJSON(t, rec, tt.status, &response)
+		})
+	}
+}
+
+func TestDeleteSongPreconditions(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch string
+		err     error
+		status  int
+		code    string
+	}{
+		{"deleted", `"3"`, nil, http.StatusOK, ""},
+		{"stale version", `"3"`, repoError(repository.ErrVersionMismatch, "song version is 4, not 3"), http.StatusPreconditionFailed, handlers.CodeVersionMismatch},
+		{"not found", `"3"`, repoError(repository.ErrNotFound, "song with ID 2 not found"), http.StatusNotFound, handlers.CodeNotFound},
+		{"invalid If-Match", `"three"`, nil, http.StatusBadRequest, handlers.CodeInvalidHeader},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubSongRepo{
+				deleteSong: func(songID int, expectedVersion int) error {
+					if songID != 2 || expectedVersion != 3 {
+						t.Errorf("DeleteSong(%d, %d), want (2, 3)", songID, expectedVersion)
+					}
+					return tt.err
+				},
+			}
+			req := newRequest(http.MethodDelete, "/songs/2", "")
+			req.Header.Set("If-Match", tt.ifMatch)
+			rec := serve(t, repo, nil, req)
+			if tt.code != "" {
+				assertProblem(t, rec, tt.status, tt.code)
+				return
+			}
+			var response handlers.StatusResponse
+			decodeJSON(t, rec, tt.status, &response)
+			if response.Status != "deleted" {
+				t.Errorf("status = %q, want deleted", response.Status)
+			}
+		})
+	}
+}
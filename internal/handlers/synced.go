@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"online-library/internal/logger"
+	"online-library/internal/lyrics"
+	"online-library/internal/repository"
+)
+
+// Форматы ответа GET /songs/{id}/lyrics
+const (
+	lyricsFormatJSON  = "json"       //куплеты, по умолчанию
+	lyricsFormatTimed = "json-timed" //строки с временными метками
+	lyricsFormatLRC   = "lrc"        //файл LRC
+)
+
+// maxLRCSize ограничение размера загружаемого файла LRC
+const maxLRCSize = 1 << 20
+
+// TimedLyricsResponse страница строк текста с временными метками
+type TimedLyricsResponse struct {
+	Song       string             `json:"song"`    //название песни
+	SongID     int                `json:"song_id"` //id песни
+	Tags       map[string]string  `json:"tags,omitempty"`
+	Lines      []lyrics.TimedLine `json:"lines"`
+	TotalLines int                `json:"total_lines"` //всего строк с метками
+	Page       int                `json:"page"`        //страница
+	PageSize   int                `json:"page_size"`   //размер страницы
+}
+
+// getSyncedLyrics отдает текст с временными метками: LRC целиком или страницу строк в JSON
+func (h *SongHandler) getSyncedLyrics(w http.ResponseWriter, r *http.Request, songID int, format string) {
+	logger.Log.Debugf("Fetching synced lyrics for song ID %d in format %s", songID, format)
+
+	songLyrics, err := h.Repo.GetSongLyricsByID(songID)
+	if err != nil {
+		writeRepoError(w, r, err, "Failed to retrieve song details")
+		return
+	}
+	if songLyrics.Synced == nil {
+		logger.Log.Infof("No synced lyrics found for song ID %d", songID)
+		WriteProblem(w, r, http.StatusNotFound, CodeLyricsNotFound, "Synced lyrics not found")
+		return
+	}
+
+	// Плееру нужен весь файл, поэтому LRC не делится на страницы
+	if format == lyricsFormatLRC {
+		w.Header().Set("ETag", lyricsETag(songLyrics, format))
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, songLyrics.Synced.LRC())
+		return
+	}
+
+	query := r.URL.Query()
+	page, size := parsePage(query, 50)
+
+	lines := songLyrics.Synced.Lines
+	start := (page - 1) * size
+	if start >= len(lines) {
+		logger.Log.Warn("Page out of range")
+		WriteProblem(w, r, http.StatusBadRequest, CodePageOutOfRange, "Page out of range")
+		return
+	}
+	end := min(start+size, len(lines))
+
+	w.Header().Set("ETag", lyricsETag(songLyrics, format))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TimedLyricsResponse{
+		Song:       songLyrics.Song,
+		SongID:     songID,
+		Tags:       songLyrics.Synced.Tags,
+		Lines:      lines[start:end],
+		TotalLines: len(lines),
+		Page:       page,
+		PageSize:   size,
+	})
+}
+
+// PutSyncedLyrics загружает текст песни с временными метками.
+// @Summary Put Synced Lyrics
+// @Description Загрузка текста песни в формате LRC ([mm:ss.xx]строка). Файл проверяется: ошибки возвращаются с номерами строк. С dry_run=true файл только проверяется. Если у песни нет обычного текста, он заполняется строками из LRC.
+// @Tags songs
+// @Accept plain
+// @Produce json
+// @Param id path int true "ID песни" example(1)
+// @Param lrc body string true "Текст в формате LRC"
+// @Param dry_run query bool false "Только проверить файл, не сохраняя"
+// @Param If-Match header string false "ETag песни, полученный ранее"
+// @Success 200 {object} lyrics.Synced "Разобранный текст с временными метками"
+// @Header 200 {string} ETag "Новая версия песни"
+// @Failure 400 {object} Problem "Ошибки в файле LRC"
+// @Failure 404 {object} Problem "Песня не найдена"
+// @Failure 412 {object} Problem "Версия песни не совпадает с If-Match"
+// @Failure 413 {object} Problem "Файл слишком большой"
+// @Failure 500 {object} Problem "Ошибка сервера"
+// @Router /songs/{id}/lyrics/synced [put]
+func (h *SongHandler) PutSyncedLyrics(w http.ResponseWriter, r *http.Request) {
+	logger.Log.Info("PutSyncedLyrics handler invoked")
+
+	songID, err := songIDFromPath(r)
+	if err != nil {
+		logger.Log.Warnf("Invalid song id: %s", r.PathValue("id"))
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidID, "Invalid song id")
+		return
+	}
+
+	expectedVersion, ok := h.ifMatchVersion(w, r, songID)
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxLRCSize))
+	if err != nil {
+		logger.Log.Warnf("Failed to read LRC for song ID %d: %v", songID, err)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			WriteProblem(w, r, http.StatusRequestEntityTooLarge, CodePayloadTooLarge, "LRC file is too large")
+			return
+		}
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidBody, "Failed to read request body")
+		return
+	}
+
+	dryRun, err := queryFlag(r.URL.Query(), "dry_run")
+	if err != nil {
+		logger.Log.Warnf("Invalid dry_run parameter: %v", err)
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	synced, err := lyrics.ParseLRC(string(body))
+	if err != nil {
+		logger.Log.Warnf("Invalid LRC for song ID %d: %v", songID, err)
+		WriteProblem(w, r, http.StatusBadRequest, CodeValidationFailed, "Invalid LRC: "+err.Error())
+		return
+	}
+
+	if !dryRun {
+		version, err := h.repoFor(r).SetSyncedLyrics(songID, synced, expectedVersion)
+		if err != nil {
+			writeRepoError(w, r, err, "Failed to store synced lyrics")
+			return
+		}
+		w.Header().Set("ETag", songETag(version))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(synced)
+}
+
+// DeleteSyncedLyrics удаляет временные метки текста песни.
+// @Summary Delete Synced Lyrics
+// @Description Удаление текста с временными метками. Обычный текст песни не меняется.
+// @Tags songs
+// @Param id path int true "ID песни" example(1)
+// @Param If-Match header string false "ETag песни, полученный ранее"
+// @Produce json
+// @Success 200 {object} StatusResponse "Метки удалены"
+// @Header 200 {string} ETag "Новая версия песни"
+// @Failure 400 {object} Problem "Ошибочный ID"
+// @Failure 404 {object} Problem "Песня не найдена или у нее нет текста с метками"
+// @Failure 412 {object} Problem "Версия песни не совпадает с If-Match"
+// @Failure 500 {object} Problem "Ошибка сервера"
+// @Router /songs/{id}/lyrics/synced [delete]
+func (h *SongHandler) DeleteSyncedLyrics(w http.ResponseWriter, r *http.Request) {
+	logger.Log.Info("DeleteSyncedLyrics handler invoked")
+
+	songID, err := songIDFromPath(r)
+	if err != nil {
+		logger.Log.Warnf("Invalid song id: %s", r.PathValue("id"))
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidID, "Invalid song id")
+		return
+	}
+
+	expectedVersion, ok := h.ifMatchVersion(w, r, songID)
+	if !ok {
+		return
+	}
+
+	version, err := h.repoFor(r).SetSyncedLyrics(songID, nil, expectedVersion)
+	if errors.Is(err, repository.ErrNoSyncedLyrics) {
+		logger.Log.Infof("No synced lyrics to delete for song ID %d", songID)
+		WriteProblem(w, r, http.StatusNotFound, CodeLyricsNotFound, "Synced lyrics not found")
+		return
+	}
+	if err != nil {
+		writeRepoError(w, r, err, "Failed to delete synced lyrics")
+		return
+	}
+
+	w.Header().Set("ETag", songETag(version))
+	writeStatus(w, songID, "deleted", "Synced lyrics deleted successfully")
+}
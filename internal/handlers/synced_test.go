@@ -0,0 +1,164 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"online-library/internal/handlers"
+	"online-library/internal/lyrics"
+	"online-library/internal/models"
+	"online-library/internal/repository"
+)
+
+const testLRC = "[00:01.00]Paranoia is in bloom\n[00:05.50]The PR transmissions will resume\n"
+
+func TestPutSyncedLyrics(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch string
+		body    string
+		err     error
+		status  int
+		code    string
+	}{
+		{"version mismatch", `"3"`, testLRC, repoError(repository.ErrVersionMismatch, "song with ID 1 has version 4, expected 3"), http.StatusPreconditionFailed, handlers.CodeVersionMismatch},
+		{"song not found", "", testLRC, repoError(repository.ErrNotFound, "song with ID 1 not found"), http.StatusNotFound, handlers.CodeNotFound},
+		{"invalid lrc", "", "[xx:01.00]broken", nil, http.StatusBadRequest, handlers.CodeValidationFailed},
+		{"invalid if-match", "3", testLRC, nil, http.StatusBadRequest, handlers.CodeInvalidHeader},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubSongRepo{
+				setSynced: func(songID int, synced *lyrics.Synced, expectedVersion int) (int, error) {
+					if tt.ifMatch == `"3"` && expectedVersion != 3 {
+						t.Errorf("expectedVersion = %d, want 3", expectedVersion)
+					}
+					return 0, tt.err
+				},
+			}
+			req := newRequest(http.MethodPut, "/songs/1/lyrics/synced", tt.body)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			assertProblem(t, serve(t, repo, nil, req), tt.status, tt.code)
+		})
+	}
+
+	t.Run("stored", func(t *testing.T) {
+		repo := &stubSongRepo{
+			setSynced: func(songID int, synced *lyrics.Synced, expectedVersion int) (int, error) {
+				return 5, nil
+			},
+		}
+		rec := serve(t, repo, nil, newRequest(http.MethodPut, "/songs/1/lyrics/synced", testLRC))
+		var synced lyrics.Synced
+		decodeJSON(t, rec, http.StatusOK, &synced)
+		if len(synced.Lines) != 2 {
+			t.Errorf("lines = %d, want 2", len(synced.Lines))
+		}
+		if etag := rec.Header().Get("ETag"); etag != `"5"` {
+			t.Errorf("ETag = %s, want \"5\"", etag)
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		// Репозиторий без setSynced: обращение к нему уронит тест
+		rec := serve(t, &stubSongRepo{}, nil, newRequest(http.MethodPut, "/songs/1/lyrics/synced?dry_run=true", testLRC))
+		if rec.Code != http.StatusOK || rec.Header().Get("ETag") != "" {
+			t.Errorf("status = %d, ETag = %q, want 200 without ETag", rec.Code, rec.Header().Get("ETag"))
+		}
+	})
+}
+
+func TestDeleteSyncedLyrics(t *testing.T) {
+	noSynced := &repository.Error{Kind: repository.ErrNotFound, Message: "song with ID 1 has no synced lyrics", Err: repository.ErrNoSyncedLyrics}
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"nothing to delete", noSynced, http.StatusNotFound, handlers.CodeLyricsNotFound},
+		{"song not found", repoError(repository.ErrNotFound, "song with ID 1 not found"), http.StatusNotFound, handlers.CodeNotFound},
+		{"version mismatch", repoError(repository.ErrVersionMismatch, "song with ID 1 has version 4, expected 3"), http.StatusPreconditionFailed, handlers.CodeVersionMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubSongRepo{
+				setSynced: func(songID int, synced *lyrics.Synced, expectedVersion int) (int, error) {
+					return 0, tt.err
+				},
+			}
+			assertProblem(t, serve(t, repo, nil, newRequest(http.MethodDelete, "/songs/1/lyrics/synced", "")), tt.status, tt.code)
+		})
+	}
+
+	t.Run("deleted", func(t *testing.T) {
+		repo := &stubSongRepo{
+			setSynced: func(songID int, synced *lyrics.Synced, expectedVersion int) (int, error) {
+				if synced != nil {
+					t.Error("synced lyrics passed to delete")
+				}
+				return 6, nil
+			},
+		}
+		rec := serve(t, repo, nil, newRequest(http.MethodDelete, "/songs/1/lyrics/synced", ""))
+		var response handlers.StatusResponse
+		decodeJSON(t, rec, http.StatusOK, &response)
+		if etag := rec.Header().Get("ETag"); etag != `"6"` {
+			t.Errorf("ETag = %s, want \"6\"", etag)
+		}
+	})
+}
+
+func TestSyncedLyricsETags(t *testing.T) {
+	// Куплеты в JSON, файл LRC и строки с метками - разные представления одной версии песни
+	repo := lyricsRepo(0)
+	repo.getLyrics = func(songID int) (*models.SongLyrics, error) {
+		synced, err := lyrics.ParseLRC(testLRC)
+		if err != nil {
+			t.Fatalf("ParseLRC() error = %v", err)
+		}
+		return &models.SongLyrics{SongID: songID, Song: "Song", Version: 5, Synced: synced}, nil
+	}
+
+	etags := make(map[string]string)
+	for _, target := range []string{"/songs/1/lyrics", "/songs/1/lyrics?format=lrc", "/songs/1/lyrics?format=json-timed"} {
+		rec := serve(t, repo, nil, newRequest(http.MethodGet, target, ""))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want 200: %s", target, rec.Code, rec.Body)
+		}
+		etag := rec.Header().Get("ETag")
+		if other, ok := etags[etag]; ok {
+			t.Errorf("%s and %s share ETag %s", other, target, etag)
+		}
+		etags[etag] = target
+	}
+	if _, ok := etags[`"5-lrc"`]; !ok {
+		t.Errorf("ETags = %v, want \"5-lrc\" for the LRC file", etags)
+	}
+	// Страница за пределами текста - ошибка, а не представление, ETag у нее нет
+	rec := serve(t, repo, nil, newRequest(http.MethodGet, "/songs/1/lyrics?format=json-timed&page=99", ""))
+	assertProblem(t, rec, http.StatusBadRequest, handlers.CodePageOutOfRange)
+	if etag := rec.Header().Get("ETag"); etag != "" {
+		t.Errorf("ETag = %s on error response", etag)
+	}
+}
+
+func TestPutSyncedLyricsDryRun(t *testing.T) {
+	for _, value := range []string{"true", "1", "TRUE", "t"} {
+		t.Run(value, func(t *testing.T) {
+			// Проверка файла без записи: репозиторий не должен вызываться
+			rec := serve(t, nil, nil, newRequest(http.MethodPut, "/songs/1/lyrics/synced?dry_run="+value, testLRC))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+			if etag := rec.Header().Get("ETag"); etag != "" {
+				t.Errorf("ETag = %s, want none for dry run", etag)
+			}
+		})
+	}
+
+	rec := serve(t, nil, nil, newRequest(http.MethodPut, "/songs/1/lyrics/synced?dry_run=yes", testLRC))
+	assertProblem(t, rec, http.StatusBadRequest, handlers.CodeInvalidParameter)
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"online-library/internal/logger"
+	"online-library/internal/models"
+)
+
+// langTagPattern тег языка BCP 47 без расширений: en, pt-BR, zh-Hant-TW
+var langTagPattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+// TranslationRequest тело запроса на добавление или изменение перевода
+type TranslationRequest struct {
+	Lyrics string `json:"lyrics"`
+}
+
+// normalizeLang проверяет тег языка и приводит его к нижнему регистру
+func normalizeLang(tag string) (string, error) {
+	tag = strings.TrimSpace(tag)
+	if len(tag) > 35 || !langTagPattern.MatchString(tag) {
+		return "", errors.New("invalid language tag " + strconv.Quote(tag))
+	}
+	return strings.ToLower(tag), nil
+}
+
+// preferredLanguages возвращает языки, на которых клиент хочет получить текст, в порядке предпочтения.
+// Параметр lang (теги через запятую) важнее заголовка Accept-Language. Для каждого тега
+// следом добавляется его основной язык: en-us, затем en. Пустой список - оригинальный текст.
+func preferredLanguages(r *http.Request) ([]string, error) {
+	var tags []string
+	if value := r.URL.Query().Get("lang"); value != "" {
+		for _, tag := range strings.Split(value, ",") {
+			lang, err := normalizeLang(tag)
+			if err != nil {
+				return nil, err
+			}
+			tags = append(tags, lang)
+		}
+	} else {
+		tags = acceptLanguages(r.Header.Get("Accept-Language"))
+	}
+
+	var langs []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		for candidate := tag; candidate != ""; {
+			if !seen[candidate] {
+				seen[candidate] = true
+				langs = append(langs, candidate)
+			}
+			cut := strings.LastIndex(candidate, "-")
+			if cut < 0 {
+				break
+			}
+			candidate = candidate[:cut]
+		}
+	}
+	return langs, nil
+}
+
+// acceptLanguages разбирает Accept-Language и возвращает теги по убыванию веса.
+// Ошибочные элементы, "*" и языки с q=0 пропускаются.
+func acceptLanguages(header string) []string {
+	type weighted struct {
+		lang string
+		q    float64
+	}
+	var items []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang, err := normalizeLang(tag)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			items = append(items, weighted{lang, q})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].q > items[j].q })
+
+	langs := make([]string, len(items))
+	for i, item := range items {
+		langs[i] = item.lang
+	}
+	return langs
+}
+
+// setLanguageHeaders сообщает язык отданного текста; ответ зависит от Accept-Language
+func setLanguageHeaders(w http.ResponseWriter, lang string) {
+	w.Header().Add("Vary", "Accept-Language")
+	if lang != "" {
+		w.Header().Set("Content-Language", lang)
+	}
+}
+
+// GetTranslations возвращает переводы текста песни.
+// @Summary Get Lyrics Translations
+// @Description Все переводы текста песни. Оригинальный текст в список не входит.
+// @Tags songs
+// @Produce json
+// @Param id path int true "ID песни" example(1)
+// @Success 200 {array} models.LyricsTranslation "Переводы текста"
+// @Failure 400 {object} Problem "Ошибочный ID"
+// @Failure 404 {object} Problem "Песня не найдена"
+// @Failure 500 {object} Problem "Ошибка сервера"
+// @Router /songs/{id}/translations [get]
+func (h *SongHandler) GetTranslations(w http.ResponseWriter, r *http.Request) {
+	logger.Log.Info("GetTranslations handler invoked")
+
+	songID, err := songIDFromPath(r)
+	if err != nil {
+		logger.Log.Warnf("Invalid song id: %s", r.PathValue("id"))
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidID, "Invalid song id")
+		return
+	}
+
+	translations, err := h.Repo.GetTranslations(songID)
+	if err != nil {
+		writeRepoError(w, r, err, "Failed to fetch translations")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(translations)
+}
+
+// PutTranslation добавляет или заменяет перевод текста песни.
+// @Summary Put Lyrics Translation
+// @Description Добавление перевода текста песни на язык lang или замена существующего. Язык - тег BCP 47 (en, pt-BR), хранится в нижнем регистре.
+// @Tags songs
+// @Accept json
+// @Produce json
+// @Param id path int true "ID песни" example(1)
+// @Param lang path string true "Язык перевода" example(en)
+// @Param translation body TranslationRequest true "Текст перевода"
+// @Success 200 {object} models.LyricsTranslation "Перевод изменен"
+// @Success 201 {object} models.LyricsTranslation "Перевод добавлен"
+// @Failure 400 {object} Problem "Ошибочные параметры"
+// @Failure 404 {object} Problem "Песня не найдена"
+// @Failure 500 {object} Problem "Ошибка сервера"
+// @Router /songs/{id}/translations/{lang} [put]
+func (h *SongHandler) PutTranslation(w http.ResponseWriter, r *http.Request) {
+	logger.Log.Info("PutTranslation handler invoked")
+
+	songID, err := songIDFromPath(r)
+	if err != nil {
+		logger.Log.Warnf("Invalid song id: %s", r.PathValue("id"))
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidID, "Invalid song id")
+		return
+	}
+	lang, err := normalizeLang(r.PathValue("lang"))
+	if err != nil {
+		logger.Log.Warnf("Invalid language: %v", err)
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	var request TranslationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		logger.Log.Warnf("Invalid JSON body: %v", err)
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidBody, "Invalid JSON body")
+		return
+	}
+	if strings.TrimSpace(request.Lyrics) == "" {
+		logger.Log.Warn("Empty translation lyrics")
+		WriteProblem(w, r, http.StatusBadRequest, CodeValidationFailed, "lyrics is required")
+		return
+	}
+
+	translation, created, err := h.Repo.PutTranslation(songID, lang, request.Lyrics)
+	if err != nil {
+		writeRepoError(w, r, err, "Failed to store translation")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if created {
+		w.Header().Set("Location", "/songs/"+strconv.Itoa(songID)+"/lyrics?lang="+lang)
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(translation)
+}
+
+// DeleteTranslation удаляет перевод текста песни.
+// @Summary Delete Lyrics Translation
+// @Description Удаление перевода текста песни на язык lang.
+// @Tags songs
+// @Param id path int true "ID песни" example(1)
+// @Param lang path string true "Язык перевода" example(en)
+// @Produce json
+// @Success 200 {object} StatusResponse "Перевод удален"
+// @Failure 400 {object} Problem "Ошибочные параметры"
+// @Failure 404 {object} Problem "Перевод не найден"
+// @Failure 500 {object} Problem "Ошибка сервера"
+// @Router /songs/{id}/translations/{lang} [delete]
+func (h *SongHandler) DeleteTranslation(w http.ResponseWriter, r *http.Request) {
+	logger.Log.Info("DeleteTranslation handler invoked")
+
+	songID, err := songIDFromPath(r)
+	if err != nil {
+		logger.Log.Warnf("Invalid song id: %s", r.PathValue("id"))
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidID, "Invalid song id")
+		return
+	}
+	lang, err := normalizeLang(r.PathValue("lang"))
+	if err != nil {
+		logger.Log.Warnf("Invalid language: %v", err)
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	if err := h.Repo.DeleteTranslation(songID, lang); err != nil {
+		writeRepoError(w, r, err, "Failed to delete translation")
+		return
+	}
+	writeStatus(w, songID, "deleted", "Translation "+lang+" deleted successfully")
+}
+
+// lyricsIn загружает текст песни на языке, который предпочитает клиент; при ошибке ответ уже отправлен
+func (h *SongHandler) lyricsIn(w http.ResponseWriter, r *http.Request, songID int) (*models.SongLyrics, bool) {
+	langs, err := preferredLanguages(r)
+	if err != nil {
+		logger.Log.Warnf("Invalid lang parameter: %v", err)
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return nil, false
+	}
+
+	songLyrics, err := h.Repo.GetSongLyricsIn(songID, langs)
+	if err != nil {
+		writeRepoError(w, r, err, "Failed to retrieve song details")
+		return nil, false
+	}
+	setLanguageHeaders(w, songLyrics.Lang)
+	return songLyrics, true
+}
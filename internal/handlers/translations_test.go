@@ -0,0 +1,144 @@
+package handlers_test
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"online-library/internal/handlers"
+	"online-library/internal/models"
+	"online-library/internal/repository"
+)
+
+func TestPutTranslation(t *testing.T) {
+	tests := []struct {
+		name     string
+		created  bool
+		status   int
+		location string
+	}{
+		{"created", true, http.StatusCreated, "/songs/1/lyrics?lang=pt-br"},
+		{"replaced", false, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubSongRepo{
+				putTranslation: func(songID int, lang string, text string) (*models.LyricsTranslation, bool, error) {
+					// Тег языка хранится в нижнем регистре
+					if songID != 1 || lang != "pt-br" {
+						t.Errorf("PutTranslation(%d, %q), want (1, \"pt-br\")", songID, lang)
+					}
+					return &models.LyricsTranslation{Lang: lang, Lyrics: text, Version: 8}, tt.created, nil
+				},
+			}
+			rec := serve(t, repo, nil, newRequest(http.MethodPut, "/songs/1/translations/pt-BR", `{"lyrics": "Olá"}`))
+
+			var translation models.LyricsTranslation
+			decodeJSON(t, rec, tt.status, &translation)
+			if translation.Version != 8 {
+				t.Errorf("version = %d, want 8", translation.Version)
+			}
+			if location := rec.Header().Get("Location"); location != tt.location {
+				t.Errorf("Location = %q, want %q", location, tt.location)
+			}
+		})
+	}
+}
+
+func TestPutTranslationErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		body   string
+		err    error
+		status int
+		code   string
+	}{
+		{"invalid language", "/songs/1/translations/english!", `{"lyrics": "Hi"}`, nil, http.StatusBadRequest, handlers.CodeInvalidParameter},
+		{"invalid body", "/songs/1/translations/en", `{"lyrics":`, nil, http.StatusBadRequest, handlers.CodeInvalidBody},
+		{"empty lyrics", "/songs/1/translations/en", `{"lyrics": "  "}`, nil, http.StatusBadRequest, handlers.CodeValidationFailed},
+		{"song not found", "/songs/1/translations/en", `{"lyrics": "Hi"}`, repoError(repository.ErrNotFound, "song with ID 1 not found"), http.StatusNotFound, handlers.CodeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubSongRepo{
+				putTranslation: func(songID int, lang string, text string) (*models.LyricsTranslation, bool, error) {
+					return nil, false, tt.err
+				},
+			}
+			rec := serve(t, repo, nil, newRequest(http.MethodPut, tt.target, tt.body))
+			assertProblem(t, rec, tt.status, tt.code)
+		})
+	}
+}
+
+func TestDeleteTranslationNotFound(t *testing.T) {
+	repo := &stubSongRepo{
+		delTranslation: func(songID int, lang string) error {
+			return repoError(repository.ErrNotFound, "translation en of song with ID 1 not found")
+		},
+	}
+	rec := serve(t, repo, nil, newRequest(http.MethodDelete, "/songs/1/translations/EN", ""))
+	assertProblem(t, rec, http.StatusNotFound, handlers.CodeNotFound)
+}
+
+func TestGetTranslationsSongNotFound(t *testing.T) {
+	repo := &stubSongRepo{
+		getTranslations: func(songID int) ([]models.LyricsTranslation, error) {
+			return nil, repoError(repository.ErrNotFound, "song with ID 1 not found")
+		},
+	}
+	rec := serve(t, repo, nil, newRequest(http.MethodGet, "/songs/1/translations", ""))
+	assertProblem(t, rec, http.StatusNotFound, handlers.CodeNotFound)
+}
+
+func TestLyricsLanguageSelection(t *testing.T) {
+	tests := []struct {
+		name           string
+		target         string
+		acceptLanguage string
+		langs          []string
+	}{
+		{"original", "/songs/1/lyrics", "", nil},
+		{"accept-language by weight", "/songs/1/lyrics", "de;q=0.5, pt-BR, fr;q=0", []string{"pt-br", "pt", "de"}},
+		{"lang parameter wins", "/songs/1/lyrics?lang=en-GB,es", "de", []string{"en-gb", "en", "es"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			repo := lyricsRepo(1)
+			getLyricsIn := repo.getLyricsIn
+			repo.getLyricsIn = func(songID int, langs []string) (*models.SongLyrics, error) {
+				got = langs
+				return getLyricsIn(songID, langs)
+			}
+			req := newRequest(http.MethodGet, tt.target, "")
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			rec := serve(t, repo, nil, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+			if !reflect.DeepEqual(got, tt.langs) {
+				t.Errorf("langs = %v, want %v", got, tt.langs)
+			}
+			if vary := strings.Join(rec.Header().Values("Vary"), ", "); !strings.Contains(vary, "Accept-Language") {
+				t.Errorf("Vary = %q, want Accept-Language", vary)
+			}
+			wantLanguage := ""
+			if len(tt.langs) > 0 {
+				wantLanguage = tt.langs[0]
+			}
+			if language := rec.Header().Get("Content-Language"); language != wantLanguage {
+				t.Errorf("Content-Language = %q, want %q", language, wantLanguage)
+			}
+		})
+	}
+}
+
+func TestLyricsInvalidLang(t *testing.T) {
+	rec := serve(t, nil, nil, newRequest(http.MethodGet, "/songs/1/lyrics?lang=en,!!", ""))
+	assertProblem(t, rec, http.StatusBadRequest, handlers.CodeInvalidParameter)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"online-library/internal/logger"
+	"online-library/internal/models"
+)
+
+// GetTrash возвращает песни из корзины.
+// @Summary Get Trash
+// @Description Список удаленных песен, последние удаленные идут первыми. Песни хранятся в корзине ограниченное время, после чего удаляются окончательно.
+// @Tags songs
+// @Produce json
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество элементов на странице" default(10) maximum(100)
+// @Success 200 {object} SongListResponse "Страница корзины"
+// @Failure 500 {object} Problem "Ошибка сервера"
+// @Router /songs/trash [get]
+func (h *SongHandler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	logger.Log.Info("GetTrash handler invoked")
+
+	query := r.URL.Query()
+	page, limit := parsePage(query, 10)
+
+	list, err := h.Repo.GetDeletedSongs(page, limit)
+	if err != nil {
+		writeRepoError(w, r, err, "Failed to fetch trash")
+		return
+	}
+
+	response := SongListResponse{
+		Items: list.Songs,
+		Total: list.Total,
+		Page:  page,
+		Limit: limit,
+		Links: pageLinks(r, page, limit, list.Total, "", ""),
+	}
+	if response.Items == nil {
+		response.Items = []models.Song{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// RestoreSong возвращает песню из корзины.
+// @Summary Restore Song
+// @Description Восстановление удаленной песни из корзины.
+// @Tags songs
+// @Produce json
+// @Param id path int true "ID песни" example(1)
+// @Success 200 {object} models.Song "Восстановленная песня"
+// @Header 200 {string} ETag "Версия песни"
+// @Failure 400 {object} Problem "Ошибочный ID"
+// @Failure 404 {object} Problem "Песни нет в корзине"
+// @Failure 409 {object} Problem "Песня не удалена"
+// @Failure 500 {object} Problem "Ошибка сервера"
+// @Router /songs/{id}/restore [post]
+func (h *SongHandler) RestoreSong(w http.ResponseWriter, r *http.Request) {
+	logger.Log.Info("RestoreSong handler invoked")
+
+	songID, err := songIDFromPath(r)
+	if err != nil {
+		logger.Log.Warnf("Invalid song id: %s", r.PathValue("id"))
+		WriteProblem(w, r, http.StatusBadRequest, CodeInvalidID, "Invalid song id")
+		return
+	}
+
+	song, err := h.repoFor(r).RestoreSong(songID)
+	if err != nil {
+		writeRepoError(w, r, err, "Failed to restore song")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", songETag(song.Version))
+	json.NewEncoder(w).Encode(song)
+}
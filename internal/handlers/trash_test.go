@@ -0,0 +1,89 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"online-library/internal/handlers"
+	"online-library/internal/models"
+	"online-library/internal/repository"
+)
+
+func TestGetTrash(t *testing.T) {
+	repo := &stubSongRepo{
+		getDeletedSongs: func(page int, limit int) (*repository.SongList, error) {
+			if page != 2 || limit != 1 {
+				t.Errorf("GetDeletedSongs(%d, %d), want (2, 1)", page, limit)
+			}
+			return &repository.SongList{Songs: []models.Song{{SongID: 7, Song: "Uprising"}}, Total: 3}, nil
+		},
+	}
+
+	rec := serve(t, repo, nil, newRequest(http.MethodGet, "/songs/trash?page=2&limit=1", ""))
+	var response handlers.SongListResponse
+	decodeJSON(t, rec, http.StatusOK, &response)
+	if len(response.Items) != 1 || response.Items[0].SongID != 7 {
+		t.Errorf("items = %+v, want song 7", response.Items)
+	}
+	if response.Total != 3 || response.Links.Next == "" || response.Links.Prev == "" {
+		t.Errorf("total = %d, links = %+v, want 3 with next and prev", response.Total, response.Links)
+	}
+}
+
+func TestGetTrashEmpty(t *testing.T) {
+	repo := &stubSongRepo{
+		getDeletedSongs: func(page int, limit int) (*repository.SongList, error) {
+			return &repository.SongList{}, nil
+		},
+	}
+
+	rec := serve(t, repo, nil, newRequest(http.MethodGet, "/songs/trash", ""))
+	var response map[string]interface{}
+	decodeJSON(t, rec, http.StatusOK, &response)
+	if items, ok := response["items"].([]interface{}); !ok || len(items) != 0 {
+		t.Errorf("items = %v, want empty array", response["items"])
+	}
+}
+
+func TestRestoreSong(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"not in trash", repoError(repository.ErrConflict, "song with ID 1 is not in trash"), http.StatusConflict, handlers.CodeConflict},
+		{"purged", repoError(repository.ErrNotFound, "song with ID 1 not found in trash"), http.StatusNotFound, handlers.CodeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubSongRepo{
+				restoreSong: func(songID int) (*models.Song, error) { return nil, tt.err },
+			}
+			rec := serve(t, repo, nil, newRequest(http.MethodPost, "/songs/1/restore", ""))
+			assertProblem(t, rec, tt.status, tt.code)
+		})
+	}
+
+	t.Run("restored", func(t *testing.T) {
+		repo := &stubSongRepo{
+			restoreSong: func(songID int) (*models.Song, error) {
+				return &models.Song{SongID: songID, Song: "Uprising", Version: 4}, nil
+			},
+		}
+		rec := serve(t, repo, nil, newRequest(http.MethodPost, "/songs/5/restore", ""))
+		var song models.Song
+		decodeJSON(t, rec, http.StatusOK, &song)
+		if song.SongID != 5 {
+			t.Errorf("ID = %d, want 5", song.SongID)
+		}
+		if etag := rec.Header().Get("ETag"); etag != `"4"` {
+			t.Errorf("ETag = %s, want \"4\"", etag)
+		}
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		rec := serve(t, nil, nil, newRequest(http.MethodPost, "/songs/abc/restore", ""))
+		assertProblem(t, rec, http.StatusBadRequest, handlers.CodeInvalidID)
+	})
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"unicode/utf8"
+
+	"online-library/internal/models"
+	"online-library/internal/repository"
+)
+
+// Ограничения длины полей песни, совпадают с колонками таблиц
+const (
+	maxNameLength  = 255
+	maxGenreLength = 100
+)
+
+// validateSong проверяет данные песни, переданные клиентом, и сообщает обо всех ошибках сразу
+func validateSong(song models.Song) error {
+	var errs []error
+	if song.Group == "" {
+		errs = append(errs, errors.New("group is required"))
+	}
+	if song.Song == "" {
+		errs = append(errs, errors.New("song is required"))
+	}
+	if utf8.RuneCountInString(song.Group) > maxNameLength {
+		errs = append(errs, fmt.Errorf("group must not exceed %d characters", maxNameLength))
+	}
+	if utf8.RuneCountInString(song.Song) > maxNameLength {
+		errs = append(errs, fmt.Errorf("song must not exceed %d characters", maxNameLength))
+	}
+	if utf8.RuneCountInString(song.Genre) > maxGenreLength {
+		errs = append(errs, fmt.Errorf("genre must not exceed %d characters", maxGenreLength))
+	}
+	if song.ReleaseDate != "" {
+		if _, err := repository.ParseReleaseDate(song.ReleaseDate); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if song.Link != "" {
+		if u, err := url.Parse(song.Link); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("link %q must be an absolute http or https URL", song.Link))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// fillMissing дополняет пустые поля песни данными внешнего API, не трогая заполненные клиентом
+func fillMissing(song *models.Song, detail *models.SongDetail) {
+	if song.ReleaseDate == "" {
+		song.ReleaseDate = detail.ReleaseDate
+	}
+	if song.Lyrics == "" {
+		song.Lyrics = detail.Text
+	}
+	if song.Link == "" {
+		song.Link = detail.Link
+	}
+}
+
+// songComplete сообщает, заполнены ли все поля, которые может дать внешний API
+func songComplete(song models.Song) bool {
+	return song.ReleaseDate != "" && song.Lyrics != "" && song.Link != ""
+}
+
+// queryFlag разбирает логический параметр запроса (true, false, 1, 0 и т.п.); без параметра - false
+func queryFlag(query url.Values, name string) (bool, error) {
+	value := query.Get(name)
+	if value == "" {
+		return false, nil
+	}
+	flag, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s parameter %q, expected true or false", name, value)
+	}
+	return flag, nil
+}
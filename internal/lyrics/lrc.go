@@ -0,0 +1,167 @@
+package lyrics
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Synced текст песни с временными метками строк (формат LRC).
+// @Description Строки текста со временем начала для караоке.
+type Synced struct {
+	Tags  map[string]string `json:"tags,omitempty"` //теги LRC: ar - исполнитель, ti - название, al - альбом и т. д.
+	Lines []TimedLine       `json:"lines"`          //строки по возрастанию времени
+}
+
+// TimedLine строка текста со временем начала
+type TimedLine struct {
+	TimeMs int64  `json:"time_ms"` //время от начала песни в миллисекундах
+	Time   string `json:"time"`    //то же время в виде mm:ss.xx
+	Text   string `json:"text"`    //пустая строка означает паузу или конец куплета
+}
+
+var (
+	// lrcTimestamp метка времени [mm:ss], [mm:ss.xx] или [mm:ss.xxx]
+	lrcTimestamp = regexp.MustCompile(`^\[(\d{1,3}):(\d{2})(?:[.:](\d{1,3}))?\]`)
+	// lrcTag тег [ar:Queen]
+	lrcTag = regexp.MustCompile(`^\[([A-Za-z#]+):(.*)\]$`)
+	// lrcWordTimestamp метка времени слова в расширенном LRC: <mm:ss.xx>
+	lrcWordTimestamp = regexp.MustCompile(`<\d{1,3}:\d{2}(?:[.:]\d{1,3})?>`)
+)
+
+// ParseLRC разбирает и проверяет текст в формате LRC.
+// Строка может иметь несколько меток времени, метки слов расширенного LRC отбрасываются,
+// тег offset применяется ко времени строк. Ошибки содержат номера строк LRC.
+func ParseLRC(text string) (*Synced, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.TrimPrefix(text, "\ufeff")
+
+	synced := &Synced{Tags: make(map[string]string)}
+	var (
+		errs   []error
+		offset int64
+	)
+	for i, raw := range strings.Split(text, "\n") {
+		number := i + 1
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+
+		if lrcTimestamp.MatchString(line) {
+			var times []int64
+			for {
+				match := lrcTimestamp.FindStringSubmatch(line)
+				if match == nil {
+					break
+				}
+				ms, err := timestampMs(match)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("line %d: %w", number, err))
+				}
+				times = append(times, ms)
+				line = line[len(match[0]):]
+			}
+			line = strings.TrimSpace(lrcWordTimestamp.ReplaceAllString(line, ""))
+			for _, ms := range times {
+				synced.Lines = append(synced.Lines, TimedLine{TimeMs: ms, Text: line})
+			}
+			continue
+		}
+
+		if match := lrcTag.FindStringSubmatch(line); match != nil {
+			name, value := strings.ToLower(match[1]), strings.TrimSpace(match[2])
+			if name == "offset" {
+				parsed, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("line %d: invalid offset %q", number, value))
+					continue
+				}
+				offset = parsed
+				continue
+			}
+			synced.Tags[name] = value
+			continue
+		}
+
+		errs = append(errs, fmt.Errorf("line %d: missing timestamp", number))
+	}
+
+	if len(errs) == 0 && len(synced.Lines) == 0 {
+		errs = append(errs, errors.New("no timed lines"))
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	// Положительный offset сдвигает строки раньше
+	for i := range synced.Lines {
+		synced.Lines[i].TimeMs = max(synced.Lines[i].TimeMs-offset, 0)
+		synced.Lines[i].Time = formatTimestamp(synced.Lines[i].TimeMs)
+	}
+	sort.SliceStable(synced.Lines, func(i, j int) bool {
+		return synced.Lines[i].TimeMs < synced.Lines[j].TimeMs
+	})
+	if len(synced.Tags) == 0 {
+		synced.Tags = nil
+	}
+	return synced, nil
+}
+
+// LRC возвращает текст в формате LRC: сначала теги, затем строки по времени
+func (s *Synced) LRC() string {
+	var out strings.Builder
+
+	names := make([]string, 0, len(s.Tags))
+	for name := range s.Tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&out, "[%s:%s]\n", name, s.Tags[name])
+	}
+
+	for _, line := range s.Lines {
+		fmt.Fprintf(&out, "[%s]%s\n", formatTimestamp(line.TimeMs), line.Text)
+	}
+	return out.String()
+}
+
+// PlainText возвращает текст без меток времени; пустые строки LRC разделяют куплеты
+func (s *Synced) PlainText() string {
+	var lines []string
+	for _, line := range s.Lines {
+		if line.Text == "" && (len(lines) == 0 || lines[len(lines)-1] == "") {
+			continue
+		}
+		lines = append(lines, line.Text)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// timestampMs переводит метку времени в миллисекунды
+func timestampMs(match []string) (int64, error) {
+	minutes, _ := strconv.ParseInt(match[1], 10, 64)
+	seconds, _ := strconv.ParseInt(match[2], 10, 64)
+	if seconds >= 60 {
+		return 0, fmt.Errorf("invalid timestamp %s: seconds must be less than 60", match[0])
+	}
+
+	var ms int64
+	if fraction := match[3]; fraction != "" {
+		ms, _ = strconv.ParseInt(fraction, 10, 64)
+		// Дробная часть может быть в десятых, сотых или тысячных долях секунды
+		for i := len(fraction); i < 3; i++ {
+			ms *= 10
+		}
+	}
+	return (minutes*60+seconds)*1000 + ms, nil
+}
+
+// formatTimestamp форматирует время как mm:ss.xx
+func formatTimestamp(ms int64) string {
+	return fmt.Sprintf("%02d:%02d.%02d", ms/60000, ms/1000%60, ms%1000/10)
+}
@@ -0,0 +1,57 @@
+package lyrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLRC(t *testing.T) {
+	text := "\ufeff[ar:Queen]\r\n[ti:Bohemian Rhapsody]\r\n[offset:500]\r\n" +
+		"[00:10.5]Is this the real life?\r\n" +
+		"[00:15.20][01:15.20]Is this just <00:16.00>fantasy?\r\n" +
+		"[00:20.00]\r\n"
+	got, err := ParseLRC(text)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got.Tags["ar"] != "Queen" || got.Tags["ti"] != "Bohemian Rhapsody" || len(got.Tags) != 2 {
+		t.Errorf("unexpected tags %v", got.Tags)
+	}
+	want := []TimedLine{
+		{10000, "00:10.00", "Is this the real life?"},
+		{14700, "00:14.70", "Is this just fantasy?"},
+		{19500, "00:19.50", ""},
+		{74700, "01:14.70", "Is this just fantasy?"},
+	}
+	if len(got.Lines) != len(want) {
+		t.Fatalf("expected %d lines, got %+v", len(want), got.Lines)
+	}
+	for i, line := range want {
+		if got.Lines[i] != line {
+			t.Errorf("line %d: got %+v, want %+v", i, got.Lines[i], line)
+		}
+	}
+
+	if plain := got.PlainText(); plain != "Is this the real life?\nIs this just fantasy?\n\nIs this just fantasy?" {
+		t.Errorf("unexpected plain text %q", plain)
+	}
+	if lrc := got.LRC(); !strings.HasPrefix(lrc, "[ar:Queen]\n[ti:Bohemian Rhapsody]\n[00:10.00]Is this the real life?\n") {
+		t.Errorf("unexpected LRC %q", lrc)
+	}
+}
+
+func TestParseLRCErrors(t *testing.T) {
+	tests := map[string]string{
+		"[00:10.00]ok\nno timestamp":  "line 2: missing timestamp",
+		"[00:75.00]bad seconds":       "line 1: invalid timestamp [00:75.00]",
+		"[offset:soon]\n[00:01.00]ok": "line 1: invalid offset",
+		"[ar:Queen]":                  "no timed lines",
+	}
+	for text, want := range tests {
+		_, err := ParseLRC(text)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseLRC(%q) error = %v, want %q", text, err, want)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+// Package lyrics разбирает текст песни на куплеты и строки.
+package lyrics
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Виды частей песни
+const (
+	KindVerse     = "verse"
+	KindChorus    = "chorus"
+	KindPreChorus = "pre-chorus"
+	KindBridge    = "bridge"
+	KindIntro     = "intro"
+	KindOutro     = "outro"
+	KindOther     = "other" //заголовок в квадратных скобках, не похожий на известные виды
+)
+
+// Lyrics текст песни, разобранный на части.
+// @Description Текст песни по частям (куплет, припев, бридж) с нумерацией строк.
+type Lyrics struct {
+	Sections  []Section `json:"sections"`
+	LineCount int       `json:"line_count"` //всего строк текста без заголовков и пустых строк
+}
+
+// Section часть песни: куплет, припев и т. д.
+type Section struct {
+	Index  int    `json:"index"`            //номер части в песне, с 1
+	Kind   string `json:"kind"`             //verse, chorus, pre-chorus, bridge, intro, outro, other
+	Number int    `json:"number,omitempty"` //номер куплета, у остальных частей - если указан в заголовке
+	Label  string `json:"label,omitempty"`  //заголовок части как в тексте, например [Chorus]
+	Lines  []Line `json:"lines"`
+
+	labeled bool //вид части указан в заголовке, а не угадан
+}
+
+// Line строка текста песни
+type Line struct {
+	Number  int    `json:"number"`  //номер строки в песне, с 1
+	Section int    `json:"section"` //номер части, в которую входит строка
+	Text    string `json:"text"`
+}
+
+// kindWords сопоставляет слова заголовков видам частей песни
+var kindWords = map[string]string{
+	"verse":      KindVerse,
+	"куплет":     KindVerse,
+	"chorus":     KindChorus,
+	"refrain":    KindChorus,
+	"hook":       KindChorus,
+	"припев":     KindChorus,
+	"pre-chorus": KindPreChorus,
+	"prechorus":  KindPreChorus,
+	"pre chorus": KindPreChorus,
+	"предприпев": KindPreChorus,
+	"bridge":     KindBridge,
+	"бридж":      KindBridge,
+	"intro":      KindIntro,
+	"вступление": KindIntro,
+	"outro":      KindOutro,
+	"концовка":   KindOutro,
+	"кода":       KindOutro,
+}
+
+// headerPattern заголовок части: [Verse 2], (Chorus x2), Bridge:, Куплет 1
+var headerPattern = regexp.MustCompile(`^(?i)[\[(]?\s*([\p{L}][\p{L} -]*?)\s*(\d+)?\s*(?:[x×]\s*\d+)?\s*[\])]?\s*:?$`)
+
+// Parse разбирает текст песни. Части разделяются пустыми строками или заголовками;
+// переводы строк \r\n, лишние пустые строки и пробелы по краям строк не влияют на результат.
+// Части без заголовка считаются куплетами, а повторяющиеся части - припевом.
+func Parse(text string) *Lyrics {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	var (
+		sections []Section
+		current  *Section
+	)
+	flush := func() {
+		if current != nil && len(current.Lines) > 0 {
+			sections = append(sections, *current)
+			current = nil
+		}
+	}
+
+	for _, raw := range strings.Split(text, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			// Заголовок, отделенный от своих строк пустой строкой, относится к следующим строкам
+			flush()
+			continue
+		}
+		if kind, number, ok := parseHeader(line); ok {
+			flush()
+			current = &Section{Kind: kind, Number: number, Label: line, labeled: true}
+			continue
+		}
+		if current == nil {
+			current = &Section{}
+		}
+		current.Lines = append(current.Lines, Line{Text: line})
+	}
+	flush()
+
+	classify(sections)
+
+	result := &Lyrics{Sections: sections}
+	for i := range sections {
+		sections[i].Index = i + 1
+		for j := range sections[i].Lines {
+			result.LineCount++
+			sections[i].Lines[j].Number = result.LineCount
+			sections[i].Lines[j].Section = i + 1
+		}
+	}
+	if result.Sections == nil {
+		result.Sections = []Section{}
+	}
+	return result
+}
+
+// Section возвращает часть песни по номеру, начиная с 1
+func (l *Lyrics) Section(index int) (Section, bool) {
+	if index < 1 || index > len(l.Sections) {
+		return Section{}, false
+	}
+	return l.Sections[index-1], true
+}
+
+// Lines возвращает строки с номерами от from до to включительно; to обрезается по концу текста
+func (l *Lyrics) Lines(from, to int) []Line {
+	lines := []Line{}
+	for _, section := range l.Sections {
+		for _, line := range section.Lines {
+			if line.Number >= from && line.Number <= to {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return lines
+}
+
+// Text возвращает строки части, соединенные переводом строки
+func (s Section) Text() string {
+	texts := make([]string, len(s.Lines))
+	for i, line := range s.Lines {
+		texts[i] = line.Text
+	}
+	return strings.Join(texts, "\n")
+}
+
+// parseHeader распознает строку-заголовок части песни
+func parseHeader(line string) (kind string, number int, ok bool) {
+	bracketed := strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]")
+
+	match := headerPattern.FindStringSubmatch(line)
+	if match != nil {
+		if known, found := kindWords[strings.ToLower(match[1])]; found {
+			number, _ = strconv.Atoi(match[2])
+			return known, number, true
+		}
+	}
+
+	// В квадратных скобках пишут только служебные пометки, например [Guitar solo]
+	if bracketed {
+		return KindOther, 0, true
+	}
+	return "", 0, false
+}
+
+// classify определяет вид частей без заголовка и нумерует куплеты.
+// Часть без заголовка, совпадающая с припевом или встречающаяся несколько раз, считается припевом.
+func classify(sections []Section) {
+	counts := make(map[string]int)
+	choruses := make(map[string]bool)
+	for _, section := range sections {
+		text := section.Text()
+		counts[text]++
+		if section.Kind == KindChorus {
+			choruses[text] = true
+		}
+	}
+
+	verse := 0
+	for i := range sections {
+		section := &sections[i]
+		if !section.labeled {
+			section.Kind = KindVerse
+			if text := section.Text(); choruses[text] || counts[text] > 1 {
+				section.Kind = KindChorus
+			}
+		}
+		if section.Kind != KindVerse {
+			continue
+		}
+		if section.Number > 0 {
+			verse = section.Number
+		} else {
+			verse++
+			section.Number = verse
+		}
+	}
+}
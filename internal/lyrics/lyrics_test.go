@@ -0,0 +1,84 @@
+package lyrics
+
+import "testing"
+
+func TestParseLabeledSections(t *testing.T) {
+	text := "[Verse 1]\r\nFirst line\r\nSecond line\r\n\r\n\r\n\r\n[Chorus]\r\nLa la la\r\n\r\nVerse 2:\r\nThird line\r\n"
+	got := Parse(text)
+
+	if got.LineCount != 4 {
+		t.Fatalf("expected 4 lines, got %d", got.LineCount)
+	}
+	want := []struct {
+		kind   string
+		number int
+		label  string
+		lines  int
+	}{
+		{KindVerse, 1, "[Verse 1]", 2},
+		{KindChorus, 0, "[Chorus]", 1},
+		{KindVerse, 2, "Verse 2:", 1},
+	}
+	if len(got.Sections) != len(want) {
+		t.Fatalf("expected %d sections, got %d", len(want), len(got.Sections))
+	}
+	for i, w := range want {
+		s := got.Sections[i]
+		if s.Index != i+1 || s.Kind != w.kind || s.Number != w.number || s.Label != w.label || len(s.Lines) != w.lines {
+			t.Errorf("section %d: got %+v", i+1, s)
+		}
+	}
+	if line := got.Sections[2].Lines[0]; line.Number != 4 || line.Section != 3 || line.Text != "Third line" {
+		t.Errorf("unexpected line %+v", line)
+	}
+}
+
+func TestParseUnlabeledRepeatedStanzaIsChorus(t *testing.T) {
+	text := "Verse one\nstill one\n\nHook line\nHook line\n\nVerse two\n\nHook line\nHook line\n"
+	got := Parse(text)
+
+	kinds := []string{KindVerse, KindChorus, KindVerse, KindChorus}
+	if len(got.Sections) != len(kinds) {
+		t.Fatalf("expected %d sections, got %d", len(kinds), len(got.Sections))
+	}
+	for i, kind := range kinds {
+		if got.Sections[i].Kind != kind {
+			t.Errorf("section %d: expected %s, got %s", i+1, kind, got.Sections[i].Kind)
+		}
+	}
+	if got.Sections[2].Number != 2 {
+		t.Errorf("expected second verse to be numbered 2, got %d", got.Sections[2].Number)
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	tests := []struct {
+		line string
+		kind string
+		ok   bool
+	}{
+		{"[Припев]", KindChorus, true},
+		{"(Chorus x2)", KindChorus, true},
+		{"Куплет 3:", KindVerse, true},
+		{"[Pre-Chorus]", KindPreChorus, true},
+		{"[Guitar solo]", KindOther, true},
+		{"I walk alone", "", false},
+		{"Bridges burning down", "", false},
+	}
+	for _, tt := range tests {
+		kind, _, ok := parseHeader(tt.line)
+		if kind != tt.kind || ok != tt.ok {
+			t.Errorf("parseHeader(%q) = %q, %v; want %q, %v", tt.line, kind, ok, tt.kind, tt.ok)
+		}
+	}
+}
+
+func TestLinesRange(t *testing.T) {
+	got := Parse("a\nb\n\nc\nd\n").Lines(2, 10)
+	if len(got) != 3 || got[0].Text != "b" || got[2].Text != "d" || got[1].Section != 2 {
+		t.Fatalf("unexpected lines %+v", got)
+	}
+	if empty := Parse("").Lines(1, 5); len(empty) != 0 {
+		t.Fatalf("expected no lines, got %+v", empty)
+	}
+}
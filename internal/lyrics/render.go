@@ -0,0 +1,115 @@
+package lyrics
+
+import (
+	"html"
+	"strconv"
+	"strings"
+)
+
+// kindNames заголовки частей песни для Markdown и HTML
+var kindNames = map[string]string{
+	KindVerse:     "Verse",
+	KindChorus:    "Chorus",
+	KindPreChorus: "Pre-chorus",
+	KindBridge:    "Bridge",
+	KindIntro:     "Intro",
+	KindOutro:     "Outro",
+}
+
+// Name возвращает заголовок части: Verse 2, Chorus; для прочих частей - заголовок из текста без скобок
+func (s Section) Name() string {
+	name, ok := kindNames[s.Kind]
+	if !ok {
+		name = strings.Trim(s.Label, "[]() :")
+	}
+	if s.Number > 0 {
+		name += " " + strconv.Itoa(s.Number)
+	}
+	return name
+}
+
+// RenderText возвращает части песни простым текстом: заголовок из текста, затем строки; части разделены пустой строкой
+func RenderText(sections []Section) string {
+	var out strings.Builder
+	for i, section := range sections {
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		if section.Label != "" {
+			out.WriteString(section.Label + "\n")
+		}
+		for _, line := range section.Lines {
+			out.WriteString(line.Text + "\n")
+		}
+	}
+	return out.String()
+}
+
+// RenderMarkdown возвращает части песни в Markdown с названием песни и заголовками частей.
+// Разметка внутри строк текста экранируется.
+func RenderMarkdown(title string, sections []Section) string {
+	var out strings.Builder
+	out.WriteString("# " + escapeMarkdown(title) + "\n")
+	for _, section := range sections {
+		out.WriteString("\n### " + escapeMarkdown(section.Name()) + "\n\n")
+		for i, line := range section.Lines {
+			out.WriteString(escapeMarkdown(line.Text))
+			if i < len(section.Lines)-1 {
+				out.WriteString("  ") // жесткий перенос строки внутри абзаца
+			}
+			out.WriteString("\n")
+		}
+	}
+	return out.String()
+}
+
+// RenderHTML возвращает части песни HTML-документом; весь текст экранируется.
+// lang - язык текста для атрибута lang, пустой не указывается.
+func RenderHTML(title string, lang string, sections []Section) string {
+	var out strings.Builder
+	out.WriteString("<!DOCTYPE html>\n<html")
+	if lang != "" {
+		out.WriteString(` lang="` + html.EscapeString(lang) + `"`)
+	}
+	out.WriteString(">\n<head>\n<meta charset=\"utf-8\">\n<title>" + html.EscapeString(title) + "</title>\n</head>\n<body>\n<article>\n")
+	out.WriteString("<h1>" + html.EscapeString(title) + "</h1>\n")
+	for _, section := range sections {
+		out.WriteString(`<section class="` + html.EscapeString(section.Kind) + `" data-index="` + strconv.Itoa(section.Index) + "\">\n")
+		out.WriteString("<h2>" + html.EscapeString(section.Name()) + "</h2>\n<p>")
+		for i, line := range section.Lines {
+			if i > 0 {
+				out.WriteString("<br>\n")
+			}
+			out.WriteString(html.EscapeString(line.Text))
+		}
+		out.WriteString("</p>\n</section>\n")
+	}
+	out.WriteString("</article>\n</body>\n</html>\n")
+	return out.String()
+}
+
+// markdownSpecial символы, которые Markdown может принять за разметку
+const markdownSpecial = "\\`*_[]<>#|~"
+
+// escapeMarkdown экранирует разметку Markdown, чтобы строка отображалась как есть
+func escapeMarkdown(text string) string {
+	var out strings.Builder
+	for _, r := range text {
+		if strings.ContainsRune(markdownSpecial, r) {
+			out.WriteByte('\\')
+		}
+		out.WriteRune(r)
+	}
+	escaped := out.String()
+
+	// Строка, начинающаяся как элемент списка или нумерованного списка
+	if strings.HasPrefix(escaped, "-") || strings.HasPrefix(escaped, "+") {
+		return "\\" + escaped
+	}
+	digits := strings.TrimLeft(escaped, "0123456789")
+	if len(digits) < len(escaped) && (strings.HasPrefix(digits, ".") || strings.HasPrefix(digits, ")")) {
+		cut := len(escaped) - len(digits)
+		return escaped[:cut] + "\\" + escaped[cut:]
+	}
+	return escaped
+}
@@ -0,0 +1,32 @@
+package lyrics
+
+import "testing"
+
+func TestRenderText(t *testing.T) {
+	sections := Parse("[Chorus]\nLa la\n\nNo label\nhere\n").Sections
+	want := "[Chorus]\nLa la\n\nNo label\nhere\n"
+	if got := RenderText(sections); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestRenderMarkdownEscapes(t *testing.T) {
+	sections := Parse("*Shout* [loud]\n- not a list\n1. not a list\n").Sections
+	want := "# Song \\#1\n\n### Verse 1\n\n" +
+		"\\*Shout\\* \\[loud\\]  \n\\- not a list  \n1\\. not a list\n"
+	if got := RenderMarkdown("Song #1", sections); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestRenderHTMLEscapes(t *testing.T) {
+	sections := Parse("[Guitar solo]\n<script>alert(1)</script> & more\nsecond\n").Sections
+	want := "<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n<title>Rock &amp; Roll</title>\n</head>\n<body>\n<article>\n" +
+		"<h1>Rock &amp; Roll</h1>\n" +
+		"<section class=\"other\" data-index=\"1\">\n<h2>Guitar solo</h2>\n" +
+		"<p>&lt;script&gt;alert(1)&lt;/script&gt; &amp; more<br>\nsecond</p>\n</section>\n" +
+		"</article>\n</body>\n</html>\n"
+	if got := RenderHTML("Rock & Roll", "en", sections); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
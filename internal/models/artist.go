@@ -0,0 +1,9 @@
+package models
+
+// Artist представляет исполнителя (группу).
+// @Description Модель исполнителя, к которому привязаны песни.
+type Artist struct {
+	ArtistID int    `json:"artist_id,omitempty"`
+	Name     string `json:"name"`
+	Bio      string `json:"bio,omitempty"`
+}
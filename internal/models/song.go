@@ -1,18 +1,102 @@
 package models
 
+import "online-library/internal/lyrics"
+
 // Song представляет сущность песни.
 // @Description Модель песни с основными атрибутами.
 type Song struct {
 	Group       string `json:"group"`
 	Song        string `json:"song"`
 	SongID      int    `json:"song_id,omitempty"`
+	ArtistID    int    `json:"artist_id,omitempty"`
+	Genre       string `json:"genre,omitempty"`
 	Lyrics      string `json:"lyrics,omitempty"`
 	ReleaseDate string `json:"release_date,omitempty"`
-	Link        string `json:"link,omitempty"`
+	Link        string `json:"link,omitempty"`    //ссылка на видео
+	Version     int    `json:"version,omitempty"` //версия записи, отдается клиенту как ETag
+
+	EnrichmentStatus string `json:"enrichment_status,omitempty"` //состояние загрузки данных из внешнего API
+	EnrichmentError  string `json:"enrichment_error,omitempty"`  //последняя ошибка загрузки
+
+	DeletedAt string `json:"deleted_at,omitempty"` //когда песня перемещена в корзину, RFC 3339
+}
+
+// Состояния загрузки данных песни из внешнего API
+const (
+	EnrichmentPending    = "pending"    //ожидает обработки
+	EnrichmentProcessing = "processing" //обрабатывается
+	EnrichmentDone       = "done"       //данные загружены
+	EnrichmentFailed     = "failed"     //попытка не удалась, будет повторена
+	EnrichmentDead       = "dead"       //попытки исчерпаны
+)
+
+// EnrichmentTask песня, для которой нужно загрузить данные из внешнего API
+type EnrichmentTask struct {
+	SongID   int
+	Group    string
+	Song     string
+	Attempts int //номер текущей попытки, учитывая захваты, не дошедшие до результата
+}
+
+// SongLyrics текст песни вместе с данными, нужными для ответа клиенту
+type SongLyrics struct {
+	SongID      int
+	Song        string //название песни
+	Lyrics      string
+	Version     int
+	Structure   *lyrics.Lyrics //текст, разобранный на части; nil, если текста нет
+	Synced      *lyrics.Synced //текст с временными метками (LRC); nil, если не загружен
+	Lang        string         //язык перевода; пустой для оригинального текста
+	LangVersion int64          //версия перевода; 0 для оригинального текста
 }
 
+// LyricsTranslation перевод текста песни.
+// @Description Текст песни на одном из языков. Язык - тег BCP 47 в нижнем регистре.
+type LyricsTranslation struct {
+	Lang      string `json:"lang" example:"en"`
+	Lyrics    string `json:"lyrics"`
+	Version   int64  `json:"version"`    //меняется при каждом изменении перевода
+	UpdatedAt string `json:"updated_at"` //RFC 3339
+}
+
+// SongDetail ответ внешнего music-info API на запрос GET /info
 type SongDetail struct {
-	ReleaseDate string `json:"release_date"`
+	ReleaseDate string `json:"releaseDate"` //в формате DD.MM.YYYY
 	Text        string `json:"text"`
 	Link        string `json:"link"`
 }
+
+// SongPatch описывает частичное обновление песни в духе RFC 7386 (JSON Merge Patch).
+// Ключ - имя JSON-поля Song; отсутствующий ключ не меняет поле, nil очищает его.
+type SongPatch map[string]*string
+
+// SongSearchResult песня, найденная полнотекстовым поиском.
+// @Description Песня с релевантностью и фрагментом текста, в котором подсвечены совпадения.
+type SongSearchResult struct {
+	Song
+	Rank    float64 `json:"rank"`    //релевантность
+	Snippet string  `json:"snippet"` //экранированный для HTML фрагмент с совпадениями, выделенными <b></b>
+}
+
+// Действия, которые записываются в историю изменений песни
+const (
+	RevisionCreate       = "create"
+	RevisionUpdate       = "update"
+	RevisionDelete       = "delete"        //перемещение в корзину
+	RevisionRestore      = "restore"       //возврат из корзины
+	RevisionRevert       = "revert"        //откат к ранее сохраненной ревизии
+	RevisionArtistRename = "artist_rename" //переименование артиста песни, откат его не отменяет
+)
+
+// SongRevision запись истории изменений песни.
+// @Description Изменение песни: кто, когда и какие поля поменял. В old и new попадают только измененные поля.
+type SongRevision struct {
+	RevisionID int64     `json:"revision_id"`
+	SongID     int       `json:"song_id"`
+	Version    int       `json:"version"` //версия песни после изменения
+	Action     string    `json:"action"`
+	Author     string    `json:"author"`                                    //из X-Author, указывается клиентом и не проверяется
+	ChangedAt  string    `json:"changed_at"`                                //RFC 3339
+	Old        SongPatch `json:"old,omitempty" swaggertype:"object,string"` //прежние значения, нет у create
+	New        SongPatch `json:"new" swaggertype:"object,string"`
+}
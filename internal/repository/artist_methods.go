@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"online-library/internal/logger"
+	"online-library/internal/models"
+)
+
+func (r *PostgresArtistRepository) GetArtists(name string, page int, limit int) ([]models.Artist, error) {
+	logger.Log.Debugf("GetArtists called with name: %s, page: %d, limit: %d", name, page, limit)
+
+	offset := (page - 1) * limit
+
+	// Имя ищется подстрокой без учета регистра, % и _ в нем не работают как шаблон
+	query := `
+		SELECT id, name, COALESCE(bio, '')
+		FROM artists
+		WHERE ($1 = '' OR name ILIKE '%' || $1 || '%')
+		ORDER BY name
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.db.Query(query, escapeLike(name), limit, offset)
+	if err != nil {
+		logger.Log.Errorf("Error executing query: %v", err)
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	// Пустой список отдается клиенту как [], а не null
+	artists := []models.Artist{}
+	for rows.Next() {
+		var artist models.Artist
+		if err := rows.Scan(&artist.ArtistID, &artist.Name, &artist.Bio); err != nil {
+			logger.Log.Errorf("Error scanning row: %v", err)
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		artists = append(artists, artist)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Log.Errorf("Error iterating rows: %v", err)
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return artists, nil
+}
+
+func (r *PostgresArtistRepository) GetArtistByID(artistID int) (*models.Artist, error) {
+	logger.Log.Debugf("GetArtistByID called with artistID: %d", artistID)
+
+	var artist models.Artist
+	query := "SELECT id, name, COALESCE(bio, '') FROM artists WHERE id = $1"
+	err := r.db.QueryRow(query, artistID).Scan(&artist.ArtistID, &artist.Name, &artist.Bio)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logger.Log.Warnf("Artist with ID %d not found", artistID)
+			return nil, notFoundError("artist with ID %d not found", artistID)
+		}
+		logger.Log.Errorf("Database error: %v", err)
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	return &artist, nil
+}
+
+func (r *PostgresArtistRepository) GetArtistSongs(artistID int, page int, limit int) ([]models.Song, error) {
+	logger.Log.Debugf("GetArtistSongs called with artistID: %d, page: %d, limit: %d", artistID, page, limit)
+
+	offset := (page - 1) * limit
+
+	// Дискография упорядочена по дате выпуска, песни без даты идут в конце
+	query := `
+		SELECT ` + songColumns + `
+		FROM ` + songSource + `
+		WHERE s.artist_id = $1 AND ` + songAlive + `
+		ORDER BY s.release_date NULLS LAST, s.name
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.db.Query(query, artistID, limit, offset)
+	if err != nil {
+		logger.Log.Errorf("Error executing query: %v", err)
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	var songs []models.Song
+	for rows.Next() {
+		song, err := scanSong(rows)
+		if err != nil {
+			logger.Log.Errorf("Error scanning row: %v", err)
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		songs = append(songs, song)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Log.Errorf("Error iterating rows: %v", err)
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return songs, nil
+}
+
+// CountArtistSongs считает песни дискографии артиста, песни в корзине не учитываются
+func (r *PostgresArtistRepository) CountArtistSongs(artistID int) (int, error) {
+	logger.Log.Debugf("CountArtistSongs called with artistID: %d", artistID)
+
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM songs WHERE artist_id = $1 AND deleted_at IS NULL", artistID).Scan(&count)
+	if err != nil {
+		logger.Log.Errorf("Failed to count songs of artist ID %d: %v", artistID, err)
+		return 0, fmt.Errorf("failed to count songs: %w", err)
+	}
+	return count, nil
+}
+
+func (r *PostgresArtistRepository) AddArtist(artist models.Artist) (int, error) {
+	logger.Log.Debugf("AddArtist called with name: %s", artist.Name)
+
+	query := `INSERT INTO artists (name, bio)
+			  VALUES ($1, $2)
+			  RETURNING id
+			  `
+	var artistID int
+	err := r.db.QueryRow(query, artist.Name, nullString(artist.Bio)).Scan(&artistID)
+	if err != nil {
+		logger.Log.Errorf("Failed to insert artist: %v", err)
+		return 0, dbError(err, "failed to insert artist")
+	}
+	logger.Log.Infof("Artist added successfully with ID %d", artistID)
+	return artistID, nil
+}
+
+// UpdateArtist меняет имя и описание артиста. Переименование меняет поле group у всех песен артиста,
+// поэтому их версии увеличиваются, а в историю каждой песни записывается ревизия.
+func (r *PostgresArtistRepository) UpdateArtist(artistID int, artist models.Artist) error {
+	logger.Log.Debugf("UpdateArtist called for artistID: %d", artistID)
+
+	tx, err := r.begin()
+	if err != nil {
+		logger.Log.Errorf("Failed to begin transaction: %v", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var oldName string
+	err = tx.QueryRow("SELECT name FROM artists WHERE id = $1 FOR UPDATE", artistID).Scan(&oldName)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Log.Warnf("Artist with ID %d not found", artistID)
+		return notFoundError("artist with ID %d not found", artistID)
+	}
+	if err != nil {
+		logger.Log.Errorf("Failed to lock artist ID %d: %v", artistID, err)
+		return fmt.Errorf("failed to update artist: %w", err)
+	}
+
+	query := `
+		UPDATE artists
+		SET name = $1, bio = $2
+		WHERE id = $3
+		`
+	if _, err := tx.Exec(query, artist.Name, nullString(artist.Bio), artistID); err != nil {
+		logger.Log.Errorf("Failed to update artist ID %d: %v", artistID, err)
+		return dbError(err, "failed to update artist")
+	}
+
+	if artist.Name != oldName {
+		// Триггер видит артиста уже переименованным и не находит изменений, поэтому ревизии записываются здесь,
+		// а триггер пропускает обновления с действием artist_rename
+		if _, err := tx.Exec("SELECT set_config('app.action', $1, true)", models.RevisionArtistRename); err != nil {
+			return fmt.Errorf("failed to set revision action: %w", err)
+		}
+		query = `
+			WITH renamed AS (
+				UPDATE songs SET version = version + 1 WHERE artist_id = $1
+				RETURNING id, version
+			)
+			INSERT INTO song_revisions (song_id, version, action, author, old_values, new_values)
+			SELECT id, version, $2, COALESCE(NULLIF(current_setting('app.author', true), ''), 'system'),
+				jsonb_build_object('group', $3::text), jsonb_build_object('group', $4::text)
+			FROM renamed
+		`
+		result, err := tx.Exec(query, artistID, models.RevisionArtistRename, oldName, artist.Name)
+		if err != nil {
+			logger.Log.Errorf("Failed to record rename of artist ID %d in song history: %v", artistID, err)
+			return fmt.Errorf("failed to record artist rename: %w", err)
+		}
+		songs, _ := result.RowsAffected()
+		logger.Log.Infof("Artist ID %d renamed from %q to %q, %d songs updated", artistID, oldName, artist.Name, songs)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Errorf("Failed to commit artist update: %v", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	logger.Log.Infof("Artist with ID %d updated successfully", artistID)
+	return nil
+}
+
+// DeleteArtist удаляет артиста. Без cascade артист с песнями, в том числе с песнями в корзине,
+// не удаляется: внешний ключ удалил бы их окончательно, и восстановить их было бы нельзя.
+func (r *PostgresArtistRepository) DeleteArtist(artistID int, cascade bool) error {
+	logger.Log.Debugf("DeleteArtist called for artistID: %d, cascade: %v", artistID, cascade)
+
+	tx, err := r.begin()
+	if err != nil {
+		logger.Log.Errorf("Failed to begin transaction: %v", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Блокировка артиста не дает добавить ему песню между подсчетом и удалением
+	var id int
+	err = tx.QueryRow("SELECT id FROM artists WHERE id = $1 FOR UPDATE", artistID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logger.Log.Warnf("Artist with ID %d not found", artistID)
+			return notFoundError("artist with ID %d not found", artistID)
+		}
+		logger.Log.Errorf("Failed to lock artist ID %d: %v", artistID, err)
+		return fmt.Errorf("failed to delete artist: %w", err)
+	}
+
+	if !cascade {
+		var songs, trashed int
+		query := "SELECT COUNT(*), COUNT(*) FILTER (WHERE deleted_at IS NOT NULL) FROM songs WHERE artist_id = $1"
+		if err := tx.QueryRow(query, artistID).Scan(&songs, &trashed); err != nil {
+			logger.Log.Errorf("Failed to count songs of artist ID %d: %v", artistID, err)
+			return fmt.Errorf("failed to count songs: %w", err)
+		}
+		if songs > 0 {
+			logger.Log.Warnf("Refusing to delete artist ID %d with %d songs (%d in trash) without confirmation", artistID, songs, trashed)
+			return conflictError(nil, "artist has %d songs (%d in trash), pass confirm=true to delete them too", songs, trashed)
+		}
+	}
+
+	// Песни артиста удаляются внешним ключом ON DELETE CASCADE
+	if _, err := tx.Exec("DELETE FROM artists WHERE id = $1", artistID); err != nil {
+		logger.Log.Errorf("Failed to delete artist ID %d: %v", artistID, err)
+		return fmt.Errorf("failed to delete artist: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Errorf("Failed to commit artist delete: %v", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	logger.Log.Infof("Artist with ID %d deleted successfully", artistID)
+	return nil
+}
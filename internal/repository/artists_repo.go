@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"database/sql"
+	"online-library/internal/models"
+)
+
+type ArtistRepository interface {
+	GetArtists(name string, page int, limit int) ([]models.Artist, error)
+	GetArtistByID(artistID int) (*models.Artist, error)
+	GetArtistSongs(artistID int, page int, limit int) ([]models.Song, error) //дискография артиста
+	CountArtistSongs(artistID int) (int, error)
+	AddArtist(artist models.Artist) (int, error)
+	UpdateArtist(artistID int, artist models.Artist) error
+	DeleteArtist(artistID int, cascade bool) error //с cascade песни артиста удаляются вместе с ним
+	// WithAuthor возвращает репозиторий, изменения песен через который записываются в историю от имени author
+	WithAuthor(author string) ArtistRepository
+}
+
+type PostgresArtistRepository struct {
+	db     *sql.DB
+	author string //автор изменений для истории песен, пустой - system
+}
+
+func NewPostgresArtistRepository(db *sql.DB) *PostgresArtistRepository {
+	return &PostgresArtistRepository{db: db}
+}
+
+// WithAuthor возвращает копию репозитория, которая подписывает изменения именем author
+func (r *PostgresArtistRepository) WithAuthor(author string) ArtistRepository {
+	return &PostgresArtistRepository{db: r.db, author: author}
+}
+
+// begin открывает транзакцию записи и передает триггеру истории автора изменений
+func (r *PostgresArtistRepository) begin() (*sql.Tx, error) {
+	return beginAs(r.db, r.author)
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"online-library/internal/logger"
+	"online-library/internal/models"
+)
+
+// MaxBatchSize - сколько песен можно изменить одной пакетной операцией
+const MaxBatchSize = 1000
+
+// Итоги пакетной операции по одной песне
+const (
+	BatchDeleted    = "deleted"
+	BatchUpdated    = "updated"
+	BatchNotFound   = "not_found"
+	BatchFailed     = "failed"
+	BatchRolledBack = "rolled_back" //изменение отменено, потому что другая песня пакета не изменилась
+)
+
+// BatchTarget песни пакетной операции: список ID или фильтр
+type BatchTarget struct {
+	IDs    []int
+	Filter *SongFilter
+}
+
+// BatchResult итог пакетной операции по одной песне
+type BatchResult struct {
+	ID      int    `json:"id"`
+	Status  string `json:"status"`
+	Version int    `json:"version,omitempty"` //версия песни после изменения
+	Error   string `json:"error,omitempty"`
+}
+
+// DeleteSongs удаляет песни одной транзакцией.
+// С atomic ошибка по любой песне отменяет всю операцию.
+func (r *PostgresSongRepository) DeleteSongs(target BatchTarget, atomic bool) ([]BatchResult, error) {
+	logger.Log.Debugf("DeleteSongs called with %d ids, filter: %v, atomic: %v", len(target.IDs), target.Filter != nil, atomic)
+
+	return r.runBatch(target, atomic, func(tx *sql.Tx, songID int) (BatchResult, error) {
+		if err := deleteSong(tx, songID, 0); err != nil {
+			return BatchResult{}, err
+		}
+		return BatchResult{Status: BatchDeleted}, nil
+	})
+}
+
+// PatchSongs применяет один JSON Merge Patch к песням одной транзакцией.
+// С atomic ошибка по любой песне отменяет всю операцию.
+func (r *PostgresSongRepository) PatchSongs(target BatchTarget, patch models.SongPatch, atomic bool) ([]BatchResult, error) {
+	logger.Log.Debugf("PatchSongs called with %d ids, filter: %v, atomic: %v", len(target.IDs), target.Filter != nil, atomic)
+
+	return r.runBatch(target, atomic, func(tx *sql.Tx, songID int) (BatchResult, error) {
+		if err := applySongPatch(tx, songID, patch, 0); err != nil {
+			return BatchResult{}, err
+		}
+		result := BatchResult{Status: BatchUpdated}
+		if err := tx.QueryRow("SELECT version FROM songs WHERE id = $1 AND deleted_at IS NULL", songID).Scan(&result.Version); err != nil {
+			return BatchResult{}, fmt.Errorf("failed to read song version: %w", err)
+		}
+		return result, nil
+	})
+}
+
+// runBatch применяет apply к каждой песне пакета в одной транзакции.
+// Каждая песня обрабатывается в своей точке сохранения: ошибка по ней не мешает остальным,
+// если только не задан atomic.
+func (r *PostgresSongRepository) runBatch(target BatchTarget, atomic bool, apply func(tx *sql.Tx, songID int) (BatchResult, error)) ([]BatchResult, error) {
+	tx, err := r.begin()
+	if err != nil {
+		logger.Log.Errorf("Failed to begin transaction: %v", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	ids, err := batchIDs(tx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult, 0, len(ids))
+	failed := false
+	for _, songID := range ids {
+		if _, err := tx.Exec("SAVEPOINT batch_item"); err != nil {
+			return nil, fmt.Errorf("failed to create savepoint: %w", err)
+		}
+
+		result, err := apply(tx, songID)
+		if err != nil {
+			var repoErr *Error
+			if !errors.As(err, &repoErr) {
+				return nil, err
+			}
+			if _, err := tx.Exec("ROLLBACK TO SAVEPOINT batch_item"); err != nil {
+				return nil, fmt.Errorf("failed to roll back to savepoint: %w", err)
+			}
+
+			result = BatchResult{Status: BatchFailed, Error: repoErr.Message}
+			if errors.Is(err, ErrNotFound) {
+				result.Status = BatchNotFound
+			}
+			failed = true
+		} else if _, err := tx.Exec("RELEASE SAVEPOINT batch_item"); err != nil {
+			return nil, fmt.Errorf("failed to release savepoint: %w", err)
+		}
+
+		result.ID = songID
+		results = append(results, result)
+	}
+
+	if atomic && failed {
+		for i := range results {
+			if results[i].Status == BatchDeleted || results[i].Status == BatchUpdated {
+				results[i].Status = BatchRolledBack
+				results[i].Version = 0
+			}
+		}
+		logger.Log.Warnf("Batch of %d songs rolled back", len(ids))
+		return results, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Errorf("Failed to commit batch: %v", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	logger.Log.Infof("Batch of %d songs applied", len(ids))
+	return results, nil
+}
+
+// batchIDs возвращает ID песен пакета без повторов.
+// Песни под фильтром блокируются до конца транзакции, чтобы выборка не менялась во время операции.
+func batchIDs(tx *sql.Tx, target BatchTarget) ([]int, error) {
+	if target.Filter == nil {
+		seen := make(map[int]bool, len(target.IDs))
+		ids := make([]int, 0, len(target.IDs))
+		for _, id := range target.IDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) > MaxBatchSize {
+			return nil, validationError("batch must not exceed %d songs, got %d", MaxBatchSize, len(ids))
+		}
+		return ids, nil
+	}
+
+	if target.Filter.isEmpty() {
+		// Пустой фильтр выбрал бы весь каталог
+		return nil, validationError("batch filter must contain at least one condition")
+	}
+	var b queryBuilder
+	if err := applySongFilter(&b, *target.Filter); err != nil {
+		return nil, err
+	}
+
+	// Лишняя строка сверх MaxBatchSize показывает, что под фильтр попало слишком много песен
+	query := fmt.Sprintf(`SELECT s.id FROM %s %s ORDER BY s.id LIMIT %s FOR UPDATE OF s`,
+		songSource, b.whereClause(), b.arg(MaxBatchSize+1))
+	rows, err := tx.Query(query, b.args...)
+	if err != nil {
+		logger.Log.Errorf("Failed to select batch songs: %v", err)
+		return nil, dbError(err, "failed to select batch songs")
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan song id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	if len(ids) > MaxBatchSize {
+		return nil, validationError("batch filter matches more than %d songs", MaxBatchSize)
+	}
+	return ids, nil
+}
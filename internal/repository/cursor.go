@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+// songCursor - содержимое курсора: сортировка, для которой он выдан, и ключи последней строки страницы
+type songCursor struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+}
+
+// sortSignature описывает сортировку строкой, чтобы курсор нельзя было применить к другому порядку
+func sortSignature(fields []SortField) string {
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field.Desc {
+			parts = append(parts, "-"+field.Field)
+		} else {
+			parts = append(parts, field.Field)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// encodeCursor упаковывает ключи строки в непрозрачную для клиента строку
+func encodeCursor(fields []SortField, values []string) string {
+	data, _ := json.Marshal(songCursor{Sort: sortSignature(fields), Values: values})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor проверяет курсор и возвращает ключи строки, после которой начинается страница
+func decodeCursor(cursor string, fields []SortField) ([]string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, validationError("malformed cursor")
+	}
+
+	var decoded songCursor
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, validationError("malformed cursor")
+	}
+	if decoded.Sort != sortSignature(fields) || len(decoded.Values) != len(fields) {
+		return nil, validationError("cursor does not match sort order %q", sortSignature(fields))
+	}
+	return decoded.Values, nil
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	fields := []SortField{{Field: "release_date", Desc: true}, {Field: "id"}}
+	values := []string{"1975-10-31", "42"}
+
+	got, err := decodeCursor(encodeCursor(fields, values), fields)
+	if err != nil {
+		t.Fatalf("decodeCursor() error = %v", err)
+	}
+	if len(got) != 2 || got[0] != values[0] || got[1] != values[1] {
+		t.Errorf("decodeCursor() = %v, want %v", got, values)
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	fields := []SortField{{Field: "id"}}
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "!!!"},
+		{"not json", "bm90IGpzb24"},
+		{"other sort order", encodeCursor([]SortField{{Field: "id", Desc: true}}, []string{"1"})},
+		{"wrong number of keys", encodeCursor(fields, []string{"1", "2"})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeCursor(tt.cursor, fields)
+			if !errors.Is(err, ErrValidation) {
+				t.Errorf("decodeCursor() error = %v, want validation error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"online-library/internal/logger"
+	"online-library/internal/models"
+	"time"
+)
+
+// ErrLeaseLost - захват песни истек, и ее обрабатывает другой обработчик
+var ErrLeaseLost = errors.New("enrichment lease lost")
+
+// ClaimEnrichments захватывает до limit песен, ожидающих загрузки данных, на время lease.
+// Песни, обработчик которых не уложился в lease, захватываются повторно.
+// Каждый захват считается попыткой: номер попытки служит меткой захвата, и песня,
+// на которой обработчик падает, не захватывается бесконечно.
+func (r *PostgresSongRepository) ClaimEnrichments(limit int, lease time.Duration) ([]models.EnrichmentTask, error) {
+	logger.Log.Debugf("ClaimEnrichments called with limit: %d, lease: %v", limit, lease)
+
+	// SKIP LOCKED позволяет нескольким обработчикам и экземплярам сервиса разбирать очередь без блокировок
+	query := `
+		UPDATE songs s
+		SET enrichment_status = 'processing',
+			enrichment_attempts = s.enrichment_attempts + 1,
+			enrichment_next_at = now() + make_interval(secs => $2)
+		FROM artists a
+		WHERE a.id = s.artist_id AND s.id IN (
+			SELECT id FROM songs
+			WHERE enrichment_status IN ('pending', 'processing', 'failed')
+				AND enrichment_next_at <= now()
+				AND deleted_at IS NULL
+			ORDER BY enrichment_next_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING s.id, a.name, s.name, s.enrichment_attempts
+	`
+	rows, err := r.db.Query(query, limit, lease.Seconds())
+	if err != nil {
+		logger.Log.Errorf("Failed to claim enrichment tasks: %v", err)
+		return nil, fmt.Errorf("failed to claim enrichment tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []models.EnrichmentTask
+	for rows.Next() {
+		var task models.EnrichmentTask
+		if err := rows.Scan(&task.SongID, &task.Group, &task.Song, &task.Attempts); err != nil {
+			logger.Log.Errorf("Failed to scan enrichment task: %v", err)
+			return nil, fmt.Errorf("failed to scan enrichment task: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Log.Errorf("Error iterating enrichment tasks: %v", err)
+		return nil, fmt.Errorf("error iterating enrichment tasks: %w", err)
+	}
+
+	logger.Log.Debugf("Claimed %d enrichment tasks", len(tasks))
+	return tasks, nil
+}
+
+// CompleteEnrichment сохраняет данные из внешнего API.
+// Поля, которые клиент успел заполнить сам, не перезаписываются.
+// Песни в корзине и песни, захват которых уже истек, не изменяются.
+func (r *PostgresSongRepository) CompleteEnrichment(task models.EnrichmentTask, detail models.SongDetail) error {
+	songID := task.SongID
+	logger.Log.Debugf("CompleteEnrichment called for songID: %d, attempt: %d", songID, task.Attempts)
+
+	releaseDate, err := nullDate(detail.ReleaseDate)
+	if err != nil {
+		// Некорректная дата не должна терять текст и ссылку
+		logger.Log.Warnf("Ignoring release date from external API for song ID %d: %v", songID, err)
+		releaseDate = sql.NullTime{}
+	}
+
+	query := `
+		UPDATE songs
+		SET release_date = COALESCE(release_date, $2),
+			lyrics = COALESCE(lyrics, $3),
+			video = COALESCE(video, $4),
+			enrichment_status = 'done',
+			enrichment_error = NULL,
+			enrichment_next_at = NULL,
+			version = version + 1
+		WHERE id = $1 AND enrichment_status = 'processing' AND enrichment_attempts = $5 AND deleted_at IS NULL
+	`
+	result, err := r.db.Exec(query, songID, releaseDate, nullString(detail.Text), nullString(detail.Link), task.Attempts)
+	if err != nil {
+		logger.Log.Errorf("Failed to complete enrichment for song ID %d: %v", songID, err)
+		return dbError(err, "failed to complete enrichment")
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		// Захват истек, песню перенесли в корзину или удалили, пока запрашивались данные
+		logger.Log.Warnf("Lease for song ID %d was lost before enrichment completed", songID)
+		return ErrLeaseLost
+	}
+
+	logger.Log.Infof("Enrichment completed for song ID %d", songID)
+	return nil
+}
+
+// FailEnrichment учитывает неудачную попытку: при retryAt в будущем песня вернется в очередь,
+// при dead попытки прекращаются.
+func (r *PostgresSongRepository) FailEnrichment(task models.EnrichmentTask, reason string, retryAt time.Time, dead bool) error {
+	songID := task.SongID
+	logger.Log.Debugf("FailEnrichment called for songID: %d, attempt: %d, dead: %v", songID, task.Attempts, dead)
+
+	status, nextAt := models.EnrichmentFailed, sql.NullTime{Time: retryAt, Valid: true}
+	if dead {
+		status, nextAt = models.EnrichmentDead, sql.NullTime{}
+	}
+
+	query := `
+		UPDATE songs
+		SET enrichment_status = $2,
+			enrichment_error = $3,
+			enrichment_next_at = $4
+		WHERE id = $1 AND enrichment_status = 'processing' AND enrichment_attempts = $5
+	`
+	result, err := r.db.Exec(query, songID, status, reason, nextAt, task.Attempts)
+	if err != nil {
+		logger.Log.Errorf("Failed to record enrichment failure for song ID %d: %v", songID, err)
+		return dbError(err, "failed to record enrichment failure")
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		logger.Log.Warnf("Lease for song ID %d was lost before enrichment failure was recorded", songID)
+		return ErrLeaseLost
+	}
+
+	logger.Log.Infof("Enrichment for song ID %d marked as %s", songID, status)
+	return nil
+}
+
+// RequeueEnrichment возвращает песню в очередь загрузки данных со сброшенным счетчиком попыток
+func (r *PostgresSongRepository) RequeueEnrichment(songID int) (*models.Song, error) {
+	logger.Log.Debugf("RequeueEnrichment called for songID: %d", songID)
+
+	query := `
+		UPDATE songs
+		SET enrichment_status = 'pending',
+			enrichment_attempts = 0,
+			enrichment_error = NULL,
+			enrichment_next_at = now()
+		WHERE id = $1 AND deleted_at IS NULL AND enrichment_status <> 'processing'
+	`
+	result, err := r.db.Exec(query, songID)
+	if err != nil {
+		logger.Log.Errorf("Failed to requeue enrichment for song ID %d: %v", songID, err)
+		return nil, dbError(err, "failed to requeue enrichment")
+	}
+
+	song, err := r.GetSongByID(songID)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		logger.Log.Warnf("Enrichment for song ID %d is already in progress", songID)
+		return nil, conflictError(nil, "enrichment for song with ID %d is already in progress", songID)
+	}
+
+	logger.Log.Infof("Enrichment requeued for song ID %d", songID)
+	return song, nil
+}
+
+// initialEnrichmentStatus возвращает состояние загрузки данных для новой песни.
+// Клиент может только поставить песню в очередь или отметить ее данные готовыми.
+func initialEnrichmentStatus(song models.Song) (string, error) {
+	switch song.EnrichmentStatus {
+	case "":
+		return models.EnrichmentDone, nil
+	case models.EnrichmentPending, models.EnrichmentDone:
+		return song.EnrichmentStatus, nil
+	default:
+		return "", validationError("invalid enrichment status %q for a new song", song.EnrichmentStatus)
+	}
+}
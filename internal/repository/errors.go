@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// Виды ошибок репозитория. Проверяются через errors.Is, по ним обработчики выбирают HTTP-статус.
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrValidation = errors.New("validation failed")
+	// ErrVersionMismatch - запись изменилась с момента чтения клиентом
+	ErrVersionMismatch = errors.New("version mismatch")
+)
+
+// Error - ошибка репозитория определенного вида с сообщением, которое можно показать клиенту
+type Error struct {
+	Kind    error  // ErrNotFound, ErrConflict, ErrValidation или ErrVersionMismatch
+	Message string // описание для клиента
+	Err     error  // исходная ошибка, если есть
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func notFoundError(format string, args ...interface{}) error {
+	return &Error{Kind: ErrNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+func conflictError(err error, format string, args ...interface{}) error {
+	return &Error{Kind: ErrConflict, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+func versionMismatchError(format string, args ...interface{}) error {
+	return &Error{Kind: ErrVersionMismatch, Message: fmt.Sprintf(format, args...)}
+}
+
+func validationError(format string, args ...interface{}) error {
+	return &Error{Kind: ErrValidation, Message: fmt.Sprintf(format, args...)}
+}
+
+// dbError переводит ошибки Postgres, вызванные данными клиента, в типизированные ошибки репозитория.
+// Остальные ошибки оборачиваются с сообщением msg.
+func dbError(err error, msg string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505": // unique_violation
+			return conflictError(err, "%s: duplicate value", msg)
+		case "23503": // foreign_key_violation
+			return conflictError(err, "%s: referenced record does not exist", msg)
+		case "23502", "22001", "22007", "22008": // not_null_violation, string_data_right_truncation, неверная дата
+			return &Error{Kind: ErrValidation, Message: msg + ": " + pqErr.Message, Err: err}
+		}
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
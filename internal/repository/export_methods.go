@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"online-library/internal/logger"
+	"online-library/internal/models"
+)
+
+// ExportSongs перебирает все песни под фильтром в порядке сортировки фильтра.
+// Строки читаются из БД по мере перебора, поэтому каталог целиком в памяти не держится.
+// Страница и курсор фильтра не учитываются. Перебор прекращается при первой ошибке.
+func (r *PostgresSongRepository) ExportSongs(ctx context.Context, filter SongFilter) iter.Seq2[models.Song, error] {
+	return func(yield func(models.Song, error) bool) {
+		logger.Log.Debugf("ExportSongs called with filter: %+v", filter)
+
+		var b queryBuilder
+		if err := applySongFilter(&b, filter); err != nil {
+			yield(models.Song{}, err)
+			return
+		}
+		sortFields, err := effectiveSort(filter.Sort)
+		if err != nil {
+			yield(models.Song{}, err)
+			return
+		}
+
+		query := fmt.Sprintf(`SELECT %s FROM %s %s %s`,
+			songColumns, songSource, b.whereClause(), orderByClause(sortFields))
+
+		// Контекст запроса прерывает выборку, если клиент отключился
+		rows, err := r.db.QueryContext(ctx, query, b.args...)
+		if err != nil {
+			logger.Log.Errorf("Error executing export query: %v", err)
+			yield(models.Song{}, dbError(err, "error executing query"))
+			return
+		}
+		defer rows.Close()
+
+		count := 0
+		for rows.Next() {
+			song, err := scanSong(rows)
+			if err != nil {
+				logger.Log.Errorf("Error scanning row: %v", err)
+				yield(models.Song{}, fmt.Errorf("error scanning row: %w", err))
+				return
+			}
+			count++
+			if !yield(song, nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			logger.Log.Errorf("Error iterating rows: %v", err)
+			yield(models.Song{}, fmt.Errorf("error iterating rows: %w", err))
+			return
+		}
+		logger.Log.Infof("Exported %d songs", count)
+	}
+}
@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"fmt"
+	"online-library/internal/models"
+	"strings"
+)
+
+// SongFilter параметры выборки списка песен
+type SongFilter struct {
+	Group          string
+	Title          string
+	Genre          string
+	ReleasedAfter  string // дата выпуска не раньше, включительно
+	ReleasedBefore string // дата выпуска не позже, включительно
+	HasLyrics      *bool  // nil - не важно, есть ли текст
+	Exact          bool   // точное совпадение group и title вместо поиска подстроки
+	Sort           []SortField
+	Page           int
+	Limit          int
+	Cursor         string // курсор keyset-пагинации; если задан, Page не используется
+}
+
+// SongList страница списка песен
+type SongList struct {
+	Songs      []models.Song
+	Total      int    // сколько всего песен подходит под фильтр
+	NextCursor string // курсор следующей страницы, пустой на последней странице
+}
+
+// SortField поле сортировки списка песен
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// sortColumn выражение для ORDER BY и тип, к которому приводится значение из курсора
+type sortColumn struct {
+	expr string
+	cast string
+}
+
+// sortableColumns - поля, по которым разрешена сортировка.
+// Выражения не возвращают NULL, чтобы по ним работала keyset-пагинация:
+// песни без даты или жанра идут первыми при сортировке по возрастанию.
+var sortableColumns = map[string]sortColumn{
+	"id":           {expr: "s.id", cast: "int"},
+	"song":         {expr: "s.name", cast: "text"},
+	"group":        {expr: "a.name", cast: "text"},
+	"genre":        {expr: "COALESCE(s.genre, '')", cast: "text"},
+	"release_date": {expr: "COALESCE(s.release_date, '-infinity'::date)", cast: "date"},
+}
+
+// defaultSort - сортировка списка песен, если клиент ее не задал
+var defaultSort = []SortField{{Field: "song"}}
+
+// ParseSort разбирает параметр сортировки вида "field,-field"; минус означает убывание
+func ParseSort(value string) ([]SortField, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	var fields []SortField
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		field := SortField{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if _, ok := sortableColumns[field.Field]; !ok {
+			return nil, validationError("unknown sort field %q", field.Field)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// queryBuilder собирает условия WHERE с позиционными параметрами
+type queryBuilder struct {
+	conds []string
+	args  []interface{}
+}
+
+// arg добавляет параметр запроса и возвращает его плейсхолдер
+func (b *queryBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+func (b *queryBuilder) where(cond string) {
+	b.conds = append(b.conds, cond)
+}
+
+// whereClause возвращает WHERE со всеми условиями или пустую строку
+func (b *queryBuilder) whereClause() string {
+	if len(b.conds) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(b.conds, " AND ")
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// matchText добавляет условие на текстовую колонку: точное совпадение без учета регистра или подстроку
+func (b *queryBuilder) matchText(column, value string, exact bool) {
+	if exact {
+		b.where(fmt.Sprintf("lower(%s) = lower(%s)", column, b.arg(value)))
+		return
+	}
+	b.where(fmt.Sprintf("%s ILIKE %s", column, b.arg("%"+escapeLike(value)+"%")))
+}
+
+// isEmpty сообщает, что фильтр не задает ни одного условия
+func (f SongFilter) isEmpty() bool {
+	return f.Group == "" && f.Title == "" && f.Genre == "" && f.ReleasedAfter == "" &&
+		f.ReleasedBefore == "" && f.HasLyrics == nil
+}
+
+// applySongFilter добавляет в builder условия фильтра песен; песни из корзины не выбираются
+func applySongFilter(b *queryBuilder, filter SongFilter) error {
+	b.where(songAlive)
+	if filter.Group != "" {
+		b.matchText("a.name", filter.Group, filter.Exact)
+	}
+	if filter.Title != "" {
+		b.matchText("s.name", filter.Title, filter.Exact)
+	}
+	if filter.Genre != "" {
+		b.where(fmt.Sprintf("lower(s.genre) = lower(%s)", b.arg(filter.Genre)))
+	}
+	if filter.ReleasedAfter != "" {
+		after, err := nullDate(filter.ReleasedAfter)
+		if err != nil {
+			return err
+		}
+		b.where("s.release_date >= " + b.arg(after))
+	}
+	if filter.ReleasedBefore != "" {
+		before, err := nullDate(filter.ReleasedBefore)
+		if err != nil {
+			return err
+		}
+		b.where("s.release_date <= " + b.arg(before))
+	}
+	if filter.HasLyrics != nil {
+		if *filter.HasLyrics {
+			b.where("COALESCE(s.lyrics, '') <> ''")
+		} else {
+			b.where("COALESCE(s.lyrics, '') = ''")
+		}
+	}
+	return nil
+}
+
+// effectiveSort дополняет сортировку полем id, чтобы порядок строк был однозначным
+func effectiveSort(fields []SortField) ([]SortField, error) {
+	if len(fields) == 0 {
+		fields = defaultSort
+	}
+
+	result := make([]SortField, 0, len(fields)+1)
+	hasID := false
+	for _, field := range fields {
+		if _, ok := sortableColumns[field.Field]; !ok {
+			return nil, validationError("unknown sort field %q", field.Field)
+		}
+		if field.Field == "id" {
+			hasID = true
+		}
+		result = append(result, field)
+	}
+	if !hasID {
+		result = append(result, SortField{Field: "id"})
+	}
+	return result, nil
+}
+
+// orderByClause строит ORDER BY по полям, прошедшим effectiveSort
+func orderByClause(fields []SortField) string {
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		column := sortableColumns[field.Field].expr
+		if field.Desc {
+			column += " DESC"
+		}
+		parts = append(parts, column)
+	}
+	return "ORDER BY " + strings.Join(parts, ", ")
+}
+
+// sortKeyColumns возвращает выражения сортировки в текстовом виде для сохранения в курсоре
+func sortKeyColumns(fields []SortField) string {
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, "("+sortableColumns[field.Field].expr+")::text")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// afterCursor добавляет условие keyset-пагинации: строки, идущие после строки с ключами values.
+// Для сортировки (a ASC, b DESC, id ASC) это (a > $1) OR (a = $1 AND b < $2) OR (a = $1 AND b = $2 AND id > $3).
+func (b *queryBuilder) afterCursor(fields []SortField, values []string) {
+	placeholders := make([]string, len(fields))
+	for i, field := range fields {
+		placeholders[i] = b.arg(values[i]) + "::" + sortableColumns[field.Field].cast
+	}
+
+	var alternatives []string
+	for i, field := range fields {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, sortableColumns[fields[j].Field].expr+" = "+placeholders[j])
+		}
+		op := " > "
+		if field.Desc {
+			op = " < "
+		}
+		parts = append(parts, sortableColumns[field.Field].expr+op+placeholders[i])
+		alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+	}
+	b.where("(" + strings.Join(alternatives, " OR ") + ")")
+}
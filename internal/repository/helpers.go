@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"online-library/internal/logger"
+	"online-library/internal/models"
+	"time"
+)
+
+// songColumns - набор колонок, из которых собирается models.Song.
+// Порядок колонок должен совпадать с порядком полей в scanSong.
+const songColumns = `s.id, s.artist_id, a.name, s.name,
+		COALESCE(s.genre, ''), COALESCE(to_char(s.release_date, 'YYYY-MM-DD'), ''),
+		COALESCE(s.lyrics, ''), COALESCE(s.video, ''), s.version,
+		s.enrichment_status, COALESCE(s.enrichment_error, ''),
+		COALESCE(to_char(s.deleted_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'), '')`
+
+// songSource - таблицы, из которых выбираются песни вместе с артистом
+const songSource = `songs s JOIN artists a ON a.id = s.artist_id`
+
+// songAlive - условие, скрывающее песни в корзине
+const songAlive = `s.deleted_at IS NULL`
+
+// releaseDateLayouts - форматы даты выпуска, которые принимаются на вход
+var releaseDateLayouts = []string{"2006-01-02", "02.01.2006"}
+
+// patchableColumns сопоставляет JSON-поля models.Song колонкам songs, которые можно менять через PATCH.
+// Поле group обрабатывается отдельно: оно меняет artist_id.
+var patchableColumns = map[string]string{
+	"song":         "name",
+	"genre":        "genre",
+	"release_date": "release_date",
+	"lyrics":       "lyrics",
+	"link":         "video",
+}
+
+// revertableColumns - поля, которые восстанавливает откат к ревизии: поля PATCH и текст с временными метками.
+// Текст с метками хранится в снимке ревизии как JSON-строка.
+var revertableColumns = map[string]string{
+	"song":          "name",
+	"genre":         "genre",
+	"release_date":  "release_date",
+	"lyrics":        "lyrics",
+	"link":          "video",
+	"lyrics_synced": "lyrics_synced",
+}
+
+// querier общий интерфейс для *sql.DB и *sql.Tx
+type querier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// execer общий интерфейс для *sql.DB и *sql.Tx, когда нужно и читать, и изменять данные
+type execer interface {
+	querier
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// rowScanner общий интерфейс для *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSong считывает строку, выбранную по songColumns.
+// В extra передаются приемники для колонок, перечисленных в запросе после songColumns.
+func scanSong(row rowScanner, extra ...interface{}) (models.Song, error) {
+	var song models.Song
+	dest := []interface{}{&song.SongID, &song.ArtistID, &song.Group, &song.Song,
+		&song.Genre, &song.ReleaseDate, &song.Lyrics, &song.Link, &song.Version,
+		&song.EnrichmentStatus, &song.EnrichmentError, &song.DeletedAt}
+	err := row.Scan(append(dest, extra...)...)
+	return song, err
+}
+
+// upsertArtist возвращает ID артиста с указанным именем, создавая его при необходимости
+func upsertArtist(tx *sql.Tx, name string) (int, error) {
+	query := `
+		INSERT INTO artists (name) VALUES ($1)
+		ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+		RETURNING id
+	`
+	var artistID int
+	if err := tx.QueryRow(query, name).Scan(&artistID); err != nil {
+		logger.Log.Errorf("Failed to upsert artist %q: %v", name, err)
+		return 0, dbError(err, "failed to upsert artist")
+	}
+	return artistID, nil
+}
+
+// songWriteError объясняет, почему запись песни не затронула ни одной строки:
+// песни нет или ее версия не совпала с ожидаемой
+func songWriteError(q querier, songID int, expectedVersion int) error {
+	var version int
+	err := q.QueryRow("SELECT version FROM songs WHERE id = $1 AND deleted_at IS NULL", songID).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Log.Warnf("Song with ID %d not found", songID)
+		return notFoundError("song with ID %d not found", songID)
+	}
+	if err != nil {
+		logger.Log.Errorf("Failed to check song ID %d: %v", songID, err)
+		return fmt.Errorf("failed to check song: %w", err)
+	}
+	logger.Log.Warnf("Song with ID %d has version %d, expected %d", songID, version, expectedVersion)
+	return versionMismatchError("song with ID %d has version %d, expected %d", songID, version, expectedVersion)
+}
+
+// ParseReleaseDate разбирает дату выпуска в одном из форматов releaseDateLayouts
+func ParseReleaseDate(value string) (time.Time, error) {
+	for _, layout := range releaseDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, validationError("invalid release date %q, expected YYYY-MM-DD or DD.MM.YYYY", value)
+}
+
+// nullDate приводит дату выпуска к значению для БД; пустая строка превращается в NULL
+func nullDate(value string) (sql.NullTime, error) {
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := ParseReleaseDate(value)
+	if err != nil {
+		return sql.NullTime{}, err
+	}
+	return sql.NullTime{Time: t, Valid: true}, nil
+}
+
+// nullString превращает пустую строку в NULL
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
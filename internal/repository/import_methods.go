@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"online-library/internal/logger"
+	"online-library/internal/models"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Результаты импорта строки
+const (
+	ImportCreated = "created" //песня добавлена (при dry run - была бы добавлена)
+	ImportSkipped = "skipped" //такая песня уже есть
+	ImportFailed  = "failed"  //строка содержит ошибку
+)
+
+// ImportRow строка импорта с номером строки во входном файле
+type ImportRow struct {
+	Line int
+	Song models.Song
+}
+
+// ImportResult итог импорта одной строки
+type ImportResult struct {
+	Line   int    `json:"line"`
+	Status string `json:"status"`
+	SongID int    `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// importKey однозначно определяет песню артиста
+type importKey struct {
+	artistID int
+	name     string
+}
+
+// importColumns - число параметров запроса на одну песню в insertSongs
+const importColumns = 7
+
+// ImportSongs добавляет порцию песен одной транзакцией. Песни, которые уже есть у артиста, пропускаются.
+// С dryRun транзакция откатывается, а отчет показывает, что произошло бы.
+func (r *PostgresSongRepository) ImportSongs(rows []ImportRow, dryRun bool) ([]ImportResult, error) {
+	logger.Log.Debugf("ImportSongs called with %d rows, dryRun: %v", len(rows), dryRun)
+
+	results := make([]ImportResult, len(rows))
+	for i, row := range rows {
+		results[i] = ImportResult{Line: row.Line, Status: ImportCreated}
+	}
+
+	tx, err := r.begin()
+	if err != nil {
+		logger.Log.Errorf("Failed to begin transaction: %v", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	artists, err := upsertArtists(tx, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := existingSongs(tx, rows, artists)
+	if err != nil {
+		return nil, err
+	}
+
+	// Отбор строк для вставки
+	var (
+		pending []int
+		keys    []importKey
+		args    []interface{}
+	)
+	for i, row := range rows {
+		key := importKey{artistID: artists[row.Song.Group], name: row.Song.Song}
+		if existing[key] {
+			results[i].Status = ImportSkipped
+			results[i].Error = "song already exists"
+			continue
+		}
+		rowArgs, err := importArgs(key.artistID, row.Song)
+		if err != nil {
+			results[i].Status = ImportFailed
+			results[i].Error = err.Error()
+			continue
+		}
+		pending = append(pending, i)
+		keys = append(keys, key)
+		args = append(args, rowArgs...)
+	}
+
+	if len(pending) > 0 {
+		ids, err := insertBatch(tx, keys, args)
+		if err != nil {
+			// Порция целиком не вставилась: вставляем по одной, чтобы найти строки с ошибками
+			logger.Log.Warnf("Batch insert failed, retrying row by row: %v", err)
+			ids, err = insertOneByOne(tx, keys, args, func(n int, err error) {
+				results[pending[n]].Status = ImportFailed
+				results[pending[n]].Error = err.Error()
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		for n, i := range pending {
+			if results[i].Status == ImportCreated {
+				results[i].SongID = ids[keys[n]]
+			}
+		}
+	}
+
+	if dryRun {
+		for i := range results {
+			results[i].SongID = 0
+		}
+		logger.Log.Infof("Dry run of %d rows finished, rolling back", len(rows))
+		return results, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Errorf("Failed to commit import: %v", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	logger.Log.Infof("Imported batch of %d rows", len(rows))
+	return results, nil
+}
+
+// upsertArtists находит или создает артистов всех строк и возвращает их ID по имени
+func upsertArtists(tx *sql.Tx, rows []ImportRow) (map[string]int, error) {
+	seen := make(map[string]bool)
+	var names []string
+	for _, row := range rows {
+		if !seen[row.Song.Group] {
+			seen[row.Song.Group] = true
+			names = append(names, row.Song.Group)
+		}
+	}
+
+	query := `
+		INSERT INTO artists (name) SELECT unnest($1::text[])
+		ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+		RETURNING id, name
+	`
+	result, err := tx.Query(query, pq.Array(names))
+	if err != nil {
+		logger.Log.Errorf("Failed to upsert artists: %v", err)
+		return nil, dbError(err, "failed to upsert artists")
+	}
+	defer result.Close()
+
+	artists := make(map[string]int, len(names))
+	for result.Next() {
+		var (
+			id   int
+			name string
+		)
+		if err := result.Scan(&id, &name); err != nil {
+			logger.Log.Errorf("Failed to scan artist: %v", err)
+			return nil, fmt.Errorf("failed to scan artist: %w", err)
+		}
+		artists[name] = id
+	}
+	return artists, result.Err()
+}
+
+// existingSongs возвращает песни из rows, которые уже есть у своих артистов
+func existingSongs(tx *sql.Tx, rows []ImportRow, artists map[string]int) (map[importKey]bool, error) {
+	artistIDs := make([]int64, len(rows))
+	names := make([]string, len(rows))
+	for i, row := range rows {
+		artistIDs[i] = int64(artists[row.Song.Group])
+		names[i] = row.Song.Song
+	}
+
+	query := `
+		SELECT s.artist_id, s.name
+		FROM songs s
+		JOIN unnest($1::int[], $2::text[]) AS v(artist_id, name) ON s.artist_id = v.artist_id AND s.name = v.name
+		WHERE s.deleted_at IS NULL
+	`
+	result, err := tx.Query(query, pq.Array(artistIDs), pq.Array(names))
+	if err != nil {
+		logger.Log.Errorf("Failed to check existing songs: %v", err)
+		return nil, fmt.Errorf("failed to check existing songs: %w", err)
+	}
+	defer result.Close()
+
+	existing := make(map[importKey]bool)
+	for result.Next() {
+		var key importKey
+		if err := result.Scan(&key.artistID, &key.name); err != nil {
+			logger.Log.Errorf("Failed to scan existing song: %v", err)
+			return nil, fmt.Errorf("failed to scan existing song: %w", err)
+		}
+		existing[key] = true
+	}
+	return existing, result.Err()
+}
+
+// importArgs готовит параметры вставки песни в порядке колонок insertBatch
+func importArgs(artistID int, song models.Song) ([]interface{}, error) {
+	releaseDate, err := nullDate(song.ReleaseDate)
+	if err != nil {
+		return nil, err
+	}
+	status, err := initialEnrichmentStatus(song)
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{artistID, song.Song, nullString(song.Genre), releaseDate,
+		nullString(song.Lyrics), nullString(song.Link), status}, nil
+}
+
+// insertBatch вставляет песни одним запросом и возвращает их ID.
+// Внутри транзакции используется точка сохранения, чтобы после ошибки можно было продолжить.
+func insertBatch(tx *sql.Tx, keys []importKey, args []interface{}) (map[importKey]int, error) {
+	if _, err := tx.Exec("SAVEPOINT import_batch"); err != nil {
+		return nil, fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	values := make([]string, len(keys))
+	for i := range keys {
+		n := i*importColumns + 1
+		values[i] = fmt.Sprintf("($%d, $%d, $%d, $%d::date, $%d, $%d, $%d, CASE WHEN $%d = 'pending' THEN now() END)",
+			n, n+1, n+2, n+3, n+4, n+5, n+6, n+6)
+	}
+	query := `INSERT INTO songs (artist_id, name, genre, release_date, lyrics, video, enrichment_status, enrichment_next_at)
+			  VALUES ` + strings.Join(values, ", ") + `
+			  RETURNING id, artist_id, name`
+
+	result, err := tx.Query(query, args...)
+	if err != nil {
+		tx.Exec("ROLLBACK TO SAVEPOINT import_batch")
+		return nil, dbError(err, "failed to insert songs")
+	}
+	defer result.Close()
+
+	// Порядок RETURNING не гарантирован, поэтому ID сопоставляются по артисту и названию
+	ids := make(map[importKey]int, len(keys))
+	for result.Next() {
+		var (
+			id  int
+			key importKey
+		)
+		if err := result.Scan(&id, &key.artistID, &key.name); err != nil {
+			// Курсор закрывается до отката: пока он открыт, транзакция не выполняет других команд
+			result.Close()
+			tx.Exec("ROLLBACK TO SAVEPOINT import_batch")
+			return nil, fmt.Errorf("failed to scan inserted song: %w", err)
+		}
+		ids[key] = id
+	}
+	if err := result.Err(); err != nil {
+		tx.Exec("ROLLBACK TO SAVEPOINT import_batch")
+		return nil, dbError(err, "failed to insert songs")
+	}
+	if _, err := tx.Exec("RELEASE SAVEPOINT import_batch"); err != nil {
+		return nil, fmt.Errorf("failed to release savepoint: %w", err)
+	}
+	return ids, nil
+}
+
+// insertOneByOne вставляет песни по одной; о строках, которые вставить не удалось, сообщается через onError
+func insertOneByOne(tx *sql.Tx, keys []importKey, args []interface{}, onError func(n int, err error)) (map[importKey]int, error) {
+	ids := make(map[importKey]int, len(keys))
+	for n, key := range keys {
+		rowIDs, err := insertBatch(tx, []importKey{key}, args[n*importColumns:(n+1)*importColumns])
+		if err != nil {
+			var repoErr *Error
+			if !errors.As(err, &repoErr) {
+				return nil, err
+			}
+			onError(n, err)
+			continue
+		}
+		ids[key] = rowIDs[key]
+	}
+	return ids, nil
+}
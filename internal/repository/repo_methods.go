@@ -2,30 +2,63 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"online-library/internal/logger"
+	"online-library/internal/lyrics"
 	"online-library/internal/models"
+	"sort"
+	"strings"
 )
 
-func (r *PostgresSongRepository) GetFilteredSongs(group string, title string, page int, limit int) ([]models.Song, error) {
+func (r *PostgresSongRepository) GetFilteredSongs(filter SongFilter) (*SongList, error) {
 
-	logger.Log.Debugf("GetFilteredSongs called with group: %s, title: %s, page: %d, limit: %d", group, title, page, limit)
+	logger.Log.Debugf("GetFilteredSongs called with filter: %+v", filter)
 
-	offset := (page - 1) * limit
+	// Формируем SQL запрос с фильтрами, незаданный фильтр не ограничивает выборку
+	var b queryBuilder
+	if err := applySongFilter(&b, filter); err != nil {
+		return nil, err
+	}
+	sortFields, err := effectiveSort(filter.Sort)
+	if err != nil {
+		return nil, err
+	}
 
-	// Формируем SQL запрос с фильтрами
-	query := `
-		SELECT song_id, group_name, song, release_date, lyrics, link
-		FROM songs
-		WHERE (group_name ILIKE $1 OR $1 IS NULL)
-		  AND (song ILIKE $2 OR $2 IS NULL)
-		ORDER BY song
-		LIMIT $3 OFFSET $4
-	`
+	// Общее количество считается без учета курсора и страницы
+	var list SongList
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s %s", songSource, b.whereClause())
+	if err := r.db.QueryRow(countQuery, b.args...).Scan(&list.Total); err != nil {
+		logger.Log.Errorf("Error counting songs: %v", err)
+		return nil, fmt.Errorf("error counting songs: %w", err)
+	}
+
+	// С курсором страница начинается после последней строки предыдущей страницы, иначе - со смещения
+	pagination := ""
+	if filter.Cursor != "" {
+		values, err := decodeCursor(filter.Cursor, sortFields)
+		if err != nil {
+			return nil, err
+		}
+		b.afterCursor(sortFields, values)
+		pagination = "LIMIT " + b.arg(filter.Limit+1)
+	} else {
+		offset := (filter.Page - 1) * filter.Limit
+		pagination = fmt.Sprintf("LIMIT %s OFFSET %s", b.arg(filter.Limit+1), b.arg(offset))
+	}
+
+	// Лишняя строка сверх limit показывает, что есть следующая страница
+	query := fmt.Sprintf(`
+		SELECT %s, %s
+		FROM %s
+		%s
+		%s
+		%s
+	`, songColumns, sortKeyColumns(sortFields), songSource, b.whereClause(), orderByClause(sortFields), pagination)
 
 	// Подготовка аргументов для запроса
-	args := []interface{}{group, title, limit, offset}
+	args := b.args
 
 	logger.Log.Debugf("Executing query: %s with args: %v", query, args)
 
@@ -33,18 +66,30 @@ func (r *PostgresSongRepository) GetFilteredSongs(group string, title string, pa
 	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		logger.Log.Errorf("Error executing query: %v", err)
-		return nil, fmt.Errorf("error executing query: %w", err)
+		return nil, dbError(err, "error executing query")
 	}
 	defer rows.Close()
 
-	var songs []models.Song
+	var lastKeys []string
 	for rows.Next() {
-		var song models.Song
-		if err := rows.Scan(&song.SongID, &song.Group, &song.Song, &song.ReleaseDate, &song.Lyrics, &song.Link); err != nil {
+		keys := make([]string, len(sortFields))
+		keyDest := make([]interface{}, len(keys))
+		for i := range keys {
+			keyDest[i] = &keys[i]
+		}
+
+		song, err := scanSong(rows, keyDest...)
+		if err != nil {
 			logger.Log.Errorf("Error scanning row: %v", err)
 			return nil, fmt.Errorf("error scanning row: %w", err)
 		}
-		songs = append(songs, song)
+
+		if len(list.Songs) == filter.Limit {
+			list.NextCursor = encodeCursor(sortFields, lastKeys)
+			break
+		}
+		list.Songs = append(list.Songs, song)
+		lastKeys = keys
 	}
 
 	// Проверка на ошибки при переборе строк
@@ -53,85 +98,358 @@ func (r *PostgresSongRepository) GetFilteredSongs(group string, title string, pa
 		return nil, fmt.Errorf("error iterating rows: %w", err)
 	}
 
-	return songs, nil
+	return &list, nil
 
 }
 
-func (r *PostgresSongRepository) GetSongLyricsByID(songID int) (string, string, error) {
+func (r *PostgresSongRepository) GetSongByID(songID int) (*models.Song, error) {
+	logger.Log.Debugf("GetSongByID called with songID: %d", songID)
+
+	query := `SELECT ` + songColumns + ` FROM ` + songSource + ` WHERE s.id = $1 AND ` + songAlive
+	song, err := scanSong(r.db.QueryRow(query, songID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logger.Log.Warnf("Song with ID %d not found", songID)
+			return nil, notFoundError("song with ID %d not found", songID)
+		}
+		logger.Log.Errorf("Database error: %v", err)
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	return &song, nil
+}
+
+func (r *PostgresSongRepository) GetSongLyricsByID(songID int) (*models.SongLyrics, error) {
 	logger.Log.Debugf("GetSongLyricsByID called with songID: %d", songID)
 
-	var song string
-	var lyrics sql.NullString // Используем sql.NullString для проверки наличия текста
+	result := models.SongLyrics{SongID: songID}
+	var lyricsText sql.NullString // Используем sql.NullString для проверки наличия текста
+	var structure, synced []byte
 
-	query := "SELECT song, lyrics FROM songs WHERE song_id = $1"
-	err := r.db.QueryRow(query, songID).Scan(&song, &lyrics)
+	query := "SELECT name, lyrics, lyrics_structure, lyrics_synced, version FROM songs WHERE id = $1 AND deleted_at IS NULL"
+	err := r.db.QueryRow(query, songID).Scan(&result.Song, &lyricsText, &structure, &synced, &result.Version)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			logger.Log.Warnf("Song with ID %d not found", songID)
 			// Если песня не найдена
-			return "", "", sql.ErrNoRows
+			return nil, notFoundError("song with ID %d not found", songID)
 		}
 		logger.Log.Errorf("Database error: %v", err)
 		// Любая другая ошибка базы данных
-		return "", "", fmt.Errorf("database error: %w", err)
+		return nil, fmt.Errorf("database error: %w", err)
 	}
 
-	// Если текст песни отсутствует
-	if !lyrics.Valid {
+	if synced != nil {
+		result.Synced = &lyrics.Synced{}
+		if err := json.Unmarshal(synced, result.Synced); err != nil {
+			logger.Log.Errorf("Failed to decode synced lyrics of song ID %d: %v", songID, err)
+			return nil, fmt.Errorf("failed to decode synced lyrics: %w", err)
+		}
+	}
+
+	// Если текст песни отсутствует, возвращаем песню с пустым текстом
+	if !lyricsText.Valid {
 		logger.Log.Infof("Song found, but lyrics are missing for song ID %d", songID)
-		return song, "", nil // Возвращаем песню, но пустой текст
+		return &result, nil
 	}
 
 	logger.Log.Infof("Song found with lyrics for song ID %d", songID)
-	return song, lyrics.String, nil
+	result.Lyrics = lyricsText.String
+	result.Structure = r.lyricsStructure(songID, result.Version, result.Lyrics, structure)
+	return &result, nil
 }
 
-func (r *PostgresSongRepository) AddSong(group, song, releaseDate, text, link string) (int, error) {
-	logger.Log.Debugf("AddSong called with group: %s, song: %s, releaseDate: %s", group, song, releaseDate)
+// lyricsStructure возвращает сохраненный разбор текста песни.
+// Если разбора еще нет (текст только что изменился), текст разбирается и разбор сохраняется.
+func (r *PostgresSongRepository) lyricsStructure(songID int, version int, text string, stored []byte) *lyrics.Lyrics {
+	if stored != nil {
+		var structure lyrics.Lyrics
+		if err := json.Unmarshal(stored, &structure); err == nil {
+			return &structure
+		}
+		logger.Log.Warnf("Stored lyrics structure of song ID %d is invalid, parsing again", songID)
+	}
 
-	query := `INSERT INTO songs (group_name, song, release_date, lyrics, link) 
-			  VALUES ($1, $2, $3, $4, $5)
-			  RETURNING song_id
+	structure := lyrics.Parse(text)
+	data, err := json.Marshal(structure)
+	if err != nil {
+		logger.Log.Errorf("Failed to encode lyrics structure of song ID %d: %v", songID, err)
+		return structure
+	}
+
+	// Версия не меняется: разбор - производные данные, а не изменение песни.
+	// Если песню успели изменить, разбор не сохраняется и будет построен при следующем чтении.
+	_, err = r.db.Exec("UPDATE songs SET lyrics_structure = $1 WHERE id = $2 AND version = $3", string(data), songID, version)
+	if err != nil {
+		logger.Log.Warnf("Failed to store lyrics structure of song ID %d: %v", songID, err)
+	}
+	return structure
+}
+
+func (r *PostgresSongRepository) AddSong(song models.Song) (int, error) {
+	logger.Log.Debugf("AddSong called with group: %s, song: %s, releaseDate: %s", song.Group, song.Song, song.ReleaseDate)
+
+	releaseDate, err := nullDate(song.ReleaseDate)
+	if err != nil {
+		logger.Log.Warnf("Invalid release date for song %q: %v", song.Song, err)
+		return 0, err
+	}
+
+	tx, err := r.begin()
+	if err != nil {
+		logger.Log.Errorf("Failed to begin transaction: %v", err)
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Артист ищется по имени и создается, если его еще нет
+	artistID, err := upsertArtist(tx, song.Group)
+	if err != nil {
+		return 0, err
+	}
+
+	// Песня в состоянии pending сразу попадает в очередь загрузки данных
+	status, err := initialEnrichmentStatus(song)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `INSERT INTO songs (artist_id, name, genre, release_date, lyrics, video, enrichment_status, enrichment_next_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, CASE WHEN $7 = 'pending' THEN now() END)
+			  RETURNING id
 			  `
 	var songID int
-	err := r.db.QueryRow(query, group, song, releaseDate, text, link).Scan(&songID)
+	err = tx.QueryRow(query, artistID, song.Song, nullString(song.Genre), releaseDate,
+		nullString(song.Lyrics), nullString(song.Link), status).Scan(&songID)
 	if err != nil {
 		logger.Log.Errorf("Failed to insert song: %v", err)
-		return 0, fmt.Errorf("failed to insert song: %w", err)
+		return 0, dbError(err, "failed to insert song")
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Errorf("Failed to commit song insert: %v", err)
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 	logger.Log.Infof("Song added successfully with ID %d", songID)
 	return songID, nil
 }
 
-func (r *PostgresSongRepository) UpdateSong(songID int, group, title, releaseDate, text, link string) error {
+func (r *PostgresSongRepository) UpdateSong(songID int, song models.Song, expectedVersion int) error {
 	logger.Log.Debugf("UpdateSong called for songID: %d", songID)
 
+	releaseDate, err := nullDate(song.ReleaseDate)
+	if err != nil {
+		logger.Log.Warnf("Invalid release date for song ID %d: %v", songID, err)
+		return err
+	}
+
+	tx, err := r.begin()
+	if err != nil {
+		logger.Log.Errorf("Failed to begin transaction: %v", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	artistID, err := upsertArtist(tx, song.Group)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE songs
-		SET group_name = $1, song = $2, release_date = $3, lyrics = $4, link = $5
-		WHERE song_id = $6
+		SET artist_id = $1, name = $2, genre = $3, release_date = $4, lyrics = $5, video = $6,
+		    version = version + 1
+		WHERE id = $7 AND deleted_at IS NULL AND ($8 = 0 OR version = $8)
 		`
-	_, err := r.db.Exec(query, group, title, releaseDate, text, link, songID)
+	result, err := tx.Exec(query, artistID, song.Song, nullString(song.Genre), releaseDate,
+		nullString(song.Lyrics), nullString(song.Link), songID, expectedVersion)
 	if err != nil {
 		logger.Log.Errorf("Failed to update song ID %d: %v", songID, err)
+		return dbError(err, "failed to update song")
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.Log.Errorf("Failed to get affected rows for song ID %d: %v", songID, err)
 		return fmt.Errorf("failed to update song: %w", err)
 	}
+	if affected == 0 {
+		return songWriteError(tx, songID, expectedVersion)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Errorf("Failed to commit update of song ID %d: %v", songID, err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 
 	logger.Log.Infof("Song with ID %d updated successfully", songID)
 	return nil
 }
 
-func (r *PostgresSongRepository) DeleteSong(songID int) error {
+func (r *PostgresSongRepository) PatchSong(songID int, patch models.SongPatch, expectedVersion int) (*models.Song, error) {
+	logger.Log.Debugf("PatchSong called for songID: %d with %d fields", songID, len(patch))
+
+	tx, err := r.begin()
+	if err != nil {
+		logger.Log.Errorf("Failed to begin transaction: %v", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := applySongPatch(tx, songID, patch, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + songColumns + ` FROM ` + songSource + ` WHERE s.id = $1 AND ` + songAlive
+	song, err := scanSong(tx.QueryRow(query, songID))
+	if err != nil {
+		logger.Log.Errorf("Failed to read patched song ID %d: %v", songID, err)
+		return nil, fmt.Errorf("failed to read patched song: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Errorf("Failed to commit patch of song ID %d: %v", songID, err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	logger.Log.Infof("Song with ID %d patched successfully", songID)
+	return &song, nil
+}
+
+// applySongPatch обновляет только перечисленные в patch колонки песни.
+// Если песни нет или ее версия не равна expectedVersion (при expectedVersion > 0),
+// возвращается ошибка вида ErrNotFound или ErrVersionMismatch.
+func applySongPatch(tx *sql.Tx, songID int, patch models.SongPatch, expectedVersion int) error {
+	return updateSongColumns(tx, songID, patch, patchableColumns, expectedVersion)
+}
+
+// updateSongColumns - applySongPatch с явным набором полей, которые разрешено менять
+func updateSongColumns(tx *sql.Tx, songID int, patch models.SongPatch, columns map[string]string, expectedVersion int) error {
+	fields := make([]string, 0, len(patch))
+	for field := range patch {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields) // стабильный текст запроса
+
+	var sets []string
+	var args []interface{}
+	for _, field := range fields {
+		value := patch[field]
+
+		if field == "group" {
+			if value == nil || *value == "" {
+				return validationError("group cannot be empty")
+			}
+			artistID, err := upsertArtist(tx, *value)
+			if err != nil {
+				return err
+			}
+			args = append(args, artistID)
+			sets = append(sets, fmt.Sprintf("artist_id = $%d", len(args)))
+			continue
+		}
+
+		column, ok := columns[field]
+		if !ok {
+			return validationError("field %q cannot be patched", field)
+		}
+
+		switch {
+		case field == "song":
+			if value == nil || *value == "" {
+				return validationError("song cannot be empty")
+			}
+			args = append(args, *value)
+		case value == nil:
+			args = append(args, nil)
+		case field == "release_date":
+			releaseDate, err := nullDate(*value)
+			if err != nil {
+				return err
+			}
+			args = append(args, releaseDate)
+		default:
+			args = append(args, nullString(*value))
+		}
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	// Пустой патч ничего не меняет, но песня все равно должна существовать и иметь ожидаемую версию
+	if len(sets) == 0 {
+		var version int
+		err := tx.QueryRow("SELECT version FROM songs WHERE id = $1 AND deleted_at IS NULL", songID).Scan(&version)
+		if err != nil || (expectedVersion > 0 && version != expectedVersion) {
+			return songWriteError(tx, songID, expectedVersion)
+		}
+		return nil
+	}
+
+	sets = append(sets, "version = version + 1")
+	args = append(args, songID, expectedVersion)
+	query := fmt.Sprintf("UPDATE songs SET %s WHERE id = $%d AND deleted_at IS NULL AND ($%d = 0 OR version = $%d)",
+		strings.Join(sets, ", "), len(args)-1, len(args), len(args))
+
+	logger.Log.Debugf("Executing query: %s with args: %v", query, args)
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		logger.Log.Errorf("Failed to patch song ID %d: %v", songID, err)
+		return dbError(err, "failed to patch song")
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.Log.Errorf("Failed to get affected rows for song ID %d: %v", songID, err)
+		return fmt.Errorf("failed to patch song: %w", err)
+	}
+	if affected == 0 {
+		return songWriteError(tx, songID, expectedVersion)
+	}
+	return nil
+}
+
+func (r *PostgresSongRepository) DeleteSong(songID int, expectedVersion int) error {
 	logger.Log.Debugf("DeleteSong called for songID: %d", songID)
 
+	tx, err := r.begin()
+	if err != nil {
+		logger.Log.Errorf("Failed to begin transaction: %v", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := deleteSong(tx, songID, expectedVersion); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Errorf("Failed to commit delete of song ID %d: %v", songID, err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	logger.Log.Infof("Song with ID %d moved to trash", songID)
+	return nil
+}
+
+// deleteSong перемещает песню в корзину через db или транзакцию
+func deleteSong(q execer, songID int, expectedVersion int) error {
 	query := `
-		DELETE FROM songs
-		WHERE song_id = $1
+		UPDATE songs
+		SET deleted_at = now(), version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)
 	`
-	_, err := r.db.Exec(query, songID)
+	result, err := q.Exec(query, songID, expectedVersion)
 	if err != nil {
 		logger.Log.Errorf("Failed to delete song ID %d: %v", songID, err)
 		return fmt.Errorf("failed to delete song: %w", err)
 	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.Log.Errorf("Failed to get affected rows for song ID %d: %v", songID, err)
+		return fmt.Errorf("failed to delete song: %w", err)
+	}
+	if affected == 0 {
+		return songWriteError(q, songID, expectedVersion)
+	}
 	return nil
 }
@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"online-library/internal/logger"
+	"online-library/internal/models"
+)
+
+// RevisionList страница истории изменений песни
+type RevisionList struct {
+	Revisions []models.SongRevision
+	Total     int // сколько всего ревизий у песни
+}
+
+// GetSongRevisions возвращает историю изменений песни, последние изменения идут первыми.
+// История доступна и для песен в корзине.
+func (r *PostgresSongRepository) GetSongRevisions(songID int, page int, limit int) (*RevisionList, error) {
+	logger.Log.Debugf("GetSongRevisions called for songID: %d, page: %d, limit: %d", songID, page, limit)
+
+	if err := songExists(r.db, songID); err != nil {
+		return nil, err
+	}
+
+	var list RevisionList
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM song_revisions WHERE song_id = $1", songID).Scan(&list.Total); err != nil {
+		logger.Log.Errorf("Error counting revisions of song ID %d: %v", songID, err)
+		return nil, fmt.Errorf("error counting revisions: %w", err)
+	}
+
+	query := `
+		SELECT id, song_id, version, action, author,
+		       to_char(changed_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+		       old_values, new_values
+		FROM song_revisions
+		WHERE song_id = $1
+		ORDER BY id DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.db.Query(query, songID, limit, (page-1)*limit)
+	if err != nil {
+		logger.Log.Errorf("Error executing query: %v", err)
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			revision         models.SongRevision
+			oldJSON, newJSON []byte
+		)
+		err := rows.Scan(&revision.RevisionID, &revision.SongID, &revision.Version, &revision.Action,
+			&revision.Author, &revision.ChangedAt, &oldJSON, &newJSON)
+		if err != nil {
+			logger.Log.Errorf("Error scanning row: %v", err)
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		if revision.Old, err = decodeValues(oldJSON); err != nil {
+			return nil, err
+		}
+		if revision.New, err = decodeValues(newJSON); err != nil {
+			return nil, err
+		}
+		list.Revisions = append(list.Revisions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Log.Errorf("Error iterating rows: %v", err)
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return &list, nil
+}
+
+// GetRevisionState возвращает поля песни в том виде, в каком они были сразу после ревизии
+func (r *PostgresSongRepository) GetRevisionState(songID int, revisionID int64) (models.SongPatch, error) {
+	logger.Log.Debugf("GetRevisionState called for songID: %d, revisionID: %d", songID, revisionID)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		logger.Log.Errorf("Failed to begin transaction: %v", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Снимок песни и последующие ревизии должны читаться согласованно
+	if _, err := tx.Exec("SET TRANSACTION ISOLATION LEVEL REPEATABLE READ"); err != nil {
+		return nil, fmt.Errorf("failed to set isolation level: %w", err)
+	}
+	return revisionState(tx, songID, revisionID, false)
+}
+
+// RevertSong возвращает полям песни значения, которые были у них сразу после ревизии.
+// Откат записывается в историю как новая ревизия с действием revert.
+func (r *PostgresSongRepository) RevertSong(songID int, revisionID int64, expectedVersion int) (*models.Song, error) {
+	logger.Log.Debugf("RevertSong called for songID: %d, revisionID: %d", songID, revisionID)
+
+	tx, err := r.begin()
+	if err != nil {
+		logger.Log.Errorf("Failed to begin transaction: %v", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT set_config('app.action', $1, true)", models.RevisionRevert); err != nil {
+		return nil, fmt.Errorf("failed to set revision action: %w", err)
+	}
+
+	state, err := revisionState(tx, songID, revisionID, true)
+	if err != nil {
+		return nil, err
+	}
+	current, err := revisionState(tx, songID, 0, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Меняются только поля, отличающиеся от текущих
+	patch := make(models.SongPatch)
+	for field, value := range state {
+		if !sameValue(value, current[field]) {
+			patch[field] = value
+		}
+	}
+
+	if err := updateSongColumns(tx, songID, patch, revertableColumns, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + songColumns + ` FROM ` + songSource + ` WHERE s.id = $1 AND ` + songAlive
+	song, err := scanSong(tx.QueryRow(query, songID))
+	if err != nil {
+		logger.Log.Errorf("Failed to read reverted song ID %d: %v", songID, err)
+		return nil, fmt.Errorf("failed to read reverted song: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Errorf("Failed to commit revert of song ID %d: %v", songID, err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	logger.Log.Infof("Song with ID %d reverted to revision %d (%d fields changed)", songID, revisionID, len(patch))
+	return &song, nil
+}
+
+// revisionState восстанавливает поля песни на момент сразу после ревизии revisionID:
+// к текущему снимку в обратном порядке применяются прежние значения всех более поздних ревизий (rewind).
+// revisionID = 0 возвращает текущий снимок. lock блокирует строку песни до конца транзакции.
+func revisionState(tx *sql.Tx, songID int, revisionID int64, lock bool) (models.SongPatch, error) {
+	query := "SELECT song_snapshot(s) FROM songs s WHERE s.id = $1"
+	if lock {
+		query += " FOR UPDATE"
+	}
+	var snapshot []byte
+	err := tx.QueryRow(query, songID).Scan(&snapshot)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, notFoundError("song with ID %d not found", songID)
+	}
+	if err != nil {
+		logger.Log.Errorf("Failed to read snapshot of song ID %d: %v", songID, err)
+		return nil, fmt.Errorf("failed to read song snapshot: %w", err)
+	}
+	state, err := decodeValues(snapshot)
+	if err != nil || revisionID == 0 {
+		return state, err
+	}
+
+	var found bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM song_revisions WHERE id = $1 AND song_id = $2)",
+		revisionID, songID).Scan(&found)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check revision: %w", err)
+	}
+	if !found {
+		return nil, notFoundError("revision %d of song with ID %d not found", revisionID, songID)
+	}
+
+	rows, err := tx.Query(`
+		SELECT action, old_values FROM song_revisions
+		WHERE song_id = $1 AND id > $2 AND old_values IS NOT NULL
+		ORDER BY id DESC
+	`, songID, revisionID)
+	if err != nil {
+		logger.Log.Errorf("Failed to read revisions of song ID %d: %v", songID, err)
+		return nil, fmt.Errorf("failed to read revisions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			action  string
+			oldJSON []byte
+		)
+		if err := rows.Scan(&action, &oldJSON); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		old, err := decodeValues(oldJSON)
+		if err != nil {
+			return nil, err
+		}
+		rewind(state, action, old)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return state, nil
+}
+
+// rewind возвращает полям state значения old, которые были у них до ревизии с действием action.
+// Переименование артиста не отменяется: песня остается у того же артиста под его текущим именем,
+// иначе откат создал бы артиста со старым именем и перенес к нему одну песню.
+func rewind(state models.SongPatch, action string, old models.SongPatch) {
+	if action == models.RevisionArtistRename {
+		return
+	}
+	for field, value := range old {
+		state[field] = value
+	}
+}
+
+// songExists проверяет, что песня есть в каталоге или в корзине
+func songExists(q querier, songID int) error {
+	var exists bool
+	if err := q.QueryRow("SELECT EXISTS (SELECT 1 FROM songs WHERE id = $1)", songID).Scan(&exists); err != nil {
+		logger.Log.Errorf("Failed to check song ID %d: %v", songID, err)
+		return fmt.Errorf("failed to check song: %w", err)
+	}
+	if !exists {
+		return notFoundError("song with ID %d not found", songID)
+	}
+	return nil
+}
+
+// decodeValues разбирает JSONB с полями песни; NULL дает nil
+func decodeValues(data []byte) (models.SongPatch, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var values models.SongPatch
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to decode revision values: %w", err)
+	}
+	return values, nil
+}
+
+// sameValue сравнивает значения полей песни, nil равен только nil
+func sameValue(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"testing"
+
+	"online-library/internal/models"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestRewindPastArtistRename(t *testing.T) {
+	// Текущее состояние: артист переименован из Old в New, перед этим у песни сменили жанр
+	state := models.SongPatch{"group": strPtr("New"), "song": strPtr("Song"), "genre": strPtr("rock")}
+
+	// Ревизии после той, к которой откатываемся, в обратном порядке
+	rewind(state, models.RevisionArtistRename, models.SongPatch{"group": strPtr("Old")})
+	rewind(state, models.RevisionUpdate, models.SongPatch{"genre": strPtr("pop")})
+
+	// Откат возвращает жанр, но оставляет песню у того же артиста под текущим именем
+	if got := *state["group"]; got != "New" {
+		t.Errorf("group = %q, want New", got)
+	}
+	if got := *state["genre"]; got != "pop" {
+		t.Errorf("genre = %q, want pop", got)
+	}
+}
+
+func TestRewindRestoresGroupChange(t *testing.T) {
+	// Перенос песни к другому артисту - обычное изменение, откат его отменяет
+	state := models.SongPatch{"group": strPtr("Other")}
+	rewind(state, models.RevisionUpdate, models.SongPatch{"group": strPtr("Original")})
+	if got := *state["group"]; got != "Original" {
+		t.Errorf("group = %q, want Original", got)
+	}
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"fmt"
+	"html"
+	"online-library/internal/logger"
+	"online-library/internal/models"
+	"strings"
+)
+
+// Границы совпадений во фрагменте ts_headline. Вместо <b></b> используются управляющие символы,
+// чтобы экранировать текст песни после выделения, а теги добавить уже к экранированному тексту.
+const (
+	headlineStart = "\x02"
+	headlineStop  = "\x03"
+)
+
+// searchHeadlineOptions настройки фрагментов ts_headline в результатах поиска
+const searchHeadlineOptions = "MaxFragments=2, MaxWords=20, MinWords=5, FragmentDelimiter=\" ... \", " +
+	"StartSel=\"" + headlineStart + "\", StopSel=\"" + headlineStop + "\""
+
+// highlightReplacer переводит границы совпадений в теги <b></b>
+var highlightReplacer = strings.NewReplacer(headlineStart, "<b>", headlineStop, "</b>")
+
+// searchSnippet экранирует фрагмент текста песни и выделяет в нем совпадения тегами <b></b>
+func searchSnippet(headline string) string {
+	return highlightReplacer.Replace(html.EscapeString(headline))
+}
+
+// SongSearchList страница результатов полнотекстового поиска
+type SongSearchList struct {
+	Results []models.SongSearchResult
+	Total   int // сколько всего песен найдено
+}
+
+// searchCondition - условие совпадения песни с запросом tsq; песни из корзины не ищутся.
+// Вектор песни уже включает имя артиста (миграция 0004), поэтому условие обслуживает индекс
+// idx_songs_search_vector. Проверка на заполненном каталоге: в плане EXPLAIN запроса поиска
+// должен быть Bitmap Index Scan on idx_songs_search_vector, а не Seq Scan on songs.
+const searchCondition = "s.search_vector @@ tsq AND " + songAlive
+
+func (r *PostgresSongRepository) SearchSongs(text string, page int, limit int) (*SongSearchList, error) {
+	logger.Log.Debugf("SearchSongs called with query: %s, page: %d, limit: %d", text, page, limit)
+
+	offset := (page - 1) * limit
+
+	var list SongSearchList
+	countQuery := `SELECT COUNT(*) FROM ` + songSource + `, websearch_to_tsquery('simple', $1) AS tsq WHERE ` + searchCondition
+	if err := r.db.QueryRow(countQuery, text).Scan(&list.Total); err != nil {
+		logger.Log.Errorf("Error counting search results: %v", err)
+		return nil, fmt.Errorf("error counting search results: %w", err)
+	}
+
+	// Вес A - название, B - группа, C - текст; ранг считается только для найденных песен
+	query := `
+		SELECT ` + songColumns + `,
+			ts_rank(s.search_vector, tsq) AS rank,
+			ts_headline('simple', COALESCE(NULLIF(s.lyrics, ''), s.name), tsq, $4) AS snippet
+		FROM ` + songSource + `, websearch_to_tsquery('simple', $1) AS tsq
+		WHERE ` + searchCondition + `
+		ORDER BY rank DESC, s.id
+		LIMIT $2 OFFSET $3
+	`
+	args := []interface{}{text, limit, offset, searchHeadlineOptions}
+
+	logger.Log.Debugf("Executing query: %s with args: %v", query, args)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		logger.Log.Errorf("Error executing query: %v", err)
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var result models.SongSearchResult
+		song, err := scanSong(rows, &result.Rank, &result.Snippet)
+		if err != nil {
+			logger.Log.Errorf("Error scanning row: %v", err)
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		result.Song = song
+		result.Snippet = searchSnippet(result.Snippet)
+		list.Results = append(list.Results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Log.Errorf("Error iterating rows: %v", err)
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return &list, nil
+}
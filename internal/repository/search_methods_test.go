@@ -0,0 +1,11 @@
+package repository
+
+import "testing"
+
+func TestSearchSnippetEscapesLyrics(t *testing.T) {
+	headline := `<script>alert(1)</script> & ` + headlineStart + `rhapsody` + headlineStop + ` "again"`
+	want := `&lt;script&gt;alert(1)&lt;/script&gt; &amp; <b>rhapsody</b> &#34;again&#34;`
+	if got := searchSnippet(headline); got != want {
+		t.Errorf("searchSnippet() = %q, want %q", got, want)
+	}
+}
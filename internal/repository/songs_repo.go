@@ -1,22 +1,92 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"iter"
+	"online-library/internal/lyrics"
 	"online-library/internal/models"
+	"time"
 )
 
 type SongRepository interface {
-	GetSongLyricsByID(songID int) (string, string, error) //возвращаем и название песни для удобства пользователя
-	GetFilteredSongs(group string, title string, page int, limit int) ([]models.Song, error)
-	AddSong(group, song, releaseDate, text, link string) (int, error)
-	UpdateSong(songID int, group, title, releaseDate, text, link string) error
-	DeleteSong(songID int) error
+	GetSongLyricsByID(songID int) (*models.SongLyrics, error) //возвращаем и название песни для удобства пользователя
+	// Переводы текста: GetSongLyricsIn отдает перевод на первый найденный язык из langs, иначе оригинал
+	GetSongLyricsIn(songID int, langs []string) (*models.SongLyrics, error)
+	GetTranslations(songID int) ([]models.LyricsTranslation, error)
+	PutTranslation(songID int, lang string, text string) (*models.LyricsTranslation, bool, error) //true - перевод добавлен
+	DeleteTranslation(songID int, lang string) error
+	GetSongByID(songID int) (*models.Song, error)
+	GetFilteredSongs(filter SongFilter) (*SongList, error)
+	SearchSongs(query string, page int, limit int) (*SongSearchList, error) //полнотекстовый поиск по тексту, названию и группе
+	AddSong(song models.Song) (int, error)
+	// expectedVersion - ожидаемая версия песни (If-Match), 0 отключает проверку
+	UpdateSong(songID int, song models.Song, expectedVersion int) error
+	PatchSong(songID int, patch models.SongPatch, expectedVersion int) (*models.Song, error) //возвращает песню после изменения
+	// DeleteSong перемещает песню в корзину, GetDeletedSongs и RestoreSong работают с корзиной
+	DeleteSong(songID int, expectedVersion int) error
+	GetDeletedSongs(page int, limit int) (*SongList, error)
+	RestoreSong(songID int) (*models.Song, error)
+	// Пакетные операции выполняются одной транзакцией, atomic отменяет все изменения при ошибке по любой песне
+	PatchSongs(target BatchTarget, patch models.SongPatch, atomic bool) ([]BatchResult, error)
+	DeleteSongs(target BatchTarget, atomic bool) ([]BatchResult, error)
+	// SetSyncedLyrics сохраняет текст с временными метками, nil удаляет его; возвращает новую версию песни
+	SetSyncedLyrics(songID int, synced *lyrics.Synced, expectedVersion int) (int, error)
+	RequeueEnrichment(songID int) (*models.Song, error) //повторная загрузка данных из внешнего API
+	ImportSongs(rows []ImportRow, dryRun bool) ([]ImportResult, error)
+	ExportSongs(ctx context.Context, filter SongFilter) iter.Seq2[models.Song, error] //перебор без загрузки всего каталога в память
+	// История изменений: ревизии записываются при каждом изменении песни
+	GetSongRevisions(songID int, page int, limit int) (*RevisionList, error)
+	GetRevisionState(songID int, revisionID int64) (models.SongPatch, error) //поля песни сразу после ревизии
+	RevertSong(songID int, revisionID int64, expectedVersion int) (*models.Song, error)
+	// WithAuthor возвращает репозиторий, изменения через который записываются в историю от имени author
+	WithAuthor(author string) SongRepository
+}
+
+// TrashRepository окончательное удаление песен из корзины
+type TrashRepository interface {
+	PurgeDeletedSongs(retention time.Duration) (int64, error)
+}
+
+// EnrichmentRepository очередь загрузки данных песен из внешнего API
+type EnrichmentRepository interface {
+	ClaimEnrichments(limit int, lease time.Duration) ([]models.EnrichmentTask, error)
+	// Complete и Fail возвращают ErrLeaseLost, если захват task истек и песню взял другой обработчик
+	CompleteEnrichment(task models.EnrichmentTask, detail models.SongDetail) error
+	// retryAt - когда повторить попытку, dead - попытки исчерпаны
+	FailEnrichment(task models.EnrichmentTask, reason string, retryAt time.Time, dead bool) error
 }
 
 type PostgresSongRepository struct {
-	db *sql.DB
+	db     *sql.DB
+	author string //автор изменений для истории, пустой - system
 }
 
 func NewPostgresSongRepository(db *sql.DB) *PostgresSongRepository {
 	return &PostgresSongRepository{db: db}
 }
+
+// WithAuthor возвращает копию репозитория, которая подписывает изменения именем author
+func (r *PostgresSongRepository) WithAuthor(author string) SongRepository {
+	return &PostgresSongRepository{db: r.db, author: author}
+}
+
+// begin открывает транзакцию записи и передает триггеру истории автора изменений
+func (r *PostgresSongRepository) begin() (*sql.Tx, error) {
+	return beginAs(r.db, r.author)
+}
+
+// beginAs открывает транзакцию, изменения в которой записываются в историю от имени author
+func beginAs(db *sql.DB, author string) (*sql.Tx, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	if author != "" {
+		if _, err := tx.Exec("SELECT set_config('app.author', $1, true)", author); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	return tx, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"online-library/internal/logger"
+	"online-library/internal/lyrics"
+)
+
+// ErrNoSyncedLyrics - у песни нет текста с временными метками; ошибка также имеет вид ErrNotFound
+var ErrNoSyncedLyrics = errors.New("song has no synced lyrics")
+
+// SetSyncedLyrics сохраняет текст песни с временными метками.
+// Если у песни еще нет обычного текста, он заполняется строками из synced. nil удаляет метки, не трогая текст;
+// если меток нет, версия не меняется и возвращается ErrNoSyncedLyrics.
+func (r *PostgresSongRepository) SetSyncedLyrics(songID int, synced *lyrics.Synced, expectedVersion int) (int, error) {
+	logger.Log.Debugf("SetSyncedLyrics called for songID: %d", songID)
+
+	var data, plain interface{} // NULL для удаления
+	if synced != nil {
+		encoded, err := json.Marshal(synced)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode synced lyrics: %w", err)
+		}
+		data, plain = string(encoded), nullString(synced.PlainText())
+	}
+
+	tx, err := r.begin()
+	if err != nil {
+		logger.Log.Errorf("Failed to begin transaction: %v", err)
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE songs
+		SET lyrics_synced = $1, lyrics = COALESCE(NULLIF(lyrics, ''), $2), version = version + 1
+		WHERE id = $3 AND deleted_at IS NULL AND ($4 = 0 OR version = $4)
+			AND ($1::jsonb IS NOT NULL OR lyrics_synced IS NOT NULL)
+		RETURNING version
+	`
+	var version int
+	err = tx.QueryRow(query, data, plain, songID, expectedVersion).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		if synced == nil {
+			return 0, deleteSyncedError(tx, songID, expectedVersion)
+		}
+		return 0, songWriteError(tx, songID, expectedVersion)
+	}
+	if err != nil {
+		logger.Log.Errorf("Failed to store synced lyrics of song ID %d: %v", songID, err)
+		return 0, dbError(err, "failed to store synced lyrics")
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Errorf("Failed to commit synced lyrics of song ID %d: %v", songID, err)
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if synced == nil {
+		logger.Log.Infof("Synced lyrics of song ID %d removed", songID)
+	} else {
+		logger.Log.Infof("Synced lyrics of song ID %d stored: %d lines", songID, len(synced.Lines))
+	}
+	return version, nil
+}
+
+// deleteSyncedError объясняет, почему удаление меток не изменило песню: ее нет, версия не совпала или меток нет
+func deleteSyncedError(tx *sql.Tx, songID int, expectedVersion int) error {
+	var (
+		version   int
+		hasSynced bool
+	)
+	err := tx.QueryRow("SELECT version, lyrics_synced IS NOT NULL FROM songs WHERE id = $1 AND deleted_at IS NULL",
+		songID).Scan(&version, &hasSynced)
+	if err != nil || (expectedVersion > 0 && version != expectedVersion) || hasSynced {
+		return songWriteError(tx, songID, expectedVersion)
+	}
+	logger.Log.Warnf("Song with ID %d has no synced lyrics to delete", songID)
+	return &Error{Kind: ErrNotFound, Message: fmt.Sprintf("song with ID %d has no synced lyrics", songID), Err: ErrNoSyncedLyrics}
+}
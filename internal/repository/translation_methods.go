@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"online-library/internal/logger"
+	"online-library/internal/lyrics"
+	"online-library/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// translationUpdatedAt - время изменения перевода в формате RFC 3339
+const translationUpdatedAt = `to_char(updated_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')`
+
+// GetSongLyricsIn возвращает текст песни на первом из языков langs, для которого есть перевод.
+// Если перевода нет ни на один из языков, возвращается оригинальный текст.
+func (r *PostgresSongRepository) GetSongLyricsIn(songID int, langs []string) (*models.SongLyrics, error) {
+	logger.Log.Debugf("GetSongLyricsIn called for songID: %d, langs: %v", songID, langs)
+
+	result, err := r.GetSongLyricsByID(songID)
+	if err != nil || len(langs) == 0 {
+		return result, err
+	}
+
+	query := `
+		SELECT lang, lyrics, version FROM song_lyrics
+		WHERE song_id = $1 AND lang = ANY($2::text[])
+		ORDER BY array_position($2::text[], lang::text)
+		LIMIT 1
+	`
+	var (
+		lang, text string
+		version    int64
+	)
+	err = r.db.QueryRow(query, songID, pq.Array(langs)).Scan(&lang, &text, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Log.Debugf("No translation of song ID %d for %v, using original lyrics", songID, langs)
+		return result, nil
+	}
+	if err != nil {
+		logger.Log.Errorf("Failed to fetch translation of song ID %d: %v", songID, err)
+		return nil, fmt.Errorf("failed to fetch translation: %w", err)
+	}
+
+	result.Lang = lang
+	result.LangVersion = version
+	result.Lyrics = text
+	result.Structure = lyrics.Parse(text)
+	return result, nil
+}
+
+// GetTranslations возвращает все переводы текста песни, упорядоченные по языку
+func (r *PostgresSongRepository) GetTranslations(songID int) ([]models.LyricsTranslation, error) {
+	logger.Log.Debugf("GetTranslations called for songID: %d", songID)
+
+	var alive bool
+	err := r.db.QueryRow("SELECT true FROM songs WHERE id = $1 AND deleted_at IS NULL", songID).Scan(&alive)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, notFoundError("song with ID %d not found", songID)
+	}
+	if err != nil {
+		logger.Log.Errorf("Failed to check song ID %d: %v", songID, err)
+		return nil, fmt.Errorf("failed to check song: %w", err)
+	}
+
+	rows, err := r.db.Query(`SELECT lang, lyrics, version, `+translationUpdatedAt+` FROM song_lyrics WHERE song_id = $1 ORDER BY lang`, songID)
+	if err != nil {
+		logger.Log.Errorf("Error executing query: %v", err)
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	translations := []models.LyricsTranslation{}
+	for rows.Next() {
+		var translation models.LyricsTranslation
+		if err := rows.Scan(&translation.Lang, &translation.Lyrics, &translation.Version, &translation.UpdatedAt); err != nil {
+			logger.Log.Errorf("Error scanning row: %v", err)
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		translations = append(translations, translation)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Log.Errorf("Error iterating rows: %v", err)
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return translations, nil
+}
+
+// PutTranslation добавляет или заменяет перевод текста песни на язык lang
+func (r *PostgresSongRepository) PutTranslation(songID int, lang string, text string) (*models.LyricsTranslation, bool, error) {
+	logger.Log.Debugf("PutTranslation called for songID: %d, lang: %s", songID, lang)
+
+	// Перевод сохраняется, только если песня есть и не в корзине; xmax = 0 у только что вставленной строки
+	query := `
+		INSERT INTO song_lyrics (song_id, lang, lyrics)
+		SELECT id, $2, $3 FROM songs WHERE id = $1 AND deleted_at IS NULL
+		ON CONFLICT (song_id, lang) DO UPDATE
+		SET lyrics = EXCLUDED.lyrics, version = nextval('song_lyrics_version_seq'), updated_at = now()
+		RETURNING xmax = 0, version, ` + translationUpdatedAt
+	translation := models.LyricsTranslation{Lang: lang, Lyrics: text}
+	var created bool
+	err := r.db.QueryRow(query, songID, lang, text).Scan(&created, &translation.Version, &translation.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Log.Warnf("Song with ID %d not found", songID)
+		return nil, false, notFoundError("song with ID %d not found", songID)
+	}
+	if err != nil {
+		logger.Log.Errorf("Failed to store translation %s of song ID %d: %v", lang, songID, err)
+		return nil, false, dbError(err, "failed to store translation")
+	}
+
+	logger.Log.Infof("Translation %s of song ID %d stored (created: %v)", lang, songID, created)
+	return &translation, created, nil
+}
+
+// DeleteTranslation удаляет перевод текста песни
+func (r *PostgresSongRepository) DeleteTranslation(songID int, lang string) error {
+	logger.Log.Debugf("DeleteTranslation called for songID: %d, lang: %s", songID, lang)
+
+	result, err := r.db.Exec("DELETE FROM song_lyrics WHERE song_id = $1 AND lang = $2", songID, lang)
+	if err != nil {
+		logger.Log.Errorf("Failed to delete translation %s of song ID %d: %v", lang, songID, err)
+		return fmt.Errorf("failed to delete translation: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete translation: %w", err)
+	}
+	if affected == 0 {
+		return notFoundError("translation %s of song with ID %d not found", lang, songID)
+	}
+
+	logger.Log.Infof("Translation %s of song ID %d deleted", lang, songID)
+	return nil
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"online-library/internal/logger"
+	"online-library/internal/models"
+	"time"
+)
+
+// GetDeletedSongs возвращает страницу корзины, последние удаленные песни идут первыми
+func (r *PostgresSongRepository) GetDeletedSongs(page int, limit int) (*SongList, error) {
+	logger.Log.Debugf("GetDeletedSongs called with page: %d, limit: %d", page, limit)
+
+	var list SongList
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM songs WHERE deleted_at IS NOT NULL").Scan(&list.Total); err != nil {
+		logger.Log.Errorf("Error counting deleted songs: %v", err)
+		return nil, fmt.Errorf("error counting deleted songs: %w", err)
+	}
+
+	query := `
+		SELECT ` + songColumns + `
+		FROM ` + songSource + `
+		WHERE s.deleted_at IS NOT NULL
+		ORDER BY s.deleted_at DESC, s.id DESC
+		LIMIT $1 OFFSET $2
+	`
+	rows, err := r.db.Query(query, limit, (page-1)*limit)
+	if err != nil {
+		logger.Log.Errorf("Error executing query: %v", err)
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		song, err := scanSong(rows)
+		if err != nil {
+			logger.Log.Errorf("Error scanning row: %v", err)
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		list.Songs = append(list.Songs, song)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Log.Errorf("Error iterating rows: %v", err)
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return &list, nil
+}
+
+// RestoreSong возвращает песню из корзины
+func (r *PostgresSongRepository) RestoreSong(songID int) (*models.Song, error) {
+	logger.Log.Debugf("RestoreSong called for songID: %d", songID)
+
+	query := `
+		UPDATE songs
+		SET deleted_at = NULL, version = version + 1
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`
+	tx, err := r.begin()
+	if err != nil {
+		logger.Log.Errorf("Failed to begin transaction: %v", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, songID)
+	if err != nil {
+		logger.Log.Errorf("Failed to restore song ID %d: %v", songID, err)
+		return nil, fmt.Errorf("failed to restore song: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.Log.Errorf("Failed to get affected rows for song ID %d: %v", songID, err)
+		return nil, fmt.Errorf("failed to restore song: %w", err)
+	}
+
+	query = `SELECT ` + songColumns + ` FROM ` + songSource + ` WHERE s.id = $1 AND ` + songAlive
+	song, err := scanSong(tx.QueryRow(query, songID))
+	if errors.Is(err, sql.ErrNoRows) {
+		// Песни нет ни в каталоге, ни в корзине: например, ее уже удалили окончательно
+		return nil, notFoundError("song with ID %d not found in trash", songID)
+	}
+	if err != nil {
+		logger.Log.Errorf("Failed to read restored song ID %d: %v", songID, err)
+		return nil, fmt.Errorf("failed to read restored song: %w", err)
+	}
+	if affected == 0 {
+		logger.Log.Warnf("Song with ID %d is not in trash", songID)
+		return nil, conflictError(nil, "song with ID %d is not in trash", songID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Errorf("Failed to commit restore of song ID %d: %v", songID, err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	logger.Log.Infof("Song with ID %d restored from trash", songID)
+	return &song, nil
+}
+
+// PurgeDeletedSongs окончательно удаляет песни, пролежавшие в корзине дольше retention
+func (r *PostgresSongRepository) PurgeDeletedSongs(retention time.Duration) (int64, error) {
+	logger.Log.Debugf("PurgeDeletedSongs called with retention: %v", retention)
+
+	result, err := r.db.Exec(
+		"DELETE FROM songs WHERE deleted_at < now() - make_interval(secs => $1)", retention.Seconds())
+	if err != nil {
+		logger.Log.Errorf("Failed to purge deleted songs: %v", err)
+		return 0, fmt.Errorf("failed to purge deleted songs: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted songs: %w", err)
+	}
+	if purged > 0 {
+		logger.Log.Infof("Purged %d songs from trash", purged)
+	}
+	return purged, nil
+}
@@ -0,0 +1,92 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	externalapi "online-library/external_api"
+	"online-library/internal/logger"
+	"online-library/internal/models"
+
+	"github.com/spf13/viper"
+)
+
+// NewExternalAPI собирает источник данных о песнях по конфигурации:
+// цепочку источников из EXTERNAL_API_PROVIDERS, обернутую кэшем
+func NewExternalAPI(db *sql.DB) (externalapi.ExternalAPI, error) {
+	providers, err := newProviderRegistry().Build(strings.Split(viper.GetString("EXTERNAL_API_PROVIDERS"), ","))
+	if err != nil {
+		return nil, err
+	}
+
+	// Кэш стоит перед цепочкой: попадание в кэш не расходует попытки и не влияет на размыкатель
+	var store externalapi.CacheStore
+	memory := externalapi.NewMemoryCache(viper.GetInt("EXTERNAL_API_CACHE_SIZE"))
+	switch backend := viper.GetString("EXTERNAL_API_CACHE"); backend {
+	case "off":
+		logger.Log.Info("External API cache disabled")
+		return providers, nil
+	case "postgres":
+		postgres := externalapi.NewPostgresCache(db)
+		go func() {
+			if n, err := postgres.Purge(context.Background()); err != nil {
+				logger.Log.Errorf("Failed to purge external API cache: %v", err)
+			} else {
+				logger.Log.Infof("Purged %d expired external API cache entries", n)
+			}
+		}()
+		store = externalapi.NewTieredCache(memory, postgres)
+	default:
+		if backend != "memory" {
+			logger.Log.Warnf("Unknown external API cache backend %q, using memory", backend)
+		}
+		store = memory
+	}
+
+	return externalapi.NewCachedClient(providers, store, externalapi.CacheConfig{
+		TTL:         viper.GetDuration("EXTERNAL_API_CACHE_TTL"),
+		NegativeTTL: viper.GetDuration("EXTERNAL_API_CACHE_NEGATIVE_TTL"),
+	}), nil
+}
+
+// newProviderRegistry регистрирует источники данных о песнях, доступные в конфигурации
+func newProviderRegistry() *externalapi.Registry {
+	registry := externalapi.NewRegistry()
+
+	// music-info API
+	registry.Register("http", func() (externalapi.ExternalAPI, error) {
+		apiClient := externalapi.NewExternalAPIClient(
+			viper.GetString("EXTERNAL_API_FULL_URL"),
+			viper.GetString("EXTERNAL_API_METHOD"),
+			viper.GetDuration("EXTERNAL_API_TIMEOUT"),
+		)
+
+		// Повторы и размыкатель цепи защищают от сбоев внешнего API
+		resilience := externalapi.DefaultResilienceConfig()
+		resilience.MaxRetries = viper.GetInt("EXTERNAL_API_MAX_RETRIES")
+		resilience.FailureThreshold = viper.GetInt("EXTERNAL_API_BREAKER_THRESHOLD")
+		resilience.OpenTimeout = viper.GetDuration("EXTERNAL_API_BREAKER_TIMEOUT")
+		return externalapi.NewResilientClient("http", apiClient, resilience), nil
+	})
+
+	// Локальный каталог в JSON или CSV
+	registry.Register("catalog", func() (externalapi.ExternalAPI, error) {
+		return externalapi.LoadCatalog(viper.GetString("EXTERNAL_API_CATALOG_FILE"))
+	})
+
+	// Песня из примера контракта music-info API, позволяет запускать сервис без внешнего API
+	registry.Register("fixture", func() (externalapi.ExternalAPI, error) {
+		return externalapi.NewFixtureProvider(externalapi.FixtureSong{
+			Group: "Muse",
+			Song:  "Supermassive Black Hole",
+			Detail: models.SongDetail{
+				ReleaseDate: "16.07.2006",
+				Text:        "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight",
+				Link:        "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
+			},
+		}), nil
+	})
+
+	return registry
+}
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"database/sql"
+	"expvar"
 	"net/http"
 	"strconv"
 
@@ -11,73 +12,166 @@ import (
 	"online-library/internal/repository"
 
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 )
 
-// NewRouter создает маршрутизатор для всех эндпоинтов
-func NewRouter(db *sql.DB) *http.ServeMux {
-	mux := http.NewServeMux()
-
-	//
-	repo := repository.NewPostgresSongRepository(db)
+// NewRouter создает маршрутизатор для всех эндпоинтов.
+// externalAPI разделяется с фоновой загрузкой данных, чтобы у них был общий размыкатель цепи.
+// Каждому запросу присваивается ID, ошибки отдаются в формате application/problem+json.
+func NewRouter(db *sql.DB, externalAPI externalapi.ExternalAPI) http.Handler {
+	return NewHandler(repository.NewPostgresSongRepository(db), repository.NewPostgresArtistRepository(db), externalAPI)
+}
 
-	//
-	externalAPI := externalapi.NewExternalAPIClient(viper.GetString("EXTERNAL_API_FULL_URL"), viper.GetString("EXTERNAL_API_METHOD"))
+// NewHandler создает маршрутизатор поверх готовых репозиториев
+func NewHandler(repo repository.SongRepository, artistRepo repository.ArtistRepository, externalAPI externalapi.ExternalAPI) http.Handler {
+	mux := http.NewServeMux()
 
 	// Инициализация обработчиков
 	songHandler := handlers.NewSongHandler(repo, externalAPI)
+	artistHandler := handlers.NewArtistHandler(artistRepo)
 
 	// Определение маршрутов
-	mux.HandleFunc("/songs", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("GET /songs", songHandler.GetSongs)
+	mux.HandleFunc("POST /songs", songHandler.AddSong)
+	mux.HandleFunc("GET /songs/search", songHandler.SearchSongs)
+	mux.HandleFunc("POST /songs/import", songHandler.ImportSongs)
+	mux.HandleFunc("GET /songs/export", songHandler.ExportSongs)
+	mux.HandleFunc("POST /songs/batch", songHandler.BatchSongs)
+	mux.HandleFunc("GET /songs/trash", songHandler.GetTrash)
+	mux.HandleFunc("GET /songs/{id}", songHandler.GetSong)
+	mux.HandleFunc("GET /songs/{id}/lyrics", songHandler.GetSongLyrics)
+	mux.HandleFunc("GET /songs/{id}/lyrics/stanzas/{index}", songHandler.GetLyricsStanza)
+	mux.HandleFunc("GET /songs/{id}/lyrics/lines", songHandler.GetLyricsLines)
+	mux.HandleFunc("PUT /songs/{id}/lyrics/synced", songHandler.PutSyncedLyrics)
+	mux.HandleFunc("DELETE /songs/{id}/lyrics/synced", songHandler.DeleteSyncedLyrics)
+	mux.HandleFunc("GET /songs/{id}/translations", songHandler.GetTranslations)
+	mux.HandleFunc("PUT /songs/{id}/translations/{lang}", songHandler.PutTranslation)
+	mux.HandleFunc("DELETE /songs/{id}/translations/{lang}", songHandler.DeleteTranslation)
+	mux.HandleFunc("PUT /songs/{id}", songHandler.UpdateSong)
+	mux.HandleFunc("PATCH /songs/{id}", songHandler.PatchSong)
+	mux.HandleFunc("DELETE /songs/{id}", songHandler.DeleteSong)
+	mux.HandleFunc("POST /songs/{id}/enrichment", songHandler.RequeueEnrichment)
+	mux.HandleFunc("POST /songs/{id}/restore", songHandler.RestoreSong)
+	mux.HandleFunc("GET /songs/{id}/revisions", songHandler.GetSongRevisions)
+	mux.HandleFunc("GET /songs/{id}/revisions/diff", songHandler.DiffSongRevisions)
+	mux.HandleFunc("POST /songs/{id}/revisions/{revision}/revert", songHandler.RevertSong)
 
-		switch r.Method {
-		case http.MethodGet:
-			songHandler.GetSongs(w, r)
-		case http.MethodPost:
-			songHandler.AddSong(w, r)
-		default:
-			logger.Log.WithFields(logrus.Fields{
-				"method": r.Method,
-				"path":   r.URL.Path,
-			}).Warn("Method not allowed")
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
-
-	mux.HandleFunc("/songs/", func(w http.ResponseWriter, r *http.Request) {
-		query := r.URL.Query()
-		// Получение ID песни из URL
-		songIDStr := query.Get("id")
+	// Устаревшая форма /songs/?id=, оставлена для старых клиентов
+	mux.HandleFunc("/songs/{$}", func(w http.ResponseWriter, r *http.Request) {
+		// Получение ID песни из query-параметра
+		songIDStr := r.URL.Query().Get("id")
 		if songIDStr == "" {
 			logger.Log.Warn("Missing query id parameter")
-			http.Error(w, "Missing query id parameter", http.StatusBadRequest)
+			handlers.WriteProblem(w, r, http.StatusBadRequest, handlers.CodeInvalidParameter, "Missing query id parameter")
 			return
 		}
 
 		songID, err := strconv.Atoi(songIDStr)
 		if err != nil || songID <= 0 {
 			logger.Log.Warn("Invalid query id parameter")
-			http.Error(w, "Invalid query id parameter", http.StatusBadRequest)
+			handlers.WriteProblem(w, r, http.StatusBadRequest, handlers.CodeInvalidID, "Invalid query id parameter")
 			return
 		}
 
-		logger.Log.Debugf("Parsed song ID from query: %d", songID)
+		logger.Log.WithFields(logrus.Fields{
+			"method": r.Method,
+			"id":     songID,
+		}).Warn("Deprecated query form of /songs/ used")
+
+		// Обработчики читают ID из пути, поэтому переносим его туда
+		r.SetPathValue("id", songIDStr)
+		successor := "/songs/" + songIDStr
 
 		switch r.Method {
 		case http.MethodGet:
+			successor += "/lyrics"
+			setDeprecation(w, successor)
 			songHandler.GetSongLyrics(w, r)
 		case http.MethodPut:
+			setDeprecation(w, successor)
 			songHandler.UpdateSong(w, r)
 		case http.MethodDelete:
+			setDeprecation(w, successor)
 			songHandler.DeleteSong(w, r)
 		default:
 			logger.Log.WithFields(logrus.Fields{
 				"method": r.Method,
 				"path":   r.URL.Path,
 			}).Warn("Method not allowed")
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			handlers.WriteProblem(w, r, http.StatusMethodNotAllowed, handlers.CodeMethodNotAllowed, "Method not allowed")
 		}
 	})
 
+	// Артисты и их дискография
+	mux.HandleFunc("GET /artists", artistHandler.GetArtists)
+	mux.HandleFunc("POST /artists", artistHandler.AddArtist)
+	mux.HandleFunc("GET /artists/{id}", artistHandler.GetArtist)
+	mux.HandleFunc("PUT /artists/{id}", artistHandler.UpdateArtist)
+	mux.HandleFunc("DELETE /artists/{id}", artistHandler.DeleteArtist)
+
+	return handlers.RequestID(withProblems(mux))
+}
+
+// NewAdminHandler создает маршрутизатор служебного порта: метрики, в том числе состояние размыкателей
+// внешнего API. Он не входит в публичный API и запускается на отдельном адресе ADMIN_ADDR.
+func NewAdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /debug/vars", expvar.Handler())
 	return mux
 }
+
+// withProblems заменяет текстовые ответы ServeMux на неизвестный путь (404) и метод (405)
+// ответами в формате application/problem+json
+func withProblems(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := mux.Handler(r); pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		// Статус и заголовок Allow определяет сам ServeMux, тело ответа отбрасывается
+		recorder := &statusRecorder{header: make(http.Header)}
+		mux.ServeHTTP(recorder, r)
+		if allow := recorder.header.Get("Allow"); allow != "" {
+			w.Header().Set("Allow", allow)
+		}
+
+		logger.Log.WithFields(logrus.Fields{
+			"method": r.Method,
+			"path":   r.URL.Path,
+			"status": recorder.status,
+		}).Warn("No route for request")
+
+		code := handlers.CodeNotFound
+		if recorder.status == http.StatusMethodNotAllowed {
+			code = handlers.CodeMethodNotAllowed
+		}
+		handlers.WriteProblem(w, r, recorder.status, code, "No route for "+r.Method+" "+r.URL.Path)
+	})
+}
+
+// statusRecorder запоминает статус и заголовки ответа, не записывая тело
+type statusRecorder struct {
+	header http.Header
+	status int
+}
+
+func (s *statusRecorder) Header() http.Header { return s.header }
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return len(b), nil
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+}
+
+// setDeprecation помечает ответ как устаревший и указывает актуальный адрес ресурса
+func setDeprecation(w http.ResponseWriter, successor string) {
+	w.Header().Set("Deprecation", "true")
+	w.Header().Set("Link", "<"+successor+">; rel=\"successor-version\"")
+}
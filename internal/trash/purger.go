@@ -0,0 +1,48 @@
+package trash
+
+import (
+	"context"
+	"online-library/internal/logger"
+	"online-library/internal/repository"
+	"time"
+)
+
+// Purger периодически удаляет из корзины песни, срок хранения которых истек
+type Purger struct {
+	repo      repository.TrashRepository
+	retention time.Duration
+	interval  time.Duration
+}
+
+// NewPurger создает задачу очистки корзины: песни хранятся retention и удаляются раз в interval
+func NewPurger(repo repository.TrashRepository, retention time.Duration, interval time.Duration) *Purger {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &Purger{repo: repo, retention: retention, interval: interval}
+}
+
+// Run очищает корзину сразу и затем раз в interval, пока не будет отменен ctx
+func (p *Purger) Run(ctx context.Context) {
+	if p.retention <= 0 {
+		// Такой срок хранения означал бы удаление всей корзины, конфигурация должна была его отклонить
+		logger.Log.Errorf("Trash purger disabled: retention %v is not positive", p.retention)
+		return
+	}
+	logger.Log.Infof("Trash purger started: retention %v, interval %v", p.retention, p.interval)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := p.repo.PurgeDeletedSongs(p.retention); err != nil {
+			logger.Log.Errorf("Failed to purge trash: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Log.Info("Trash purger stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
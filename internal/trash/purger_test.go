@@ -0,0 +1,77 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubRepo запоминает сроки хранения, с которыми вызывалась очистка
+type stubRepo struct {
+	mu         sync.Mutex
+	retentions []time.Duration
+	err        error
+}
+
+func (s *stubRepo) PurgeDeletedSongs(retention time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retentions = append(s.retentions, retention)
+	return 1, s.err
+}
+
+func (s *stubRepo) calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.retentions)
+}
+
+func TestPurgerRunsImmediatelyAndOnInterval(t *testing.T) {
+	// Ошибка очистки не останавливает задачу
+	repo := &stubRepo{err: errors.New("database is down")}
+	purger := NewPurger(repo, 24*time.Hour, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		purger.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for repo.calls() < 3 {
+		select {
+		case <-deadline:
+			t.Fatalf("purger ran %d times, want at least 3", repo.calls())
+		case <-time.After(time.Millisecond):
+		}
+	}
+	cancel()
+	<-done
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for _, retention := range repo.retentions {
+		if retention != 24*time.Hour {
+			t.Errorf("retention = %v, want 24h", retention)
+		}
+	}
+}
+
+func TestPurgerRefusesNonPositiveRetention(t *testing.T) {
+	for _, retention := range []time.Duration{0, -time.Hour} {
+		repo := &stubRepo{}
+		NewPurger(repo, retention, time.Millisecond).Run(context.Background())
+		if repo.calls() != 0 {
+			t.Errorf("retention %v: trash purged %d times, want 0", retention, repo.calls())
+		}
+	}
+}
+
+func TestNewPurgerDefaultsInterval(t *testing.T) {
+	if p := NewPurger(&stubRepo{}, time.Hour, 0); p.interval != time.Hour {
+		t.Errorf("interval = %v, want 1h", p.interval)
+	}
+}
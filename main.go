@@ -1,12 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"online-library/config"
 	"online-library/internal/database"
+	"online-library/internal/enrichment"
 	"online-library/internal/logger"
+	"online-library/internal/repository"
 	"online-library/internal/routes"
+	"online-library/internal/trash"
 )
 
 func main() {
@@ -33,11 +43,72 @@ func main() {
 		logger.Log.Fatalf("Failed to apply migrations: %v", err)
 	}
 
+	// Сервис останавливается по SIGINT и SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Клиент внешнего API общий для обработчиков и фоновой загрузки данных
+	externalAPI, err := routes.NewExternalAPI(db)
+	if err != nil {
+		logger.Log.Fatalf("Failed to configure external API: %v", err)
+	}
+
+	// Запуск фоновой загрузки данных песен
+	enrichmentCfg := enrichment.DefaultConfig()
+	enrichmentCfg.Workers = cfg.EnrichmentWorkers
+	enrichmentCfg.PollInterval = cfg.EnrichmentPollInterval
+	enrichmentCfg.MaxAttempts = cfg.EnrichmentMaxAttempts
+	enrichmentCfg.RetryBackoff = cfg.EnrichmentRetryBackoff
+	pool := enrichment.NewPool(repository.NewPostgresSongRepository(db), externalAPI, enrichmentCfg)
+
+	// Очистка корзины от песен с истекшим сроком хранения
+	purger := trash.NewPurger(repository.NewPostgresSongRepository(db), cfg.TrashRetention, cfg.TrashPurgeInterval)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		pool.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		purger.Run(ctx)
+	}()
+
 	// Инициализация маршрутов
-	router := routes.NewRouter(db)
+	router := routes.NewRouter(db, externalAPI)
 
 	// Запуск HTTP-сервера
-	logger.Log.Infof("Starting server on port %s...", cfg.ServerPort)
-	logger.Log.Fatal(http.ListenAndServe(":"+cfg.ServerPort, router))
+	server := &http.Server{Addr: ":" + cfg.ServerPort, Handler: router}
+	go func() {
+		logger.Log.Infof("Starting server on port %s...", cfg.ServerPort)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Log.Fatal(err)
+		}
+	}()
+
+	// Служебный сервер с метриками слушает отдельный адрес, по умолчанию только localhost
+	servers := []*http.Server{server}
+	if cfg.AdminAddr != "" {
+		adminServer := &http.Server{Addr: cfg.AdminAddr, Handler: routes.NewAdminHandler()}
+		servers = append(servers, adminServer)
+		go func() {
+			logger.Log.Infof("Starting admin server on %s...", cfg.AdminAddr)
+			if err := adminServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Log.Fatal(err)
+			}
+		}()
+	}
 
+	<-ctx.Done()
+	logger.Log.Info("Shutting down...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	for _, srv := range servers {
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			logger.Log.Errorf("Failed to shut down server %s: %v", srv.Addr, err)
+		}
+	}
+	wg.Wait()
 }